@@ -0,0 +1,1506 @@
+// Package cli implements the git-hotspots command line, shared by its entry points.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"git-hotspots/internal/config"
+	"git-hotspots/internal/git"
+	"git-hotspots/pkg/report"
+	"git-hotspots/pkg/ui"
+
+	"golang.org/x/term"
+)
+
+// Run runs git-hotspots with the given command-line arguments, excluding the
+// program name, reading commit hashes for --commits-from - from stdin, which
+// reads as empty if nil, and writing output to stdout and errors to stderr.
+// It returns the exit status. If the first argument is serve, it runs the
+// serve subcommand instead, answering HTTP requests for reports until
+// interrupted.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) (code int) {
+	// Serve reports over HTTP instead if requested
+	if len(args) > 0 && args[0] == "serve" {
+		return runServe(args[1:], stdout, stderr)
+	}
+
+	// Parse flags
+	opts := &options{}
+	flags := newFlagSet(opts, stderr)
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	// Determine the repository path
+	repoPath := "."
+	if flags.NArg() > 0 {
+		repoPath = flags.Arg(0)
+	}
+
+	// Apply defaults from config files for flags not set on the command line,
+	// before anything depends on them, such as merge or separate. A
+	// repository given by URL has no config file until it's cloned below
+	configApplied := false
+	if !git.IsRemoteURL(repoPath) {
+		if err := applyConfig(flags, configRoot(repoPath)); err != nil {
+			fmt.Fprintf(stdout, "Error loading config: %v\n", err)
+			return 1
+		}
+		configApplied = true
+	}
+
+	// With --merge or --separate every argument is a repository
+	multiRepo := opts.multiRepo()
+	repoPaths := append([]string(nil), flags.Args()...)
+	if len(repoPaths) == 0 {
+		repoPaths = []string{"."}
+	}
+
+	// Otherwise an optional second argument restricts analysis to a subpath
+	if !multiRepo && flags.NArg() > 2 {
+		fmt.Fprintln(stdout, "Error: too many arguments; use --merge or --separate to analyze several repositories.")
+		return 1
+	}
+	if !multiRepo && flags.NArg() > 1 {
+		if opts.subpath != "" {
+			fmt.Fprintln(stdout, "Error: specify the subpath either as an argument or with --path, not both.")
+			return 1
+		}
+		opts.subpath = flags.Arg(1)
+	}
+
+	// Clone repositories given by URL into temporary directories, removed
+	// on exit unless --keep-clone is set
+	for i, arg := range repoPaths {
+		if !git.IsRemoteURL(arg) || (i > 0 && !multiRepo) {
+			continue
+		}
+		tmpDir, err := os.MkdirTemp("", "git-hotspots-")
+		if err != nil {
+			fmt.Fprintf(stdout, "Error creating a directory to clone into: %v\n", err)
+			return 1
+		}
+		if opts.keepClone {
+			defer fmt.Fprintf(stderr, "Kept the clone of %s in %s\n", arg, tmpDir)
+		} else {
+			defer os.RemoveAll(tmpDir)
+		}
+
+		// Name the clone after the repository, so merged paths are prefixed with it
+		dir := filepath.Join(tmpDir, remoteName(arg))
+		fmt.Fprintf(stderr, "Cloning %s...\n", arg)
+		if err := git.Clone(arg, dir, os.Getenv("GIT_TOKEN"), nil); err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", err)
+			return 1
+		}
+		repoPaths[i] = dir
+		if i == 0 {
+			repoPath = dir
+		}
+	}
+
+	// Resolve the absolute path
+	absoluteRepoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error resolving path: %v\n", err)
+		return 1
+	}
+
+	// Check if it's a Git repository. With several repositories, failures
+	// are reported per repository after the others have been analyzed.
+	if !multiRepo && !git.IsGitRepository(absoluteRepoPath) {
+		fmt.Fprintf(stdout, "Error: %s is not a Git repository.\n", absoluteRepoPath)
+		return 1
+	}
+
+	// Find the repository root, which may be above the given path
+	repoRoot, err := git.RepositoryRoot(absoluteRepoPath)
+	if err != nil && !multiRepo {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	} else if err != nil {
+		repoRoot = absoluteRepoPath
+	}
+
+	// Apply the config files of a repository cloned from a URL
+	if !configApplied {
+		if err := applyConfig(flags, repoRoot); err != nil {
+			fmt.Fprintf(stdout, "Error loading config: %v\n", err)
+			return 1
+		}
+	}
+
+	// Test mode writes JSON so tests can check exact values
+	if opts.testMode && opts.format == "ui" {
+		opts.format = "json"
+	}
+
+	// The UI needs a terminal, so fall back to plain tables when piped or in CI
+	if opts.format == "ui" && !isTerminal(stdout) {
+		opts.format = "table"
+	}
+
+	if err := validateFlags(opts); err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	}
+
+	a, ok := newAnalysis(opts, repoRoot, repoPaths, stdin, stdout, stderr)
+	if !ok {
+		return 1
+	}
+
+	// Profile the analysis if requested, until the UI starts or Run returns
+	a.prof, err = startProfiling(opts.cpuProfile, opts.memProfile)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	}
+	defer a.prof.stop(stderr)
+
+	// Describe the commits that would be analyzed instead of analyzing them if requested
+	if opts.explain {
+		return a.runExplain()
+	}
+
+	// Show the history of a single file instead of ranking hotspots if requested
+	if opts.file != "" {
+		return a.runFileHistory()
+	}
+
+	// Once the output is done, report skipped commits, hotspots over the
+	// thresholds and repositories that couldn't be analyzed on stderr, so
+	// JSON output stays parseable, and fail if there were any
+	a.trackCommits()
+	defer func() {
+		if a.printProblems() {
+			code = 1
+		}
+	}()
+
+	// Show each repository's hotspots separately if requested
+	if opts.separate {
+		return a.runSeparate()
+	}
+
+	// Results from a sample are only an estimate
+	if opts.sample > 0 && opts.sample < 1 {
+		fmt.Fprintf(stderr, "Note: analyzing a random %g%% of commits, so results are an estimate.\n", opts.sample*100)
+	}
+
+	// Only count the analyzed commits, files and authors if requested
+	if opts.countOnly {
+		return a.runCountOnly()
+	}
+
+	// Modes reading the commits rather than hotspots
+	switch opts.mode {
+	case "knowledge-map":
+		return a.runKnowledgeMap()
+	case "contributors":
+		return a.runContributors()
+	}
+
+	// Identify hotspots
+	fileHotspots, dirHotspots, err := a.identify(a.analyzeOptions)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error analyzing commits: %v\n", err)
+		return 1
+	}
+	a.exceeding = a.thresholds.Exceeding(append(fileHotspots, dirHotspots...))
+
+	switch opts.mode {
+	case "trend":
+		return a.runTrend(fileHotspots)
+	case "heatmap":
+		return a.runHeatmap(fileHotspots)
+	case "tree":
+		return a.runTree(fileHotspots)
+	case "defects":
+		return a.runDefects(fileHotspots)
+	case "ownership-changes":
+		return a.runOwnershipChanges(fileHotspots)
+	}
+
+	// Keep the report current until interrupted if requested
+	if opts.watch {
+		return a.runWatch(fileHotspots, dirHotspots)
+	}
+	return a.runReport(fileHotspots, dirHotspots)
+}
+
+// analysis holds what Run needs to analyze repositories and report on them
+// once the flags are validated, and the problems to report on stderr.
+type analysis struct {
+	opts           *options
+	stdout, stderr io.Writer
+	prof           *profiler
+
+	repoRoot  string
+	repoPaths []string
+	now       time.Time
+
+	analyzeOptions git.AnalyzeOptions
+	hotspotOptions git.HotspotOptions
+	reportOptions  report.Options
+	thresholds     git.Thresholds
+
+	// summary describes the analyzed commits for JSON output, from
+	// windowSince, the start of the window: Since, or the first commit for
+	// listed commits, which date bounds don't apply to
+	summary     report.Summary
+	windowSince time.Time
+
+	// roots maps the names qualifying merged paths to their repository roots
+	roots map[string]string
+
+	skippedCommits int
+	limitReached   bool
+	commitIssues   git.CommitIssues
+	unreadable     []string
+	exceeding      []git.Hotspot
+	repoErrors     []error
+}
+
+// newAnalysis prepares the options to analyze the repository at repoRoot,
+// or the repositories at repoPaths with --merge or --separate, as opts
+// requests, reading commit hashes for --commits-from - from stdin. It
+// reports any error on stdout and returns false then.
+func newAnalysis(opts *options, repoRoot string, repoPaths []string, stdin io.Reader, stdout, stderr io.Writer) (*analysis, bool) {
+	a := &analysis{
+		opts:      opts,
+		stdout:    stdout,
+		stderr:    stderr,
+		repoRoot:  repoRoot,
+		repoPaths: repoPaths,
+		now:       time.Now(),
+		roots:     make(map[string]string),
+	}
+
+	// Warn that shallow clones only cover part of the history, or refuse them if requested
+	checkPaths := []string{repoRoot}
+	if opts.multiRepo() {
+		checkPaths = repoPaths
+	}
+	for _, checkPath := range checkPaths {
+		// Repositories that can't be opened are reported later
+		if shallow, err := git.IsShallow(checkPath); err != nil || !shallow {
+			continue
+		}
+		if opts.requireFullHistory {
+			fmt.Fprintf(stdout, "Error: %s is a shallow clone; fetch the full history with git fetch --unshallow.\n", checkPath)
+			return nil, false
+		}
+		fmt.Fprintf(stderr, "Warning: %s is a shallow clone, so hotspots only cover the fetched history and may be incomplete.\n", checkPath)
+	}
+
+	// Merge author identities if requested
+	var aliases *git.Aliases
+	var err error
+	if len(opts.aliasFlags) > 0 || opts.aliasesFile != "" {
+		aliases, err = readAliases(opts.aliasFlags, opts.aliasesFile)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error reading aliases: %v\n", err)
+			return nil, false
+		}
+	}
+
+	// Skip the commits of bots and other excluded authors if requested
+	var excludeAuthors *git.AuthorFilter
+	if len(opts.excludeAuthorFlags) > 0 || opts.excludeAuthorsFile != "" {
+		excludeAuthors, err = readAuthorFilter(opts.excludeAuthorFlags, opts.excludeAuthorsFile)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error reading excluded authors: %v\n", err)
+			return nil, false
+		}
+	}
+
+	a.analyzeOptions = git.AnalyzeOptions{
+		Since:             git.DefaultSince(a.now),
+		Path:              opts.subpath,
+		MaxFilesPerCommit: opts.maxFilesPerCommit,
+		IncludeSubmodules: opts.includeSubmodules,
+		CountLines:        opts.countLines,
+		IgnoreWhitespace:  opts.ignoreWhitespace,
+		CacheSize:         opts.cacheSize,
+		Extensions:        opts.extensions,
+		Sample:            opts.sample,
+		Seed:              opts.seed,
+		LimitCommits:      opts.limitCommits,
+		Aliases:           aliases,
+		ExcludeAuthors:    excludeAuthors,
+		AllRefs:           opts.allRefs,
+		Remotes:           opts.remotes,
+		SkipRootCommits:   opts.excludeInitialCommit,
+		Backend:           git.Backend(opts.backend),
+		RespectGitIgnore:  opts.respectGitIgnore,
+		MergeStrategy:     git.MergeStrategy(opts.mergeStrategy),
+	}
+
+	// Analyze exactly the listed commits if requested
+	if opts.commitsFrom != "" {
+		a.analyzeOptions.Hashes, err = readCommitHashes(opts.commitsFrom, stdin)
+		if err == nil && len(a.analyzeOptions.Hashes) == 0 {
+			err = fmt.Errorf("no commit hashes in %s", opts.commitsFrom)
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "Error reading commits: %v\n", err)
+			return nil, false
+		}
+	}
+	a.windowSince = a.analyzeOptions.Since
+	if len(a.analyzeOptions.Hashes) > 0 {
+		a.windowSince = time.Time{}
+	}
+
+	a.hotspotOptions = git.HotspotOptions{
+		NormalizeByCommitSize: opts.normalizeByCommitSize,
+		MinCommits:            opts.minCommits,
+		SkipFiles:             opts.noFiles,
+		SkipDirs:              opts.noDirs,
+		ExcludeBursts:         opts.excludeBursts,
+		MaxIdle:               opts.maxIdle,
+		ActiveWithin:          opts.activeWithin,
+		RankBy:                git.Ranking(opts.rankBy),
+		ScoreExpr:             opts.scoreExpr,
+		Now:                   a.now,
+		CountCoAuthors:        opts.countCoAuthors,
+		OwnerThreshold:        opts.ownerThreshold,
+		TieBreak:              git.TieBreak(opts.tieBreak),
+		RootLabel:             opts.rootLabel,
+		CaseInsensitivePaths:  opts.caseInsensitivePaths,
+		FirstCommitAsCreation: opts.firstCommitAsCreation,
+		IgnoreDeletions:       opts.ignoreDeletions,
+		ExcludeTests:          opts.testPatterns,
+		TagWeights:            opts.tagWeights,
+		DefectPattern:         opts.defects,
+	}
+	if opts.extrapolate {
+		a.hotspotOptions.Extrapolate = opts.sample
+	}
+
+	// Group files by component instead of directory if a mapping is given
+	if opts.componentsFile != "" {
+		a.hotspotOptions.Components, err = config.LoadComponents(opts.componentsFile)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading components: %v\n", err)
+			return nil, false
+		}
+	}
+
+	// Group files by the Go module they belong to if requested
+	if opts.group == "go-module" {
+		a.hotspotOptions.Components, err = git.GoModuleComponents(repoRoot, opts.subpath)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error finding Go modules: %v\n", err)
+			return nil, false
+		}
+	}
+
+	// Count the files under each directory in HEAD to normalize by if requested
+	if opts.normalizeDirBySize {
+		files, err := headFiles(repoRoot, a.analyzeOptions)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", err)
+			return nil, false
+		}
+		a.hotspotOptions.DirSizes = git.CountDirFiles(files, a.hotspotOptions)
+	}
+
+	// Count the lines of each file in HEAD to turn churn into a change rate
+	// if requested
+	if opts.normalizeChurnBySize {
+		a.hotspotOptions.FileLines, err = headFileLines(repoRoot, a.analyzeOptions)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", err)
+			return nil, false
+		}
+	}
+	a.reportOptions = report.Options{
+		Since:        a.analyzeOptions.Since,
+		TopCount:     opts.topCount,
+		WithGravatar: opts.withGravatar,
+		DateFormat:   opts.dateFormat,
+		NoColor:      opts.noColor || os.Getenv("NO_COLOR") != "",
+		NoFiles:      opts.noFiles,
+		NoDirs:       opts.noDirs,
+		Reverse:      opts.reverse,
+		Compact:      opts.compact,
+		RepoURL:      opts.repoURL,
+	}
+
+	// Keep every commit of each hotspot only for the views reading them,
+	// watching included as it runs past now, and otherwise count the
+	// commits for activity sparklines as they're analyzed
+	a.hotspotOptions.KeepCommits = opts.format == "ui" || opts.watch || opts.mode == "trend" || opts.mode == "heatmap" || opts.mode == "ownership-changes"
+	a.hotspotOptions.ActivitySince = a.reportOptions.WindowStart(a.now)
+	a.hotspotOptions.ActivityBuckets = report.ActivityBuckets
+	// Paths are relative to the subpath, so link them under it, or under
+	// its directory if it names a single file reported by its base name
+	if sub := strings.Trim(path.Clean(filepath.ToSlash(opts.subpath)), "/"); opts.repoURL != "" && sub != "" && sub != "." {
+		head, err := git.OpenHeadTree(repoRoot, sub)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", err)
+			return nil, false
+		}
+		if head.IsFile() {
+			sub = path.Dir(sub)
+		}
+		if sub != "." {
+			a.reportOptions.RepoURL = report.FileURL(opts.repoURL, sub)
+		}
+	}
+	switch git.Ranking(opts.rankBy) {
+	case git.RankByChurn:
+		// A change rate is only in the score, so rank by it as for commits
+		if !opts.normalizeChurnBySize {
+			a.reportOptions.Metric = report.MetricChurn
+		}
+	case git.RankByReverts:
+		a.reportOptions.Metric = report.MetricReverts
+	}
+	a.thresholds = git.Thresholds{
+		Commits: opts.failIfCommits,
+		Score:   opts.failIfScore,
+	}
+
+	a.summary = report.Summary{
+		Repositories: []string{repoRoot},
+		Version:      toolVersion(),
+		Since:        a.windowSince,
+	}
+	if opts.sample > 0 && opts.sample < 1 {
+		a.summary.Sample = opts.sample
+		a.summary.Extrapolated = opts.extrapolate
+	}
+	if opts.limitCommits > 0 {
+		a.summary.LimitCommits = opts.limitCommits
+	}
+	return a, true
+}
+
+// trackCommits counts the commits skipped for touching too many files and
+// those that couldn't be fully read, noting which had a parent missing so
+// they can be looked into, and whether the commit limit left older commits
+// out, truncating the results.
+func (a *analysis) trackCommits() {
+	a.analyzeOptions.OnLargeCommit = func(git.CommitInfo) {
+		a.skippedCommits++
+	}
+	a.analyzeOptions.OnLimitReached = func() {
+		a.limitReached = true
+		a.summary.Truncated = true
+	}
+	a.analyzeOptions.OnCommitIssue = func(commit git.CommitInfo, issue git.CommitIssue) {
+		a.commitIssues.Add(issue)
+		if issue == git.UnreadableParent {
+			a.unreadable = append(a.unreadable, commit.Hash)
+		}
+	}
+}
+
+// resetCommits forgets the commits counted by trackCommits, before the
+// history is analyzed again.
+func (a *analysis) resetCommits() {
+	a.skippedCommits, a.commitIssues, a.unreadable = 0, git.CommitIssues{}, nil
+}
+
+// printProblems prints the skipped commits, hotspots over the thresholds
+// and repositories that couldn't be analyzed on stderr, and reports whether
+// any of them should fail the run.
+func (a *analysis) printProblems() bool {
+	if a.skippedCommits > 0 {
+		fmt.Fprintf(a.stderr, "\nSkipped %d commits touching more than %d files\n", a.skippedCommits, a.opts.maxFilesPerCommit)
+	}
+	if a.limitReached {
+		fmt.Fprintf(a.stderr, "\nNote: only the %d most recent commits were analyzed, so results are truncated.\n", a.opts.limitCommits)
+	}
+	printIssues(a.stderr, a.commitIssues, a.unreadable)
+	if len(a.exceeding) > 0 {
+		fmt.Fprintln(a.stderr, "\nHotspots over the threshold:")
+		for _, h := range a.exceeding {
+			fmt.Fprintf(a.stderr, "- %s: %d commits, score %.2f\n", report.QuotePath(h.Path), h.Commits, h.Score)
+		}
+	}
+	if len(a.repoErrors) > 0 {
+		fmt.Fprintln(a.stderr, "\nFailed to analyze some repositories:")
+		for _, err := range a.repoErrors {
+			fmt.Fprintf(a.stderr, "- %v\n", err)
+		}
+	}
+	return len(a.exceeding) > 0 || len(a.repoErrors) > 0
+}
+
+// analyze returns the commits to report on, merging all repositories if requested.
+func (a *analysis) analyze(opts git.AnalyzeOptions) ([]git.CommitInfo, error) {
+	a.resetCommits()
+	if !a.opts.merge {
+		return git.AnalyzeCommitsWithOptions(a.repoRoot, opts)
+	}
+	repos, errs := git.AnalyzeRepositories(a.repoPaths, opts)
+	a.repoErrors = errs
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("none of the repositories could be analyzed")
+	}
+	a.summary.Repositories = nil
+	for _, repo := range repos {
+		a.summary.Repositories = append(a.summary.Repositories, repo.Root)
+		a.roots[repo.Name] = repo.Root
+	}
+	return git.MergeRepositories(repos), nil
+}
+
+// identify returns the file and directory hotspots, streaming commits into
+// the accumulator for a single repository so the full history never needs
+// to be held in memory.
+func (a *analysis) identify(opts git.AnalyzeOptions) ([]git.Hotspot, []git.Hotspot, error) {
+	subpath, hotspotOptions := a.opts.subpath, a.hotspotOptions
+	if a.opts.merge {
+		commits, err := a.analyze(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		fileHotspots, dirHotspots := git.IdentifyHotspotsWithOptions(commits, hotspotOptions)
+		a.summary.Summary = git.Summarize(commits)
+		a.summary.Issues = a.commitIssues
+		if a.opts.onlyExisting {
+			heads := make(map[string]*git.HeadTree)
+			for name, root := range a.roots {
+				if heads[name], err = git.OpenHeadTree(root, subpath); err != nil {
+					return nil, nil, err
+				}
+			}
+			fileHotspots, dirHotspots = dropDeleted(fileHotspots, dirHotspots, hotspotOptions, func(p string) bool {
+				name, rest, ok := splitMergedPath(p, a.roots)
+				return ok && (rest == "" || heads[name].Exists(rest))
+			})
+		}
+		if a.opts.untouched() {
+			for name, root := range a.roots {
+				fileHotspots, dirHotspots, err = addUnchanged(fileHotspots, dirHotspots, root, a.analyzeOptions, hotspotOptions, name)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		if a.opts.pathStyle == "absolute" {
+			absoluteMergedPaths(fileHotspots, a.roots, subpath)
+			if hotspotOptions.Components == nil {
+				absoluteMergedPaths(dirHotspots, a.roots, subpath)
+			}
+		}
+		return fileHotspots, dirHotspots, nil
+	}
+
+	a.resetCommits()
+	acc := git.NewHotspotAccumulatorWithOptions(hotspotOptions)
+	err := git.AnalyzeCommitsFunc(a.repoRoot, opts, func(commit git.CommitInfo) error {
+		acc.Add(commit)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	fileHotspots, dirHotspots := acc.Result()
+	a.summary.Summary = acc.Summary()
+	a.summary.Issues = a.commitIssues
+	if a.opts.onlyExisting {
+		head, err := git.OpenHeadTree(a.repoRoot, subpath)
+		if err != nil {
+			return nil, nil, err
+		}
+		fileHotspots, dirHotspots = dropDeleted(fileHotspots, dirHotspots, hotspotOptions, head.Exists)
+	}
+	if a.opts.untouched() {
+		fileHotspots, dirHotspots, err = addUnchanged(fileHotspots, dirHotspots, a.repoRoot, a.analyzeOptions, hotspotOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if a.opts.pathStyle == "absolute" {
+		dir := filepath.Join(a.repoRoot, filepath.FromSlash(subpath))
+		absolutePaths(fileHotspots, dir, "")
+		if hotspotOptions.Components == nil {
+			absolutePaths(dirHotspots, dir, hotspotOptions.RootLabel)
+		}
+	}
+	return fileHotspots, dirHotspots, nil
+}
+
+// reanalyze returns the hotspots to show since the given time, for views
+// changing the window, counting lines if they need them.
+func (a *analysis) reanalyze(since time.Time, countLines bool) ([]git.Hotspot, []git.Hotspot, error) {
+	opts := a.analyzeOptions
+	opts.Since = since
+	opts.CountLines = opts.CountLines || countLines
+	fileHotspots, dirHotspots, err := a.identify(opts)
+	return fileHotspots, a.displayDirs(dirHotspots), err
+}
+
+// displayDirs returns the directory hotspots to show, with those nested
+// deeper than --display-depth rolled up into their ancestors. Only the
+// output is affected, not thresholds.
+func (a *analysis) displayDirs(dirHotspots []git.Hotspot) []git.Hotspot {
+	return git.RollUpDirs(dirHotspots, a.opts.displayDepth, a.hotspotOptions)
+}
+
+// display writes the output of a mode: JSON with --format json, a plain
+// table with --summary or --format table, and otherwise the UI, falling back
+// to the table if it can't start. It returns the exit status.
+func (a *analysis) display(writeJSON, writeTable, displayUI func() error) int {
+	var err error
+	if a.opts.format == "json" {
+		err = writeJSON()
+	} else if a.opts.summaryOnly || a.opts.format == "table" {
+		err = writeTable()
+	} else {
+		err = runUI(a.stderr, a.prof, displayUI, writeTable)
+	}
+	if err != nil {
+		fmt.Fprintf(a.stdout, "Error writing output: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runExplain prints the ref, date bounds and filters used to select commits
+// and how many matched, for --explain.
+func (a *analysis) runExplain() int {
+	explainPaths := []string{a.repoRoot}
+	if a.opts.multiRepo() {
+		explainPaths = a.repoPaths
+	}
+	if err := explainQuery(a.stdout, explainPaths, a.analyzeOptions, a.opts.commitsFrom); err != nil {
+		fmt.Fprintf(a.stdout, "Error explaining query: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runFileHistory shows the full history of the file given by --file.
+func (a *analysis) runFileHistory() int {
+	file := a.opts.file
+	historyOptions := a.analyzeOptions
+	historyOptions.Since = time.Time{}
+	history, err := git.FileHistory(a.repoRoot, file, historyOptions)
+	if err != nil {
+		fmt.Fprintf(a.stdout, "Error reading file history: %v\n", err)
+		return 1
+	}
+	return a.display(func() error {
+		return report.WriteFileHistoryJSON(a.stdout, file, history, a.reportOptions)
+	}, func() error {
+		return report.WriteFileHistory(a.stdout, file, history, a.reportOptions)
+	}, func() error {
+		return ui.DisplayFileHistory(file, history, a.reportOptions)
+	})
+}
+
+// runSeparate shows the hotspots of each repository separately, for
+// --separate.
+func (a *analysis) runSeparate() int {
+	opts, stdout := a.opts, a.stdout
+	repos, errs := git.AnalyzeRepositories(a.repoPaths, a.analyzeOptions)
+	a.repoErrors = errs
+
+	var results []report.RepositoryHotspots
+	for _, repo := range repos {
+		fileHotspots, dirHotspots := git.IdentifyHotspotsWithOptions(repo.Commits, a.hotspotOptions)
+		if opts.onlyExisting {
+			head, err := git.OpenHeadTree(repo.Root, opts.subpath)
+			if err != nil {
+				a.repoErrors = append(a.repoErrors, &git.RepositoryError{Path: repo.Root, Err: err})
+				continue
+			}
+			fileHotspots, dirHotspots = dropDeleted(fileHotspots, dirHotspots, a.hotspotOptions, head.Exists)
+		}
+		if opts.untouched() {
+			var err error
+			fileHotspots, dirHotspots, err = addUnchanged(fileHotspots, dirHotspots, repo.Root, a.analyzeOptions, a.hotspotOptions)
+			if err != nil {
+				a.repoErrors = append(a.repoErrors, &git.RepositoryError{Path: repo.Root, Err: err})
+				continue
+			}
+		}
+		if opts.pathStyle == "absolute" {
+			dir := filepath.Join(repo.Root, filepath.FromSlash(opts.subpath))
+			absolutePaths(fileHotspots, dir, "")
+			if a.hotspotOptions.Components == nil {
+				absolutePaths(dirHotspots, dir, a.hotspotOptions.RootLabel)
+			}
+		}
+		results = append(results, report.RepositoryHotspots{
+			Name:        repo.Name,
+			Files:       fileHotspots,
+			Directories: a.displayDirs(dirHotspots),
+			Summary: &report.Summary{
+				Summary:      git.Summarize(repo.Commits),
+				Repositories: []string{repo.Root},
+				Version:      toolVersion(),
+				Since:        a.analyzeOptions.Since,
+				Issues:       repo.Issues,
+			},
+		})
+
+		for _, h := range a.thresholds.Exceeding(append(fileHotspots, dirHotspots...)) {
+			h.Path = path.Join(repo.Name, h.Path)
+			a.exceeding = append(a.exceeding, h)
+		}
+	}
+
+	var err error
+	if opts.format == "json" {
+		err = report.WriteRepositoriesJSON(stdout, results, a.reportOptions)
+	} else if opts.format == "jsonl" {
+		err = report.WriteRepositoriesJSONLines(stdout, results, a.reportOptions)
+	} else if opts.format == "prometheus" {
+		err = report.WriteRepositoriesPrometheus(stdout, results, a.reportOptions)
+	} else if opts.summaryOnly {
+		for _, result := range results {
+			fmt.Fprintf(stdout, "Repository: %s\n", result.Name)
+			printSummary(stdout, result.Files, result.Directories, a.reportOptions)
+			fmt.Fprintln(stdout)
+		}
+	} else if opts.format == "table" {
+		err = report.WriteRepositoriesTable(stdout, results, a.reportOptions)
+	} else if len(results) > 0 {
+		err = runUI(a.stderr, a.prof, func() error {
+			return ui.DisplayRepositoryHotspots(results, a.reportOptions)
+		}, func() error {
+			return report.WriteRepositoriesTable(stdout, results, a.reportOptions)
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(stdout, "Error writing output: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runCountOnly prints the numbers of commits analyzed, distinct files
+// touched and distinct authors, streaming commits for a single repository
+// without ranking them, for --count-only.
+func (a *analysis) runCountOnly() int {
+	var totals git.Summary
+	if a.opts.merge {
+		commits, err := a.analyze(a.analyzeOptions)
+		if err != nil {
+			fmt.Fprintf(a.stdout, "Error analyzing commits: %v\n", err)
+			return 1
+		}
+		totals = git.Summarize(commits)
+	} else {
+		a.resetCommits()
+		var summarizer git.Summarizer
+		err := git.AnalyzeCommitsFunc(a.repoRoot, a.analyzeOptions, func(commit git.CommitInfo) error {
+			summarizer.Add(commit)
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(a.stdout, "Error analyzing commits: %v\n", err)
+			return 1
+		}
+		totals = summarizer.Summary()
+	}
+	var err error
+	if a.opts.format == "json" {
+		err = report.WriteCountsJSON(a.stdout, totals)
+	} else {
+		err = report.WriteCounts(a.stdout, totals)
+	}
+	if err != nil {
+		fmt.Fprintf(a.stdout, "Error writing output: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runKnowledgeMap shows who knows which parts of the repository, in
+// knowledge-map mode.
+func (a *analysis) runKnowledgeMap() int {
+	commits, err := a.analyze(a.analyzeOptions)
+	if err != nil {
+		fmt.Fprintf(a.stdout, "Error analyzing commits: %v\n", err)
+		return 1
+	}
+
+	knowledgeMap := git.BuildKnowledgeMap(commits)
+	return a.display(func() error {
+		return report.WriteKnowledgeJSON(a.stdout, knowledgeMap)
+	}, func() error {
+		return report.WriteKnowledgeTree(a.stdout, knowledgeMap)
+	}, func() error {
+		return ui.DisplayKnowledgeMap(knowledgeMap, a.reportOptions)
+	})
+}
+
+// runContributors ranks the authors of the analyzed commits, in
+// contributors mode.
+func (a *analysis) runContributors() int {
+	commits, err := a.analyze(a.analyzeOptions)
+	if err != nil {
+		fmt.Fprintf(a.stdout, "Error analyzing commits: %v\n", err)
+		return 1
+	}
+
+	contributors := git.IdentifyContributors(commits)
+	return a.display(func() error {
+		return report.WriteContributorsJSON(a.stdout, contributors, a.opts.topCount)
+	}, func() error {
+		return report.WriteContributors(a.stdout, contributors, a.opts.topCount)
+	}, func() error {
+		return ui.DisplayContributors(contributors, a.reportOptions)
+	})
+}
+
+// runTrend reports files that are cooling down or heating up, in trend mode.
+func (a *analysis) runTrend(fileHotspots []git.Hotspot) int {
+	trends := git.ComputeTrends(fileHotspots, a.windowSince, a.now, a.opts.trendSplit)
+	return a.display(func() error {
+		return report.WriteTrendsJSON(a.stdout, trends, a.opts.topCount)
+	}, func() error {
+		return report.WriteTrends(a.stdout, trends, a.opts.topCount)
+	}, func() error {
+		return ui.DisplayTrends(trends, a.reportOptions)
+	})
+}
+
+// runHeatmap grids the commits touching the top files in each period, in
+// heatmap mode.
+func (a *analysis) runHeatmap(fileHotspots []git.Hotspot) int {
+	a.reportOptions.Sort(fileHotspots)
+	top := fileHotspots
+	if len(top) > a.opts.topCount {
+		top = top[:a.opts.topCount]
+	}
+	heatmap := git.ComputeHeatmap(top, a.windowSince, a.now, git.HeatmapBucket(a.opts.heatmapBucket))
+	return a.display(func() error {
+		return report.WriteHeatmapJSON(a.stdout, heatmap)
+	}, func() error {
+		return report.WriteHeatmap(a.stdout, heatmap)
+	}, func() error {
+		return ui.DisplayHeatmap(heatmap, a.reportOptions)
+	})
+}
+
+// runTree nests file hotspots under their directories in one tree, in tree
+// mode.
+func (a *analysis) runTree(fileHotspots []git.Hotspot) int {
+	tree := git.BuildHotspotTree(fileHotspots)
+	return a.display(func() error {
+		return report.WriteHotspotTreeJSON(a.stdout, tree)
+	}, func() error {
+		return report.WriteHotspotTree(a.stdout, tree)
+	}, func() error {
+		return ui.DisplayHotspotTree(tree, a.reportOptions)
+	})
+}
+
+// runDefects ranks files by the bug-fixing commits that touched them, in
+// defects mode.
+func (a *analysis) runDefects(fileHotspots []git.Hotspot) int {
+	defects := git.RankDefects(fileHotspots)
+	return a.display(func() error {
+		return report.WriteDefectsJSON(a.stdout, defects, a.opts.topCount)
+	}, func() error {
+		return report.WriteDefects(a.stdout, defects, a.opts.topCount)
+	}, func() error {
+		return ui.DisplayDefects(defects, a.reportOptions)
+	})
+}
+
+// runOwnershipChanges reports files whose dominant author changed over the
+// window, in ownership-changes mode.
+func (a *analysis) runOwnershipChanges(fileHotspots []git.Hotspot) int {
+	changes := git.DetectOwnershipChanges(fileHotspots, a.windowSince, a.now)
+	return a.display(func() error {
+		return report.WriteOwnershipChangesJSON(a.stdout, changes, a.opts.topCount)
+	}, func() error {
+		return report.WriteOwnershipChanges(a.stdout, changes, a.opts.topCount)
+	}, func() error {
+		return ui.DisplayOwnershipChanges(changes, a.reportOptions)
+	})
+}
+
+// runWatch keeps the report current, re-running the analysis whenever HEAD
+// moves until interrupted, for --watch.
+func (a *analysis) runWatch(fileHotspots, dirHotspots []git.Hotspot) int {
+	stdout := a.stdout
+	a.prof.stop(a.stderr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	changes := git.WatchHead(ctx, a.repoRoot, a.opts.watchInterval)
+
+	var err error
+	if a.opts.format == "table" {
+		err = report.WriteTable(stdout, fileHotspots, a.displayDirs(dirHotspots), a.reportOptions)
+		for range changes {
+			if err != nil {
+				break
+			}
+			fmt.Fprintf(stdout, "\nHEAD moved, re-running at %s\n\n", time.Now().Format(time.TimeOnly))
+			if fileHotspots, dirHotspots, err = a.identify(a.analyzeOptions); err == nil {
+				a.exceeding = a.thresholds.Exceeding(append(fileHotspots, dirHotspots...))
+				err = report.WriteTable(stdout, fileHotspots, a.displayDirs(dirHotspots), a.reportOptions)
+			}
+		}
+	} else {
+		dirHotspots := a.displayDirs(dirHotspots)
+		err = runUI(a.stderr, a.prof, func() error {
+			return ui.WatchHotspots(fileHotspots, dirHotspots, a.reportOptions, a.reanalyze, changes)
+		}, func() error {
+			return report.WriteTable(stdout, fileHotspots, dirHotspots, a.reportOptions)
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runReport writes the hotspots to the output directory, in the requested
+// format or as a summary, or displays them in the UI, in hotspots mode.
+func (a *analysis) runReport(fileHotspots, dirHotspots []git.Hotspot) int {
+	opts, stdout, reportOptions := a.opts, a.stdout, a.reportOptions
+	dirHotspots = a.displayDirs(dirHotspots)
+
+	// Write every requested format to the output directory if requested
+	if opts.outputDir != "" {
+		reportOptions.Summary = &a.summary
+		if err := writeOutputDir(opts.outputDir, opts.formats, fileHotspots, dirHotspots, reportOptions); err != nil {
+			fmt.Fprintf(stdout, "Error writing output: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Write JSON or plain tables instead of launching the UI if requested
+	if opts.format != "ui" && (opts.format != "table" || !opts.summaryOnly) {
+		var err error
+		if opts.format == "sqlite" {
+			err = writeSQLite(opts.output, a.repoRoot, opts.merge, fileHotspots, dirHotspots, a.now, a.summary, reportOptions)
+		} else {
+			reportOptions.Summary = &a.summary
+			err = writeHotspots(stdout, opts.format, fileHotspots, dirHotspots, reportOptions)
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "Error writing output: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Just print a summary instead of launching the UI if requested
+	if opts.summaryOnly {
+		printSummary(stdout, fileHotspots, dirHotspots, reportOptions)
+		return 0
+	}
+
+	// Display hotspots in UI, falling back to tables if it can't start
+	err := runUI(a.stderr, a.prof, func() error {
+		return ui.DisplayHotspots(fileHotspots, dirHotspots, reportOptions, a.reanalyze)
+	}, func() error {
+		return report.WriteTable(stdout, fileHotspots, dirHotspots, reportOptions)
+	})
+	if err != nil {
+		fmt.Fprintf(stdout, "Error writing output: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeSQLite appends a snapshot of the hotspots to the SQLite database at
+// path. The run records the ref of repoRoot, which isn't meaningful when
+// several repositories are merged, so it's left empty then.
+func writeSQLite(path, repoRoot string, merged bool, fileHotspots, dirHotspots []git.Hotspot, now time.Time, summary report.Summary, opts report.Options) error {
+	run := report.Run{Time: now}
+	if !merged {
+		var err error
+		if run.Ref, run.Head, err = git.HeadRef(repoRoot); err != nil {
+			return err
+		}
+	}
+	opts.Summary = &summary
+	return report.WriteSQLite(path, fileHotspots, dirHotspots, run, opts)
+}
+
+// printSummary prints a plain-text summary of the top hotspots, of at most
+// opts.TopCount files and directories unless opts leaves either out.
+func printSummary(w io.Writer, fileHotspots, dirHotspots []git.Hotspot, opts report.Options) {
+	opts.Sort(fileHotspots)
+	opts.Sort(dirHotspots)
+
+	fmt.Fprintln(w, "Git Hotspots Analysis Summary:")
+	filesTitle, dirsTitle := "Top File Hotspots", "Top Directory Hotspots"
+	if opts.Reverse {
+		filesTitle, dirsTitle = opts.Titles()
+	}
+	displayCount := 5 // At most five of each
+	if opts.TopCount < displayCount {
+		displayCount = opts.TopCount
+	}
+
+	if !opts.NoFiles {
+		fmt.Fprintf(w, "\n%s:\n", filesTitle)
+		for i, h := range fileHotspots {
+			if i >= displayCount {
+				break
+			}
+			fmt.Fprintf(w, "- %s: %d commits (Top contributor: %s with %d commits)\n",
+				report.QuotePath(h.Path), h.Commits, h.TopContributor, h.AuthorCommits)
+		}
+	}
+
+	if !opts.NoDirs {
+		fmt.Fprintf(w, "\n%s:\n", dirsTitle)
+		for i, h := range dirHotspots {
+			if i >= displayCount {
+				break
+			}
+			fmt.Fprintf(w, "- %s: %d commits (Top contributor: %s with %d commits)\n",
+				report.QuotePath(h.Path), h.Commits, h.TopContributor, h.AuthorCommits)
+		}
+	}
+}
+
+// printIssues prints how many commits couldn't be fully read, and why, so
+// data quality problems don't go unnoticed, and lists the first few of the
+// unreadable commits, those with a parent that couldn't be read. It prints
+// nothing without issues.
+func printIssues(w io.Writer, issues git.CommitIssues, unreadable []string) {
+	if issues.Total() == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nSome commits couldn't be fully read:")
+	if issues.UnreadableParents > 0 {
+		fmt.Fprintf(w, "- %d with a parent that couldn't be read or diffed, e.g. in a shallow clone\n", issues.UnreadableParents)
+		const maxListed = 5
+		for i, hash := range unreadable {
+			if i == maxListed {
+				fmt.Fprintf(w, "  and %d more\n", len(unreadable)-maxListed)
+				break
+			}
+			fmt.Fprintf(w, "  %s\n", hash)
+		}
+	}
+	if issues.WholeTree > 0 {
+		fmt.Fprintf(w, "- %d without changes against their parents, counted as changing every file\n", issues.WholeTree)
+	}
+	if issues.NoFiles > 0 {
+		fmt.Fprintf(w, "- %d without any counted files, e.g. only bumping a submodule\n", issues.NoFiles)
+	}
+}
+
+// outputFileNames are the names of the files each format is written to in
+// --output-dir, and the formats written to files rather than shown.
+var outputFileNames = map[string]string{
+	"table":      "hotspots.txt",
+	"json":       "hotspots.json",
+	"jsonl":      "hotspots.jsonl",
+	"prometheus": "hotspots.prom",
+	"csv":        "hotspots.csv",
+	"md":         "hotspots.md",
+	"html":       "hotspots.html",
+}
+
+// writeHotspots writes the hotspots to w in format, one of outputFileNames.
+func writeHotspots(w io.Writer, format string, fileHotspots, dirHotspots []git.Hotspot, opts report.Options) error {
+	switch format {
+	case "json":
+		return report.WriteJSON(w, fileHotspots, dirHotspots, opts)
+	case "jsonl":
+		return report.WriteJSONLines(w, fileHotspots, dirHotspots, opts)
+	case "prometheus":
+		return report.WritePrometheus(w, fileHotspots, dirHotspots, opts)
+	case "csv":
+		return report.WriteCSV(w, fileHotspots, dirHotspots, opts)
+	case "md":
+		return report.WriteMarkdown(w, fileHotspots, dirHotspots, opts)
+	case "html":
+		return report.WriteHTML(w, fileHotspots, dirHotspots, opts)
+	}
+	return report.WriteTable(w, fileHotspots, dirHotspots, opts)
+}
+
+// checkWritableDir creates dir if needed and checks that files can be
+// created in it, so an unwritable --output-dir fails before the analysis.
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".git-hotspots-*")
+	if err != nil {
+		return fmt.Errorf("%s isn't writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// writeOutputDir writes the hotspots to dir in each of formats, to the file
+// named by outputFileNames.
+func writeOutputDir(dir string, formats []string, fileHotspots, dirHotspots []git.Hotspot, opts report.Options) error {
+	for _, format := range formats {
+		name := filepath.Join(dir, outputFileNames[format])
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		err = writeHotspots(f, format, fileHotspots, dirHotspots, opts)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// addUnchanged adds the files in HEAD of the repository at root that have no
+// commits among the hotspots, and their directories, for --reverse and
+// --include-untouched. Merged hotspots pass the name qualifying their paths.
+func addUnchanged(fileHotspots, dirHotspots []git.Hotspot, root string, analyzeOptions git.AnalyzeOptions, opts git.HotspotOptions, name ...string) ([]git.Hotspot, []git.Hotspot, error) {
+	files, err := headFiles(root, analyzeOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range files {
+		files[i] = path.Join(append(name, files[i])...)
+	}
+	fileHotspots, dirHotspots = git.AddUnchanged(fileHotspots, dirHotspots, files, opts)
+	return fileHotspots, dirHotspots, nil
+}
+
+// headFiles lists the files in HEAD of the repository at root that opts
+// analyzes, relative to opts.Path: those with one of opts.Extensions and, if
+// opts.RespectGitIgnore is set, not ignored by git.
+func headFiles(root string, opts git.AnalyzeOptions) ([]string, error) {
+	head, err := git.OpenHeadTree(root, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	files, err := head.Files(opts.Extensions)
+	if err != nil || !opts.RespectGitIgnore {
+		return files, err
+	}
+	gitIgnore, err := git.ReadGitIgnore(root)
+	if err != nil {
+		return nil, err
+	}
+	return gitIgnore.Filter(files, opts.Path), nil
+}
+
+// applyConfig sets the flags not given on the command line from the config
+// files in the home directory and the repository root.
+func applyConfig(flags *flag.FlagSet, root string) error {
+	values, err := config.Load(root)
+	if err != nil {
+		return err
+	}
+	return config.Apply(flags, values)
+}
+
+// configRoot returns the root of the repository containing path, whose
+// config file applies, or path itself if it isn't in a repository.
+func configRoot(path string) string {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if root, err := git.RepositoryRoot(absolutePath); err == nil {
+		return root
+	}
+	return absolutePath
+}
+
+// headFileLines counts the lines of the files headFiles lists, leaving out
+// binary files.
+func headFileLines(root string, opts git.AnalyzeOptions) (map[string]int, error) {
+	files, err := headFiles(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	head, err := git.OpenHeadTree(root, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	return head.CountLines(files)
+}
+
+// absolutePaths rewrites the paths of hotspots, which are relative to dir,
+// as absolute paths. The root directory's, under rootLabel if set, is dir.
+func absolutePaths(hotspots []git.Hotspot, dir, rootLabel string) {
+	for i := range hotspots {
+		if rootLabel != "" && hotspots[i].Path == rootLabel {
+			hotspots[i].Path = dir
+			continue
+		}
+		hotspots[i].Path = filepath.Join(dir, filepath.FromSlash(hotspots[i].Path))
+	}
+}
+
+// absoluteMergedPaths rewrites the paths of merged hotspots, which are
+// qualified by repository name, as absolute paths. roots maps each name to
+// its repository root, and the rest of each path is relative to subpath.
+func absoluteMergedPaths(hotspots []git.Hotspot, roots map[string]string, subpath string) {
+	for i, h := range hotspots {
+		if name, rest, ok := splitMergedPath(h.Path, roots); ok {
+			hotspots[i].Path = filepath.Join(roots[name], filepath.FromSlash(subpath), filepath.FromSlash(rest))
+		}
+	}
+}
+
+// splitMergedPath splits a merged hotspot path into the name of its
+// repository in roots and the rest of the path, which is empty for the
+// repository itself. Names may contain slashes, so the longest matching
+// name wins.
+func splitMergedPath(p string, roots map[string]string) (name, rest string, ok bool) {
+	for candidate := range roots {
+		if (p == candidate || strings.HasPrefix(p, candidate+"/")) && len(candidate) > len(name) {
+			name = candidate
+		}
+	}
+	if name == "" {
+		return "", "", false
+	}
+	return name, strings.TrimPrefix(strings.TrimPrefix(p, name), "/"), true
+}
+
+// dropDeleted drops the file and directory hotspots that exists reports
+// gone from HEAD. Components aren't paths, so their hotspots are kept, and
+// so is the root directory's under opts.RootLabel.
+func dropDeleted(fileHotspots, dirHotspots []git.Hotspot, opts git.HotspotOptions, exists func(path string) bool) ([]git.Hotspot, []git.Hotspot) {
+	fileHotspots = git.FilterExisting(fileHotspots, exists)
+	if opts.Components == nil {
+		dirHotspots = git.FilterExisting(dirHotspots, func(p string) bool {
+			return p == opts.RootLabel || exists(p)
+		})
+	}
+	return fileHotspots, dirHotspots
+}
+
+// explainQuery writes the ref, date bounds and filters commits are selected
+// with for each repository, and how many commits matched, to help find out
+// why a file isn't showing up. commitsFrom names the file hashes were read
+// from, if any.
+func explainQuery(w io.Writer, repoPaths []string, opts git.AnalyzeOptions, commitsFrom string) error {
+	for i, repoPath := range repoPaths {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		root, err := git.RepositoryRoot(repoPath)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Repository:       %s\n", root)
+
+		// Listed commits are analyzed as given, without walking the history
+		if len(opts.Hashes) > 0 {
+			fmt.Fprintf(w, "Commits:          %d listed in %s (date bounds don't apply)\n", len(opts.Hashes), commitsFrom)
+		} else {
+			name, hash, err := git.HeadRef(root)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Ref:              %s (%s)\n", name, hash)
+			if opts.AllRefs {
+				refs, err := git.BranchRefs(root, opts.Remotes)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "All refs:         %s\n", strings.Join(refs, " "))
+			}
+			since := "the first commit"
+			if !opts.Since.IsZero() {
+				since = opts.Since.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "Since:            %s\n", since)
+			fmt.Fprintln(w, "Until:            now")
+		}
+
+		path := "(whole repository)"
+		if opts.Path != "" {
+			path = opts.Path
+		}
+		fmt.Fprintf(w, "Path:             %s\n", path)
+		extensions := "all"
+		if len(opts.Extensions) > 0 {
+			extensions = strings.Join(opts.Extensions, " ")
+		}
+		fmt.Fprintf(w, "Extensions:       %s\n", extensions)
+		maxFiles := "no limit"
+		if opts.MaxFilesPerCommit > 0 {
+			maxFiles = fmt.Sprint(opts.MaxFilesPerCommit)
+		}
+		fmt.Fprintf(w, "Max files/commit: %s\n", maxFiles)
+		rootCommits := "included"
+		if opts.SkipRootCommits {
+			rootCommits = "skipped"
+		}
+		fmt.Fprintf(w, "Root commits:     %s\n", rootCommits)
+		submodules := "skipped"
+		if opts.IncludeSubmodules {
+			submodules = "included"
+		}
+		fmt.Fprintf(w, "Submodules:       %s\n", submodules)
+
+		// Count the matching commits without identifying hotspots
+		matched, skipped := 0, 0
+		countOpts := opts
+		countOpts.CountLines = false
+		countOpts.OnLargeCommit = func(git.CommitInfo) {
+			skipped++
+		}
+		err = git.AnalyzeCommitsFunc(root, countOpts, func(git.CommitInfo) error {
+			matched++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Matched commits:  %d", matched)
+		if skipped > 0 {
+			fmt.Fprintf(w, " (%d more skipped for touching too many files)", skipped)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// remoteName returns the name of the repository at a remote URL, such as
+// "repo" for https://github.com/org/repo.git or git@github.com:org/repo.git.
+func remoteName(url string) string {
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		return "repository"
+	}
+	return name
+}
+
+// version is the version of git-hotspots, set at build time with
+// -ldflags "-X git-hotspots/internal/cli.version=v1.2.3".
+var version = "dev"
+
+// toolVersion returns the version of git-hotspots, falling back to the module
+// version for builds with go install.
+func toolVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
+
+// runUI runs display and, if the terminal UI can't be started, explains why on
+// stderr and writes the plain-text fallback instead. Profiles are flushed
+// first, so they cover the analysis and not the time spent in the UI.
+func runUI(stderr io.Writer, prof *profiler, display func() error, fallback func() error) error {
+	prof.stop(stderr)
+	if err := display(); err != nil {
+		fmt.Fprintf(stderr, "Couldn't start the terminal UI (%v), showing plain text instead.\n\n", err)
+		return fallback()
+	}
+	return nil
+}
+
+// stringList is a flag that can be repeated, collecting every value.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// IsList makes config files set the flag once per item of a list.
+func (l *stringList) IsList() bool {
+	return true
+}
+
+// readAliases parses the given aliases and those in the named file, if any.
+func readAliases(aliases []string, file string) (*git.Aliases, error) {
+	result := git.NewAliases()
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := result.Read(f); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	for _, alias := range aliases {
+		if err := result.Add(alias); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// readAuthorFilter parses the given author patterns and those in the named
+// file, if any.
+func readAuthorFilter(patterns []string, file string) (*git.AuthorFilter, error) {
+	result := git.NewAuthorFilter()
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := result.Read(f); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	for _, pattern := range patterns {
+		if err := result.Add(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// readCommitHashes reads commit hashes from the named file, or from stdin if
+// name is "-", finding none if stdin is nil.
+func readCommitHashes(name string, stdin io.Reader) ([]string, error) {
+	if name == "-" {
+		if stdin == nil {
+			return nil, nil
+		}
+		return git.ReadCommitHashes(stdin)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return git.ReadCommitHashes(f)
+}
+
+// isTerminal reports whether w is a terminal the UI can run in.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
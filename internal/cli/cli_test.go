@@ -0,0 +1,1409 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	hotspots "git-hotspots/internal/git"
+	"git-hotspots/internal/testutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRun(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	// Create some commits
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt", "file2.txt"}, "Add file2", now.Add(-12*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"dir1/file3.txt"}, "Add file3 in dir1", now.Add(-6*time.Hour))
+
+	// Run the CLI tool against the test repository in test mode, which
+	// writes JSON so exact values can be checked
+	var out bytes.Buffer
+	if code := Run([]string{"--test-mode=true", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Errorf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+
+	type testModeHotspot struct {
+		Path           string `json:"path"`
+		Commits        int    `json:"commits"`
+		TopContributor string `json:"topContributor"`
+		AuthorCommits  int    `json:"authorCommits"`
+	}
+	var testModeReport struct {
+		Summary struct {
+			Commits int `json:"commits"`
+			Authors int `json:"authors"`
+		} `json:"summary"`
+		Files       []testModeHotspot `json:"files"`
+		Directories []testModeHotspot `json:"directories"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &testModeReport); err != nil {
+		t.Fatalf("Expected JSON output in test mode: %v\nOutput: %s", err, out.String())
+	}
+	if testModeReport.Summary.Commits != 3 || testModeReport.Summary.Authors != 1 {
+		t.Errorf("Expected 3 commits by 1 author, got %+v", testModeReport.Summary)
+	}
+
+	// Every commit writes the same content, so file1.txt only changes once
+	expectedFiles := map[string]int{"file1.txt": 1, "file2.txt": 1, "dir1/file3.txt": 1}
+	if len(testModeReport.Files) != len(expectedFiles) {
+		t.Errorf("Expected %d files, got %+v", len(expectedFiles), testModeReport.Files)
+	}
+	for _, h := range testModeReport.Files {
+		if h.Commits != expectedFiles[h.Path] || h.TopContributor != "Test User" || h.AuthorCommits != h.Commits {
+			t.Errorf("Unexpected file hotspot %+v", h)
+		}
+	}
+	expectedDirs := []testModeHotspot{{Path: "dir1", Commits: 1, TopContributor: "Test User", AuthorCommits: 1}}
+	if !reflect.DeepEqual(testModeReport.Directories, expectedDirs) {
+		t.Errorf("Expected directories %+v, got %+v", expectedDirs, testModeReport.Directories)
+	}
+
+	// The human-readable summary is printed with --summary
+	out.Reset()
+	if code := Run([]string{"--summary", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Errorf("Run exited with status %d with --summary\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "- file1.txt: 1 commits (Top contributor: Test User with 1 commits)") {
+		t.Errorf("Expected a plain-text summary, got: %s", out.String())
+	}
+
+	// Without a terminal, the default UI format falls back to plain tables
+	out.Reset()
+	if code := Run([]string{tmpDir}, nil, &out, &out); code != 0 {
+		t.Errorf("Run exited with status %d without a terminal\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "Top Hotspot Files") || !strings.Contains(out.String(), "dir1/file3.txt") {
+		t.Errorf("Expected table output without a terminal, got: %s", out.String())
+	}
+
+	// Test case for non-git directory
+	nonGitDir, err := ioutil.TempDir("", "non-git-test-")
+	if err != nil {
+		t.Fatalf("Failed to create non-git temp dir: %v", err)
+	}
+	defer os.RemoveAll(nonGitDir)
+
+	out.Reset()
+	if code := Run([]string{"--test-mode=true", nonGitDir}, nil, &out, &out); code == 0 {
+		t.Errorf("Expected Run to fail for non-git directory, but it succeeded")
+	}
+	outputStr := out.String()
+	if !strings.Contains(outputStr, "is not a Git repository") {
+		t.Errorf("Expected error message for non-git repository, got: %s", outputStr)
+	}
+
+	// Test case for a path that doesn't exist inside a repository
+	out.Reset()
+	if code := Run([]string{"--test-mode=true", filepath.Join(tmpDir, "does", "not", "exist")}, nil, &out, &out); code == 0 {
+		t.Errorf("Expected Run to fail for a missing path, but it succeeded")
+	}
+	if outputStr := out.String(); !strings.Contains(outputStr, "is not a Git repository") {
+		t.Errorf("Expected error message for a missing path, got: %s", outputStr)
+	}
+
+	// Test case for hotspots over the score threshold: JSON is still
+	// written to stdout, and the offending file is reported on stderr
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"--format", "json", "--fail-if-score", "0.5", tmpDir}, nil, &stdout, &stderr); code == 0 {
+		t.Errorf("Expected Run to fail for a hotspot over the threshold, but it succeeded")
+	}
+	if !json.Valid(stdout.Bytes()) {
+		t.Errorf("Expected valid JSON output, got: %s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "file1.txt: 1 commits, score 1.00") {
+		t.Errorf("Expected file1.txt to be reported over the threshold, got: %s", stderr.String())
+	}
+
+	// Test case for a shallow clone: mark HEAD as a shallow boundary, as
+	// git clone --depth 1 does, so the report still runs with a warning
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".git", "shallow"), []byte(head.Hash().String()+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write shallow file: %v", err)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := Run([]string{"--format", "json", tmpDir}, nil, &stdout, &stderr); code != 0 {
+		t.Errorf("Run exited with status %d for a shallow clone\nStderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "shallow clone") {
+		t.Errorf("Expected a shallow clone warning, got: %s", stderr.String())
+	}
+
+	// With --require-full-history, shallow clones are refused
+	if code := Run([]string{"--require-full-history", tmpDir}, nil, io.Discard, io.Discard); code == 0 {
+		t.Errorf("Expected Run to fail for a shallow clone with --require-full-history")
+	}
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{"unknown flag", []string{"--no-such-flag", tmpDir}, 2, "flag provided but not defined"},
+		{"too many arguments", []string{tmpDir, "src", "extra"}, 1, "too many arguments"},
+		{"subpath twice", []string{"--path", "src", tmpDir, "src"}, 1, "either as an argument or with --path"},
+		{"unknown mode", []string{"--mode", "nope", tmpDir}, 1, `unknown mode "nope"`},
+		{"absolute tree", []string{"--mode", "tree", "--path-style", "absolute", tmpDir}, 1, "--path-style absolute isn't supported in tree mode"},
+		{"unknown format", []string{"--format", "xml", tmpDir}, 1, `unknown format "xml"`},
+		{"jsonl outside hotspots", []string{"--format", "jsonl", "--mode", "trend", tmpDir}, 1, "isn't supported in trend mode"},
+		{"prometheus with file", []string{"--format", "prometheus", "--file", "file1.txt", tmpDir}, 1, "--format prometheus isn't supported with --file"},
+		{"html separately", []string{"--format", "html", "--separate", tmpDir, tmpDir}, 1, "--format html is only supported in hotspots mode"},
+		{"repo url with table", []string{"--format", "table", "--repo-url", "https://example.com/repo", tmpDir}, 1, "--repo-url is only supported with --format md or html"},
+		{"repo url merged", []string{"--format", "md", "--repo-url", "https://example.com/repo", "--merge", tmpDir, tmpDir}, 1, "--repo-url can't be used with --merge"},
+		{"formats without output dir", []string{"--formats", "json", tmpDir}, 1, "--output-dir and --formats must be used together"},
+		{"unknown output format", []string{"--output-dir", t.TempDir(), "--formats", "json,xml", tmpDir}, 1, `unknown format "xml" in --formats`},
+		{"output dir outside hotspots", []string{"--output-dir", t.TempDir(), "--formats", "json", "--mode", "trend", tmpDir}, 1, "--output-dir is only supported in hotspots mode"},
+		{"output dir not a directory", []string{"--output-dir", filepath.Join(tmpDir, "file1.txt"), "--formats", "json", tmpDir}, 1, "--output-dir:"},
+		{"unknown ranking", []string{"--rank-by", "nope", tmpDir}, 1, `unknown ranking "nope"`},
+		{"dir size with churn", []string{"--normalize-dir-by-size", "--rank-by", "churn", tmpDir}, 1, "--normalize-dir-by-size only works on a single repository"},
+		{"unknown backend", []string{"--backend", "libgit2", tmpDir}, 1, `unknown backend "libgit2"`},
+		{"unknown tie-break", []string{"--contributor-tie-break", "oldest", tmpDir}, 1, `unknown contributor tie-break "oldest"`},
+		{"churn size without churn", []string{"--normalize-churn-by-size", tmpDir}, 1, "--normalize-churn-by-size only works on a single repository ranked by churn"},
+		{"git backend with churn", []string{"--backend", "git", "--rank-by", "churn", tmpDir}, 1, "--backend git can't count lines"},
+		{"unknown score variable", []string{"--score-expr", "commits + lines", tmpDir}, 1, `--score-expr: unknown variable "lines"`},
+		{"score expr with ranking", []string{"--score-expr", "commits", "--rank-by", "churn", tmpDir}, 1, "--score-expr can't be used with --rank-by"},
+		{"reverse outside hotspots", []string{"--reverse", "--mode", "trend", tmpDir}, 1, "--reverse and --include-untouched are only supported in hotspots mode"},
+		{"remotes without all", []string{"--remotes", tmpDir}, 1, "--remotes requires --all"},
+		{"all with commits-from", []string{"--all", "--commits-from", "-", tmpDir}, 1, "--all can't be used with --commits-from or --file"},
+		{"empty idle band", []string{"--max-idle", "720h", "--active-within", "168h", tmpDir}, 1, "--max-idle must be shorter than --active-within"},
+		{"owner threshold out of range", []string{"--owner-threshold", "1", tmpDir}, 1, "--owner-threshold must be between 0 and 1"},
+		{"unknown grouping", []string{"--group", "package", tmpDir}, 1, `unknown grouping "package"`},
+		{"go modules with merge", []string{"--group", "go-module", "--merge", tmpDir, tmpDir}, 1, "--group go-module can't be used with --components, --merge or --separate"},
+		{"negative display depth", []string{"--display-depth", "-1", tmpDir}, 1, "--display-depth can't be negative"},
+		{"display depth with components", []string{"--display-depth", "2", "--components", "components.yml", tmpDir}, 1, "--display-depth can't be used with --components"},
+		{"display depth with absolute paths", []string{"--display-depth", "2", "--path-style", "absolute", tmpDir}, 1, "--display-depth can't be used with --components, --group go-module or --path-style absolute"},
+		{"test pattern without exclude tests", []string{"--test-pattern", "**/fixtures", tmpDir}, 1, "--test-pattern requires --exclude-tests"},
+		{"invalid test pattern", []string{"--exclude-tests", "--test-pattern", "[fixtures", tmpDir}, 1, `invalid test pattern "[fixtures"`},
+		{"unknown merge commit strategy", []string{"--merge-commit-strategy", "ours", tmpDir}, 1, "unknown merge commit strategy \"ours\" (expected union, first-parent or none)"},
+		{"root label with slash", []string{"--root-label", "a/b", tmpDir}, 1, "--root-label \"a/b\" can't contain a slash"},
+		{"root label with go modules", []string{"--root-label", "<root>", "--group", "go-module", tmpDir}, 1, "--root-label can't be used with --components or --group go-module"},
+		{"merge and separate", []string{"--merge", "--separate", tmpDir}, 1, "can't be used together"},
+		{"unknown heatmap bucket", []string{"--mode", "heatmap", "--heatmap-bucket", "year", tmpDir}, 1, `unknown heatmap bucket "year"`},
+		{"trend split out of range", []string{"--trend-split", "1.5", tmpDir}, 1, "--trend-split must be between 0 and 1"},
+		{"sample out of range", []string{"--sample", "1.5", tmpDir}, 1, "--sample must be between 0 and 1"},
+		{"negative commit limit", []string{"--limit-commits", "-1", tmpDir}, 1, "--limit-commits can't be negative"},
+		{"commit limit with commit list", []string{"--limit-commits", "1", "--commits-from", "-", tmpDir}, 1, "--limit-commits can't be used with --commits-from"},
+		{"extrapolate without sample", []string{"--extrapolate", tmpDir}, 1, "require --sample"},
+		{"sqlite without output", []string{"--format", "sqlite", tmpDir}, 1, "--format sqlite and --output must be used together"},
+		{"no files and no dirs", []string{"--no-files", "--no-dirs", tmpDir}, 1, "can't be used together"},
+		{"no files outside hotspots", []string{"--no-files", "--mode", "trend", tmpDir}, 1, "--no-files isn't supported in trend mode"},
+		{"watch with json", []string{"--watch", "--format", "json", tmpDir}, 1, "--watch only works in hotspots mode"},
+		{"count only with file", []string{"--count-only", "--file", "file1.txt", tmpDir}, 1, "--count-only can't be used with --mode, --separate, --file, --watch or --explain"},
+		{"count only with jsonl", []string{"--count-only", "--format", "jsonl", tmpDir}, 1, "--format jsonl isn't supported with --count-only"},
+		{"serve several repositories", []string{"serve", tmpDir, tmpDir}, 1, "serve takes a single repository"},
+		{"invalid alias", []string{"--alias", "Bot", tmpDir}, 1, `invalid alias "Bot"`},
+		{"invalid excluded author", []string{"--exclude-author", "/(/", tmpDir}, 1, `invalid author pattern "/(/"`},
+		{"missing excluded authors file", []string{"--exclude-authors-file", "no-such-file", tmpDir}, 1, "Error reading excluded authors"},
+		{"unknown language", []string{"--lang", "go,cobol", tmpDir}, 1, `unknown language "cobol" (supported: c, cpp,`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if code := Run(tt.args, nil, &out, &out); code != tt.wantCode {
+				t.Errorf("Expected exit status %d, got %d\nOutput: %s", tt.wantCode, code, out.String())
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("Expected output to contain %q, got: %s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestRunCommitsFromStdin(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", time.Now().Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file2.txt"}, "Add file2", time.Now().Add(-time.Hour))
+
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+
+	stdin := strings.NewReader(head.Hash().String() + "\n")
+	var out bytes.Buffer
+	if code := Run([]string{"--commits-from", "-", "--format", "json", tmpDir}, stdin, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(got.Files) != 1 || got.Files[0].Path != "file2.txt" {
+		t.Errorf("Expected only file2.txt from the listed commit, got %+v", got.Files)
+	}
+}
+
+func TestRunCommitsFromWindow(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	old := time.Now().AddDate(-3, 0, 0)
+	var hashes []string
+	for i, change := range []testutil.Change{
+		{Author: "Alice", Date: old, Write: map[string]string{"handoff.go": "v1"}},
+		{Author: "Alice", Date: old.AddDate(0, 0, 1), Write: map[string]string{"handoff.go": "v2"}},
+		{Author: "Bob", Date: old.AddDate(2, 0, 0), Write: map[string]string{"handoff.go": "v3"}},
+	} {
+		change.Message = fmt.Sprintf("Change %d", i)
+		hashes = append(hashes, testutil.Commit(t, tmpDir, change).String())
+	}
+	hashesFile := filepath.Join(t.TempDir(), "hashes")
+	if err := os.WriteFile(hashesFile, []byte(strings.Join(hashes, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write hashes: %v", err)
+	}
+
+	// Listed commits aren't bounded by the default window of a year, so the
+	// window of ownership changes spans them too
+	var out bytes.Buffer
+	if code := Run([]string{"--mode", "ownership-changes", "--format", "json", "--commits-from", hashesFile, tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var changes []struct {
+		Path     string `json:"path"`
+		NewOwner string `json:"newOwner"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &changes); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(changes) != 1 || changes[0].Path != "handoff.go" || changes[0].NewOwner != "Bob" {
+		t.Errorf("Expected handoff.go to pass to Bob, got %+v", changes)
+	}
+
+	// The same goes for trends, splitting the window in half between the
+	// first listed commit and now
+	out.Reset()
+	if code := Run([]string{"--mode", "trend", "--format", "json", "--commits-from", hashesFile, tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var trends []struct {
+		Path    string `json:"path"`
+		Earlier int    `json:"earlierCommits"`
+		Later   int    `json:"laterCommits"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &trends); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(trends) != 1 || trends[0].Earlier != 2 || trends[0].Later != 1 {
+		t.Errorf("Expected handoff.go with 2 earlier commits and 1 later, got %+v", trends)
+	}
+}
+
+func TestRunUIFallback(t *testing.T) {
+	var stderr bytes.Buffer
+	fellBack := false
+	err := runUI(&stderr, nil, func() error {
+		return errors.New("no terminal")
+	}, func() error {
+		fellBack = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runUI returned error: %v", err)
+	}
+	if !fellBack {
+		t.Errorf("Expected the fallback to run when the UI fails")
+	}
+	if !strings.Contains(stderr.String(), "no terminal") {
+		t.Errorf("Expected the UI error on stderr, got: %s", stderr.String())
+	}
+
+	// The fallback isn't used when the UI runs
+	fellBack = false
+	if err := runUI(&stderr, nil, func() error { return nil }, func() error {
+		fellBack = true
+		return nil
+	}); err != nil || fellBack {
+		t.Errorf("Expected only the UI to run, got err %v and fallback %v", err, fellBack)
+	}
+}
+
+func TestExplainQuery(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"old.txt"}, "Old commit", now.AddDate(-2, 0, 0))
+	testutil.CreateCommit(t, tmpDir, []string{"src/a.txt"}, "Recent commit", now.AddDate(0, 0, -1))
+	testutil.CreateCommit(t, tmpDir, []string{"src/b.txt", "src/c.txt"}, "Large commit", now)
+
+	var out bytes.Buffer
+	opts := hotspots.AnalyzeOptions{Since: now.AddDate(-1, 0, 0), Path: "src", MaxFilesPerCommit: 1}
+	if err := explainQuery(&out, []string{tmpDir}, opts, ""); err != nil {
+		t.Fatalf("explainQuery failed: %v", err)
+	}
+
+	// The old commit is outside the window and the large one is skipped
+	output := out.String()
+	for _, expected := range []string{"refs/heads/master", opts.Since.Format(time.RFC3339), "Path:             src", "Matched commits:  1 (1 more skipped"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the explanation, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestRunPathStyleAbsolute(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Add main", time.Now().Add(-time.Hour))
+
+	root, err := hotspots.RepositoryRoot(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to get repository root: %v", err)
+	}
+
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "json", "--path-style", "absolute", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+		Directories []struct {
+			Path string `json:"path"`
+		} `json:"directories"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(got.Files) != 1 || got.Files[0].Path != filepath.Join(root, "src", "main.go") {
+		t.Errorf("Expected an absolute file path under %s, got %+v", root, got.Files)
+	}
+	if len(got.Directories) != 1 || got.Directories[0].Path != filepath.Join(root, "src") {
+		t.Errorf("Expected an absolute directory path under %s, got %+v", root, got.Directories)
+	}
+}
+
+func TestRunNoFilesNoDirs(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Add main", time.Now().Add(-time.Hour))
+
+	// Only the requested kind of hotspot is identified and shown
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "table", "--no-dirs", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "src/main.go") || strings.Contains(out.String(), "Top Hotspot Directories") {
+		t.Errorf("Expected only the file table, got: %s", out.String())
+	}
+
+	out.Reset()
+	if code := Run([]string{"--format", "json", "--no-files", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files       []json.RawMessage `json:"files"`
+		Directories []json.RawMessage `json:"directories"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(got.Files) != 0 || len(got.Directories) != 1 {
+		t.Errorf("Expected no files and 1 directory, got %d and %d", len(got.Files), len(got.Directories))
+	}
+}
+
+func TestRunReverse(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "lib/old.go"}, "Initial import", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/util.go"}, "Add util", now.Add(-time.Hour))
+
+	// Files in HEAD without commits come first
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "json", "--reverse", "--exclude-initial-commit", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files []struct {
+			Path      string `json:"path"`
+			Commits   int    `json:"commits"`
+			FirstSeen any    `json:"firstSeen"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(got.Files) != 3 || got.Files[0].Path != "lib/old.go" || got.Files[0].Commits != 0 || got.Files[0].FirstSeen != nil {
+		t.Errorf("Expected lib/old.go without commits first, got %+v", got.Files)
+	}
+
+	out.Reset()
+	if code := Run([]string{"--format", "table", "--reverse", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "Coldest Files") {
+		t.Errorf("Expected coldspot tables, got: %s", out.String())
+	}
+}
+
+func TestRunNormalizeDirBySize(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"big/a.go", "big/b.go", "big/c.go", "big/d.go"}, "Add big", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"small/a.go"}, "Add small", now.Add(-time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"big/e.go"}, "Extend big", now.Add(-time.Hour))
+
+	directories := func(args ...string) []string {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run(append([]string{"--format", "json"}, append(args, tmpDir)...), nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Directories []struct {
+				Path string `json:"path"`
+			} `json:"directories"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		var paths []string
+		for _, d := range got.Directories {
+			paths = append(paths, d.Path)
+		}
+		return paths
+	}
+
+	// big has 2 commits over 5 files, small 1 over 1
+	if got := directories(); !reflect.DeepEqual(got, []string{"big", "small"}) {
+		t.Errorf("Expected big ranked first by default, got %v", got)
+	}
+	if got := directories("--normalize-dir-by-size"); !reflect.DeepEqual(got, []string{"small", "big"}) {
+		t.Errorf("Expected small ranked first per file, got %v", got)
+	}
+}
+
+func TestRunNormalizeChurnBySize(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	// big.go changes more lines, but small.go more for its size
+	now := time.Now()
+	testutil.Commit(t, tmpDir, testutil.Change{Date: now.Add(-2 * time.Hour), Write: map[string]string{
+		"big.go":   strings.Repeat("line\n", 40),
+		"small.go": "a\nb\n",
+	}})
+	testutil.Commit(t, tmpDir, testutil.Change{Date: now.Add(-time.Hour), Write: map[string]string{
+		"big.go":   strings.Repeat("line\n", 50),
+		"small.go": "c\nd\n",
+	}})
+
+	files := func(args ...string) []string {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run(append([]string{"--format", "json", "--rank-by", "churn"}, append(args, tmpDir)...), nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Files []struct {
+				Path string `json:"path"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		var paths []string
+		for _, f := range got.Files {
+			paths = append(paths, f.Path)
+		}
+		return paths
+	}
+
+	// big.go has 50 lines changed over 50 lines, small.go 6 over 2
+	if got := files(); !reflect.DeepEqual(got, []string{"big.go", "small.go"}) {
+		t.Errorf("Expected big.go ranked first by churn, got %v", got)
+	}
+	if got := files("--normalize-churn-by-size"); !reflect.DeepEqual(got, []string{"small.go", "big.go"}) {
+		t.Errorf("Expected small.go ranked first per line, got %v", got)
+	}
+}
+
+func TestRunMergeChurn(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.Commit(t, tmpDir, testutil.Change{Date: now.Add(-2 * time.Hour), Write: map[string]string{"f.go": "a\n"}})
+	testutil.Commit(t, tmpDir, testutil.Change{Date: now.Add(-time.Hour), Write: map[string]string{"f.go": "b\nc\nd\ne\n"}})
+
+	lines := func(args ...string) map[string][2]int {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run(append([]string{"--format", "json", "--rank-by", "churn"}, args...), nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Files []struct {
+				Path         string `json:"path"`
+				LinesAdded   int    `json:"linesAdded"`
+				LinesDeleted int    `json:"linesDeleted"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		counts := make(map[string][2]int)
+		for _, f := range got.Files {
+			counts[f.Path] = [2]int{f.LinesAdded, f.LinesDeleted}
+		}
+		return counts
+	}
+
+	// Merged paths are qualified with the repository name, and keep the
+	// lines changed counted for them in the repository
+	if got, want := lines(tmpDir), map[string][2]int{"f.go": {5, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	merged := filepath.Base(tmpDir) + "/f.go"
+	if got, want := lines("--merge", tmpDir), map[string][2]int{merged: {5, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v merged, got %v", want, got)
+	}
+}
+
+func TestRunLimitCommits(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"old.go"}, "Old", now.Add(-3*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"mid.go"}, "Mid", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"new.go"}, "New", now.Add(-time.Hour))
+
+	run := func(limit string) (report struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+		Summary struct {
+			Commits      int  `json:"commits"`
+			LimitCommits int  `json:"limitCommits"`
+			Truncated    bool `json:"truncated"`
+		} `json:"summary"`
+	}, stderr string) {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		if code := Run([]string{"--format", "json", "--limit-commits", limit, tmpDir}, nil, &out, &errOut); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		return report, errOut.String()
+	}
+
+	// Only the most recent commits are walked, and the report says so
+	report, stderr := run("2")
+	if len(report.Files) != 2 || report.Summary.Commits != 2 || report.Summary.LimitCommits != 2 || !report.Summary.Truncated {
+		t.Errorf("Expected 2 files from 2 commits, truncated, got %+v", report)
+	}
+	if !strings.Contains(stderr, "only the 2 most recent commits were analyzed") {
+		t.Errorf("Expected a note on stderr, got %q", stderr)
+	}
+
+	// A limit the history doesn't reach truncates nothing
+	report, stderr = run("5")
+	if len(report.Files) != 3 || report.Summary.Truncated || stderr != "" {
+		t.Errorf("Expected all 3 files without truncation, got %+v and %q", report, stderr)
+	}
+}
+
+func TestRunRespectGitIgnore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "gen/api.go"}, "Initial import", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"gen/client.go"}, "Regenerate", now.Add(-time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"docs/guide.md"}, "Add guide", now.Add(-time.Hour))
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("gen/\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+
+	repo := tmpDir
+	files := func(args ...string) []string {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run(append([]string{"--format", "json"}, append(args, repo)...), nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Files []struct {
+				Path string `json:"path"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		var paths []string
+		for _, f := range got.Files {
+			paths = append(paths, f.Path)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	// Committed files under gen are left out, including from untouched files
+	if got := files(); !reflect.DeepEqual(got, []string{"docs/guide.md", "gen/api.go", "gen/client.go", "src/main.go"}) {
+		t.Errorf("Expected every file by default, got %v", got)
+	}
+	if got := files("--respect-gitignore", "--include-untouched"); !reflect.DeepEqual(got, []string{"docs/guide.md", "src/main.go"}) {
+		t.Errorf("Expected ignored files left out, got %v", got)
+	}
+
+	// The committed .gitignore files of a repository given by URL apply too
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Ignore gen", Date: now.Add(-time.Minute), Write: map[string]string{".gitignore": "gen/\n"}})
+	repo = "file://" + filepath.ToSlash(tmpDir)
+	if got := files("--respect-gitignore"); !reflect.DeepEqual(got, []string{".gitignore", "docs/guide.md", "src/main.go"}) {
+		t.Errorf("Expected ignored files left out of the clone, got %v", got)
+	}
+}
+
+func TestRunIgnoreDeletions(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "old.go"}, "Initial commit", now.Add(-2*time.Hour))
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Remove old.go", Date: now.Add(-time.Hour), Delete: []string{"old.go"}})
+
+	files := func(args ...string) map[string][2]int {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run(append([]string{"--format", "json"}, append(args, tmpDir)...), nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Files []struct {
+				Path      string `json:"path"`
+				Commits   int    `json:"commits"`
+				Deletions int    `json:"deletions"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		counts := make(map[string][2]int)
+		for _, f := range got.Files {
+			counts[f.Path] = [2]int{f.Commits, f.Deletions}
+		}
+		return counts
+	}
+
+	// Commits and deletions of each file
+	if got, want := files(), map[string][2]int{"main.go": {1, 0}, "old.go": {2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v by default, got %v", want, got)
+	}
+	if got, want := files("--ignore-deletions"), map[string][2]int{"main.go": {1, 0}, "old.go": {1, 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v ignoring deletions, got %v", want, got)
+	}
+
+	// Merged repositories count deletions under the qualified paths
+	name := filepath.Base(tmpDir)
+	if got, want := files("--merge"), map[string][2]int{name + "/main.go": {1, 0}, name + "/old.go": {2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v merged, got %v", want, got)
+	}
+	if got, want := files("--merge", "--ignore-deletions"), map[string][2]int{name + "/main.go": {1, 0}, name + "/old.go": {1, 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v merged ignoring deletions, got %v", want, got)
+	}
+}
+
+func TestRunMergeLifetimes(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "gone.go"}, "Initial commit", now.Add(-48*time.Hour))
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Remove gone.go", Date: now.Add(-time.Hour), Delete: []string{"gone.go"}})
+
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "json", "--merge", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files []map[string]any `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+
+	// Files created and deleted in merged repositories have lifetimes
+	name := filepath.Base(tmpDir)
+	lifetimes := make(map[string][3]bool)
+	for _, f := range got.Files {
+		_, created := f["created"]
+		_, deleted := f["deleted"]
+		_, lifetime := f["lifetimeDays"]
+		lifetimes[f["path"].(string)] = [3]bool{created, deleted, lifetime}
+	}
+	want := map[string][3]bool{name + "/main.go": {true, false, true}, name + "/gone.go": {true, true, true}}
+	if !reflect.DeepEqual(lifetimes, want) {
+		t.Errorf("Expected created, deleted and lifetimeDays %v, got %v\nOutput: %s", want, lifetimes, out.String())
+	}
+}
+
+func TestRunRootLabel(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"go.mod", "Makefile", "src/main.go"}, "Initial commit", now.Add(-2*time.Hour))
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Bump deps", Date: now.Add(-time.Hour), Write: map[string]string{"go.mod": "changed"}})
+
+	dirs := func(args ...string) map[string]int {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run(append([]string{"--format", "json"}, append(args, tmpDir)...), nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Directories []struct {
+				Path    string `json:"path"`
+				Commits int    `json:"commits"`
+			} `json:"directories"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		commits := make(map[string]int)
+		for _, d := range got.Directories {
+			commits[d.Path] = d.Commits
+		}
+		return commits
+	}
+
+	if got, want := dirs(), map[string]int{"src": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v by default, got %v", want, got)
+	}
+
+	// The root isn't a deleted directory, and is the repository itself in
+	// absolute paths
+	if got, want := dirs("--root-label", "<root>", "--only-existing"), map[string]int{"<root>": 2, "src": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v with a root label, got %v", want, got)
+	}
+	if got := dirs("--root-label", "<root>", "--path-style", "absolute"); got[tmpDir] != 2 {
+		t.Errorf("Expected the root at %s, got %v", tmpDir, got)
+	}
+}
+
+func TestRunDisplayDepth(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"a/b/x.go"}, "Add x", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"a/c/y.go"}, "Add y", now.Add(-time.Hour))
+
+	// Directories are shown rolled up into a, but the thresholds apply to
+	// the directories analyzed, a/b and a/c with a commit each
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "json", "--display-depth", "1", "--fail-if-commits", "1", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files       []struct{ Path string } `json:"files"`
+		Directories []struct {
+			Path    string `json:"path"`
+			Commits int    `json:"commits"`
+		} `json:"directories"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(got.Directories) != 1 || got.Directories[0].Path != "a" || got.Directories[0].Commits != 2 {
+		t.Errorf("Expected a single directory a with 2 commits, got %+v", got.Directories)
+	}
+	if len(got.Files) != 2 {
+		t.Errorf("Expected both files, got %+v", got.Files)
+	}
+}
+
+func TestRunExcludeTests(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"pkg/a.go", "pkg/a_test.go", "pkg/fixtures/data.json"}, "Initial commit", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"app/test_views.py"}, "Add Python tests", now.Add(-time.Hour))
+
+	// Extra patterns can come from the config file
+	if err := os.WriteFile(filepath.Join(tmpDir, ".git-hotspots.yaml"), []byte("test-pattern: [\"**/fixtures\", \"docs/**\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	run := func() (files []string, testFiles map[string]int) {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run([]string{"--format", "json", "--exclude-tests", tmpDir}, nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Files []struct {
+				Path string `json:"path"`
+			} `json:"files"`
+			Directories []struct {
+				Path      string `json:"path"`
+				TestFiles int    `json:"testFiles"`
+			} `json:"directories"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		for _, f := range got.Files {
+			files = append(files, f.Path)
+		}
+		testFiles = make(map[string]int)
+		for _, d := range got.Directories {
+			testFiles[d.Path] = d.TestFiles
+		}
+		return files, testFiles
+	}
+
+	// The commit only adding Python tests is skipped, and pkg/fixtures has
+	// no directory hotspot to count its test files in
+	files, testFiles := run()
+	if !reflect.DeepEqual(files, []string{"pkg/a.go"}) || !reflect.DeepEqual(testFiles, map[string]int{"pkg": 1}) {
+		t.Errorf("Expected only pkg/a.go, with 1 test file in pkg, got %v and %v", files, testFiles)
+	}
+}
+
+func TestRunOutputDir(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	// Each format is written to its own file in the directory, created if
+	// missing, and nothing to stdout
+	dir := filepath.Join(t.TempDir(), "reports")
+	var out bytes.Buffer
+	if code := Run([]string{"--output-dir", dir, "--formats", "json, csv,md,html,json", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %q", out.String())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read output dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"hotspots.csv", "hotspots.html", "hotspots.json", "hotspots.md"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected files %v, got %v", want, names)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hotspots.json"))
+	if err != nil {
+		t.Fatalf("Failed to read JSON report: %v", err)
+	}
+	var got struct {
+		Summary struct {
+			Commits int `json:"commits"`
+		} `json:"summary"`
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Expected JSON: %v\n%s", err, data)
+	}
+	if got.Summary.Commits != 1 || len(got.Files) != 1 || got.Files[0].Path != "src/main.go" {
+		t.Errorf("Expected a summary and src/main.go, got %s", data)
+	}
+	for name, want := range map[string]string{"hotspots.csv": "file,src/main.go,1,", "hotspots.md": "| src/main.go |", "hotspots.html": "<td>src/main.go</td>"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", name, want, data, err)
+		}
+	}
+}
+
+func TestRunJSONActivity(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"main.go"}, "Initial commit", time.Now().AddDate(0, 0, -21))
+
+	// Sparklines are counted during the analysis, spanning the year
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "json", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files []struct {
+			Activity []int `json:"activity"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON: %v\n%s", err, out.String())
+	}
+	if len(got.Files) != 1 || len(got.Files[0].Activity) != 12 || got.Files[0].Activity[11] != 1 {
+		t.Errorf("Expected the commit in the last bucket, got %s", out.String())
+	}
+}
+
+func TestRunRepoURL(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	// Paths are relative to --path, so the links include it
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "md", "--repo-url", "https://github.com/org/repo/blob/main/", "--path", "src", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if want := "| [main.go](https://github.com/org/repo/blob/main/src/main.go) |"; !strings.Contains(out.String(), want) {
+		t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+	}
+
+	// A path naming a single file reports it by its base name, so the link
+	// only includes its directory
+	out.Reset()
+	if code := Run([]string{"--format", "md", "--repo-url", "https://github.com/org/repo/blob/main", "--path", "src/main.go", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if want := "| [main.go](https://github.com/org/repo/blob/main/src/main.go) |"; !strings.Contains(out.String(), want) {
+		t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+	}
+}
+
+func TestRunIncludeUntouched(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "docs/guide.md"}, "Initial import", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/util.go"}, "Add util", now.Add(-time.Hour))
+
+	// Untouched files are listed after the hotspots, filtered by --path
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "json", "--include-untouched", "--exclude-initial-commit", "--path", "src", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files []struct {
+			Path    string `json:"path"`
+			Commits int    `json:"commits"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if len(got.Files) != 2 || got.Files[0].Path != "util.go" || got.Files[1].Path != "main.go" || got.Files[1].Commits != 0 {
+		t.Errorf("Expected util.go, then main.go without commits, got %+v", got.Files)
+	}
+
+	// Merged repositories are seeded under their names
+	out.Reset()
+	if code := Run([]string{"--format", "json", "--include-untouched", "--exclude-initial-commit", "--merge", tmpDir, tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "/docs/guide.md\"") {
+		t.Errorf("Expected the untouched file of the merged repositories, got: %s", out.String())
+	}
+}
+
+func TestRunHeatmap(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/util.go"}, "Add util", now.AddDate(0, 0, -20))
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Fix util", Date: now.AddDate(0, 0, -19), Write: map[string]string{"src/util.go": "v2"}})
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Tidy util", Date: now.AddDate(0, 0, -18), Write: map[string]string{"src/util.go": "v3"}})
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Add main", now.AddDate(0, 0, -3))
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Fix main", Date: now.AddDate(0, 0, -2), Write: map[string]string{"src/main.go": "v2"}})
+	testutil.CreateCommit(t, tmpDir, []string{"README.md"}, "Add README", now.Add(-time.Hour))
+
+	// The top files by commits get a row of commits per day, most changed first
+	var out bytes.Buffer
+	if code := Run([]string{"--mode", "heatmap", "--heatmap-bucket", "day", "--format", "json", "--top", "2", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Bucket  string   `json:"bucket"`
+		Periods []string `json:"periods"`
+		Paths   []string `json:"paths"`
+		Counts  [][]int  `json:"counts"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if got.Bucket != "day" || len(got.Paths) != 2 || len(got.Counts) != 2 {
+		t.Fatalf("Expected 2 rows of days, got %+v", got)
+	}
+	if want := []string{"src/util.go", "src/main.go"}; !reflect.DeepEqual(got.Paths, want) {
+		t.Errorf("Expected rows for %v, got %v", want, got.Paths)
+	}
+	for i, counts := range got.Counts {
+		if len(counts) != len(got.Periods) {
+			t.Errorf("Expected a cell for each of %d days in row %d, got %d", len(got.Periods), i, len(counts))
+		}
+	}
+
+	out.Reset()
+	if code := Run([]string{"--mode", "heatmap", "--format", "table", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.HasPrefix(out.String(), "Commits per week from ") || !strings.Contains(out.String(), "src/util.go") {
+		t.Errorf("Expected a weekly grid, got: %s", out.String())
+	}
+}
+
+func TestRunTree(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "README.md"}, "Initial import", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/util.go"}, "Add util", now.Add(-time.Hour))
+
+	// Files are nested under their directories, which sum their counts
+	var out bytes.Buffer
+	if code := Run([]string{"--mode", "tree", "--format", "json", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	type node struct {
+		Path     string `json:"path"`
+		Type     string `json:"type"`
+		Commits  int    `json:"commits"`
+		Children []node `json:"children"`
+	}
+	var got node
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if got.Path != "." || got.Commits != 3 || len(got.Children) != 2 {
+		t.Fatalf("Expected a root with 3 commits and 2 children, got %+v", got)
+	}
+	if src := got.Children[0]; src.Path != "src" || src.Type != "directory" || src.Commits != 2 || len(src.Children) != 2 {
+		t.Errorf("Expected src first with 2 commits over 2 files, got %+v", src)
+	}
+	if readme := got.Children[1]; readme.Path != "README.md" || readme.Type != "file" {
+		t.Errorf("Expected README.md file last, got %+v", readme)
+	}
+
+	out.Reset()
+	if code := Run([]string{"--mode", "tree", "--format", "table", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.HasPrefix(out.String(), ". (3 commits") || !strings.Contains(out.String(), "│   ") {
+		t.Errorf("Expected an indented tree, got: %s", out.String())
+	}
+}
+
+func TestRunCountOnly(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "README.md"}, "Initial import", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/util.go"}, "Add util", now.Add(-time.Hour))
+
+	// Totals are printed as key=value lines instead of a ranking
+	var out bytes.Buffer
+	if code := Run([]string{"--count-only", tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if expected := "commits=2\nfiles=3\nauthors=1\n"; out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+
+	// Or as JSON, here for both repositories merged
+	out.Reset()
+	if code := Run([]string{"--count-only", "--format", "json", "--merge", tmpDir, tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Commits int `json:"commits"`
+		Files   int `json:"files"`
+		Authors int `json:"authors"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if got.Commits != 4 || got.Files != 6 || got.Authors != 1 {
+		t.Errorf("Expected 4 commits, 6 files and 1 author, got %+v", got)
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Add main", time.Now().Add(-time.Hour))
+	writeConfig := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, ".git-hotspots.yaml"), []byte(contents), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+
+	// Config values apply before the arguments are checked, so merge from
+	// the config file lets every argument be a repository
+	writeConfig("merge: true\ncount-only: true\nformat: json\n")
+	var out bytes.Buffer
+	if code := Run([]string{tmpDir, tmpDir, tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Commits int `json:"commits"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+	}
+	if got.Commits != 3 {
+		t.Errorf("Expected the commit of each of 3 merged repositories, got %d", got.Commits)
+	}
+
+	// Only option names are keys, and there's no option for the window
+	writeConfig("since: 30d\n")
+	out.Reset()
+	if code := Run([]string{tmpDir}, nil, &out, io.Discard); code != 1 || !strings.Contains(out.String(), `unknown config key "since"`) {
+		t.Errorf("Expected since to be rejected, got status %d and %q", code, out.String())
+	}
+}
+
+func TestRunConfigLists(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	for i, author := range []string{"Alice", "dependabot[bot]", "renovate"} {
+		testutil.Commit(t, tmpDir, testutil.Change{Author: author, Date: now.Add(time.Duration(i-3) * time.Hour), Write: map[string]string{"main.go": author}})
+	}
+	writeConfig := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, ".git-hotspots.yaml"), []byte(contents), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+	counts := func() (commits, authors int) {
+		t.Helper()
+		var out bytes.Buffer
+		if code := Run([]string{"--count-only", "--format", "json", tmpDir}, nil, &out, io.Discard); code != 0 {
+			t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+		}
+		var got struct {
+			Commits int `json:"commits"`
+			Authors int `json:"authors"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, out.String())
+		}
+		return got.Commits, got.Authors
+	}
+
+	// Each item of a list is a pattern of its own, as with repeated flags
+	writeConfig("exclude-author: [\"*[bot]\", \"renovate\"]\n")
+	if commits, authors := counts(); commits != 1 || authors != 1 {
+		t.Errorf("Expected 1 commit by 1 author left, got %d commits by %d authors", commits, authors)
+	}
+	writeConfig("alias: [\"renovate = Bots\", \"dependabot[bot] = Bots\"]\n")
+	if commits, authors := counts(); commits != 3 || authors != 2 {
+		t.Errorf("Expected 3 commits by Alice and Bots, got %d commits by %d authors", commits, authors)
+	}
+}
+
+func TestRunCommitIssues(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Add main", time.Now().Add(-time.Hour))
+
+	// An empty commit can only be counted as changing every file
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+	signature := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("Empty", &git.CommitOptions{Author: signature, Committer: signature, AllowEmptyCommits: true}); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := Run([]string{"--format", "json", tmpDir}, nil, &out, &errOut); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), `"wholeTree": 1`) {
+		t.Errorf("Expected the issue in the JSON summary, got: %s", out.String())
+	}
+	if !strings.Contains(errOut.String(), "1 without changes against their parents") {
+		t.Errorf("Expected the issue on stderr, got: %s", errOut.String())
+	}
+
+	// Commits with a parent missing are listed
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "orphan.go"), []byte("orphan"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := wt.Add("orphan.go"); err != nil {
+		t.Fatalf("Failed to add file: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	missing := plumbing.NewHash("0123456789012345678901234567890123456789")
+	orphan, err := wt.Commit("Orphan", &git.CommitOptions{Author: signature, Committer: signature, Parents: []plumbing.Hash{head.Hash(), missing}})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	hashes := filepath.Join(t.TempDir(), "hashes")
+	if err := ioutil.WriteFile(hashes, []byte(orphan.String()+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write hashes: %v", err)
+	}
+	out.Reset()
+	errOut.Reset()
+	if code := Run([]string{"--format", "json", "--commits-from", hashes, tmpDir}, nil, &out, &errOut); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(errOut.String(), "1 with a parent that couldn't be read") || !strings.Contains(errOut.String(), orphan.String()) {
+		t.Errorf("Expected the orphan commit on stderr, got: %s", errOut.String())
+	}
+}
+
+func TestRunProfiles(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Add main", time.Now().Add(-time.Hour))
+
+	profileDir := t.TempDir()
+	cpuProfile := filepath.Join(profileDir, "cpu.pprof")
+	memProfile := filepath.Join(profileDir, "mem.pprof")
+	var out bytes.Buffer
+	if code := Run([]string{"--format", "json", "--cpuprofile", cpuProfile, "--memprofile", memProfile, tmpDir}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	for _, name := range []string{cpuProfile, memProfile} {
+		if info, err := os.Stat(name); err != nil || info.Size() == 0 {
+			t.Errorf("Expected a profile in %s, got %v", name, err)
+		}
+	}
+
+	// A profile that can't be created is an error
+	out.Reset()
+	if code := Run([]string{"--cpuprofile", filepath.Join(profileDir, "missing", "cpu.pprof"), tmpDir}, nil, &out, io.Discard); code != 1 {
+		t.Errorf("Expected exit status 1, got %d", code)
+	}
+	if !strings.Contains(out.String(), "failed to create CPU profile") {
+		t.Errorf("Expected an error about the profile, got: %s", out.String())
+	}
+}
+
+func TestRunRemoteURL(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go"}, "Add main", time.Now().Add(-time.Hour))
+
+	// The repository is cloned, analyzed and removed again
+	var out, errOut bytes.Buffer
+	if code := Run([]string{"--format", "json", "file://" + filepath.ToSlash(tmpDir)}, nil, &out, &errOut); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), `"path": "src/main.go"`) {
+		t.Errorf("Expected the cloned file in the output, got: %s", out.String())
+	}
+	if !strings.Contains(errOut.String(), "Cloning file://") {
+		t.Errorf("Expected a cloning message on stderr, got: %s", errOut.String())
+	}
+}
+
+func TestRunRemoteURLConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "src/util.go"}, "Add main", now.Add(-2*time.Hour))
+	testutil.Commit(t, tmpDir, testutil.Change{Date: now.Add(-time.Hour), Write: map[string]string{
+		"src/main.go":        "changed",
+		".git-hotspots.yaml": "format: json\ntop: 1\n",
+	}})
+
+	// The config file committed to the cloned repository applies
+	var out bytes.Buffer
+	if code := Run([]string{"file://" + filepath.ToSlash(tmpDir)}, nil, &out, io.Discard); code != 0 {
+		t.Fatalf("Run exited with status %d\nOutput: %s", code, out.String())
+	}
+	var got struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output from the config file: %v\nOutput: %s", err, out.String())
+	}
+	if len(got.Files) != 1 || got.Files[0].Path != "src/main.go" {
+		t.Errorf("Expected only the top file src/main.go, got %+v", got.Files)
+	}
+}
+
+func TestRemoteName(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/org/repo.git": "repo",
+		"https://github.com/org/repo/":    "repo",
+		"git@github.com:org/repo.git":     "repo",
+		"git@example.com:repo.git":        "repo",
+	} {
+		if got := remoteName(url); got != want {
+			t.Errorf("remoteName(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestAbsoluteMergedPaths(t *testing.T) {
+	roots := map[string]string{
+		"api":     filepath.FromSlash("/src/api"),
+		"api/web": filepath.FromSlash("/other/api/web"),
+	}
+	hs := []hotspots.Hotspot{{Path: "api/main.go"}, {Path: "api/web/index.js"}, {Path: "api"}}
+	absoluteMergedPaths(hs, roots, "")
+
+	expected := []string{
+		filepath.FromSlash("/src/api/main.go"),
+		filepath.FromSlash("/other/api/web/index.js"),
+		filepath.FromSlash("/src/api"),
+	}
+	for i, h := range hs {
+		if h.Path != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], h.Path)
+		}
+	}
+}
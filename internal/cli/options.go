@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"git-hotspots/internal/git"
+	"git-hotspots/pkg/report"
+)
+
+// options holds the parsed command-line flags, and the values
+// validateFlags derives from them.
+type options struct {
+	topCount              int
+	mode                  string
+	defectPattern         string
+	format                string
+	output                string
+	outputDir             string
+	formatsFlag           string
+	subpath               string
+	normalizeByCommitSize bool
+	normalizeDirBySize    bool
+	normalizeChurnBySize  bool
+	noFiles               bool
+	noDirs                bool
+	reverse               bool
+	includeUntouched      bool
+	minCommits            int
+	excludeBursts         time.Duration
+	maxIdle               time.Duration
+	activeWithin          time.Duration
+	rankBy                string
+	scoreExprFlag         string
+	weightFlag            string
+	tagPattern            string
+	withGravatar          bool
+	merge                 bool
+	separate              bool
+	failIfCommits         int
+	failIfScore           float64
+	trendSplit            float64
+	heatmapBucket         string
+	excludeInitialCommit  bool
+	mergeStrategy         string
+	maxFilesPerCommit     int
+	commitsFrom           string
+	dateFormatFlag        string
+	noColor               bool
+	compact               bool
+	repoURL               string
+	aliasFlags            stringList
+	aliasesFile           string
+	excludeAuthorFlags    stringList
+	excludeAuthorsFile    string
+	tieBreak              string
+	ownerThreshold        float64
+	caseInsensitivePaths  bool
+	firstCommitAsCreation bool
+	countCoAuthors        bool
+	excludeTests          bool
+	testPatternFlags      stringList
+	ignoreDeletions       bool
+	respectGitIgnore      bool
+	includeSubmodules     bool
+	group                 string
+	rootLabel             string
+	displayDepth          int
+	componentsFile        string
+	ignoreWhitespace      bool
+	explain               bool
+	countOnly             bool
+	requireFullHistory    bool
+	cpuProfile            string
+	memProfile            string
+	cacheSize             int
+	backend               string
+	lang                  string
+	sample                float64
+	seed                  int64
+	limitCommits          int
+	extrapolate           bool
+	allRefs               bool
+	remotes               bool
+	file                  string
+	onlyExisting          bool
+	pathStyle             string
+	watch                 bool
+	watchInterval         time.Duration
+	keepClone             bool
+	testMode              bool
+	summaryOnly           bool
+
+	// Set by validateFlags
+	formats      []string
+	dateFormat   report.DateFormat
+	scoreExpr    *git.ScoreExpr
+	countLines   bool
+	extensions   []string
+	testPatterns *git.TestPatterns
+	tagWeights   *git.TagWeights
+	defects      *regexp.Regexp
+}
+
+// newFlagSet returns the flag set parsing the command line into opts,
+// printing usage and parse errors to stderr.
+func newFlagSet(opts *options, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("git-hotspots", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.IntVar(&opts.topCount, "top", 10, "Number of top files and directories to display")
+	flags.StringVar(&opts.mode, "mode", "hotspots", "Analysis mode: hotspots, knowledge-map, ownership-changes, trend, heatmap, defects, contributors or tree")
+	flags.StringVar(&opts.defectPattern, "defect-pattern", "", "Regular expression matching the messages of bug-fixing commits in defects mode (default: fixes, bugs and issue references)")
+	flags.StringVar(&opts.format, "format", "ui", "Output format: ui, table, json, jsonl, prometheus, sqlite, csv, md or html (table is used instead of ui when stdout isn't a terminal)")
+	flags.StringVar(&opts.output, "output", "", "Database file to append snapshots to with --format sqlite")
+	flags.StringVar(&opts.outputDir, "output-dir", "", "Directory to write a hotspots file to in each of --formats, analyzing once")
+	flags.StringVar(&opts.formatsFlag, "formats", "", "Comma-separated formats to write to --output-dir: table, json, jsonl, prometheus, csv, md or html")
+	flags.StringVar(&opts.subpath, "path", "", "Restrict analysis to files under this path in the repository")
+	flags.BoolVar(&opts.normalizeByCommitSize, "normalize-by-commit-size", false, "Weight each file by 1/number of files in the commit")
+	flags.BoolVar(&opts.normalizeDirBySize, "normalize-dir-by-size", false, "Rank directories by their score per file currently under them in HEAD, surfacing small directories that change a lot")
+	flags.BoolVar(&opts.normalizeChurnBySize, "normalize-churn-by-size", false, "With --rank-by churn, rank files by their lines changed per line currently in HEAD, surfacing small files that change a lot")
+	flags.BoolVar(&opts.noFiles, "no-files", false, "Only identify directory hotspots")
+	flags.BoolVar(&opts.noDirs, "no-dirs", false, "Only identify file hotspots")
+	flags.BoolVar(&opts.reverse, "reverse", false, "List the least changed files and directories first, including those in HEAD without commits in the window")
+	flags.BoolVar(&opts.includeUntouched, "include-untouched", false, "Include every file in HEAD, with zero commits if it wasn't changed in the window")
+	flags.IntVar(&opts.minCommits, "min-commits", 0, "Hide files and directories with fewer commits than this")
+	flags.DurationVar(&opts.excludeBursts, "exclude-bursts", 0, "Hide directories whose commits all fall within this span of each other, such as bulk imports, e.g. 24h")
+	flags.DurationVar(&opts.maxIdle, "max-idle", 0, "Only show files and directories not modified within this long before the end of the window, e.g. 720h")
+	flags.DurationVar(&opts.activeWithin, "active-within", 0, "Only show files and directories modified within this long before the end of the window, e.g. 168h")
+	flags.StringVar(&opts.rankBy, "rank-by", string(git.RankByScore), "Ranking: score, hot-per-day (score per day since the file was first seen), churn (lines added and deleted), weighted (score with commits weighted by --weight), reverts (commits reverting earlier ones) or concentration (how much one author dominates the commits)")
+	flags.StringVar(&opts.scoreExprFlag, "score-expr", "", "Rank by a custom formula over commits, churn, authorCount, ageDays, idleDays, reverts and deletions, e.g. \"commits * 2 + churn\"")
+	flags.StringVar(&opts.weightFlag, "weight", "", "Weights of commit message tags for --rank-by weighted, e.g. fix=3,feat=1 (other commits weigh 1)")
+	flags.StringVar(&opts.tagPattern, "tag-pattern", "", "Regular expression whose first group is the tag of a commit subject (default: Conventional Commits prefixes)")
+	flags.BoolVar(&opts.withGravatar, "with-gravatar", false, "Include gravatar hashes of top contributor emails in JSON output")
+	flags.BoolVar(&opts.merge, "merge", false, "Analyze all repository arguments as one combined ranking")
+	flags.BoolVar(&opts.separate, "separate", false, "Analyze all repository arguments and show each separately")
+	flags.IntVar(&opts.failIfCommits, "fail-if-commits", 0, "Exit with a non-zero status if any hotspot has more commits than this")
+	flags.Float64Var(&opts.failIfScore, "fail-if-score", 0, "Exit with a non-zero status if any hotspot has a higher score than this")
+	flags.Float64Var(&opts.trendSplit, "trend-split", git.DefaultTrendSplit, "Split point for trend mode, as a fraction of the analysis window")
+	flags.StringVar(&opts.heatmapBucket, "heatmap-bucket", string(git.BucketWeek), "Period heatmap mode counts commits per: day, week or month")
+	flags.BoolVar(&opts.excludeInitialCommit, "exclude-initial-commit", false, "Skip root commits, such as an initial bulk import, whatever their size")
+	flags.StringVar(&opts.mergeStrategy, "merge-commit-strategy", string(git.MergeUnion), "Which changes of merge commits to count: union (against every parent), first-parent (what the merge brought in) or none (skip merges)")
+	flags.IntVar(&opts.maxFilesPerCommit, "max-files-per-commit", 0, "Skip commits touching more files than this")
+	flags.StringVar(&opts.commitsFrom, "commits-from", "", "Analyze the commit hashes listed in this file (- for stdin) instead of the history")
+	flags.StringVar(&opts.dateFormatFlag, "date-format", "", "Date format: rfc3339, unix, relative or a Go time layout (default rfc3339 for JSON, relative for the UI)")
+	flags.BoolVar(&opts.noColor, "no-color", false, "Disable colors in the UI (also disabled by the NO_COLOR environment variable)")
+	flags.BoolVar(&opts.compact, "compact", false, "Fit the UI's tables to small terminals, leaving out the top contributor and shortening paths")
+	flags.StringVar(&opts.repoURL, "repo-url", "", "Link file paths in md and html output to this URL followed by the path, e.g. https://github.com/org/repo/blob/main")
+	flags.Var(&opts.aliasFlags, "alias", `Merge an author identity into a canonical name, e.g. "Bot <bot@example.com> = Automation" (repeatable)`)
+	flags.StringVar(&opts.aliasesFile, "aliases", "", "File of author aliases, one per line in the format of --alias")
+	flags.Var(&opts.excludeAuthorFlags, "exclude-author", `Skip the commits of authors whose name or email matches this pattern, e.g. "*[bot]", or /regexp/ (repeatable)`)
+	flags.StringVar(&opts.excludeAuthorsFile, "exclude-authors-file", "", "File of author patterns to skip the commits of, one per line in the format of --exclude-author, with # comments")
+	flags.StringVar(&opts.tieBreak, "contributor-tie-break", string(git.TieBreakName), "How to pick the top contributor among authors tied for the most commits: name (first alphabetically) or recent (latest commit)")
+	flags.Float64Var(&opts.ownerThreshold, "owner-threshold", 0, "Flag files and directories whose top contributor made more than this fraction of the commits, e.g. 0.8, as knowledge silos")
+	flags.BoolVar(&opts.caseInsensitivePaths, "case-insensitive-paths", false, "Count paths differing only in case, such as File.go and file.go, as the same hotspot")
+	flags.BoolVar(&opts.firstCommitAsCreation, "first-commit-as-creation", false, "Date the creation of files added before the analysis window from their first commit in it, so every file has a lifetime")
+	flags.BoolVar(&opts.countCoAuthors, "count-coauthors", false, "Credit co-authors from Co-authored-by trailers as well as the author when finding top contributors")
+	flags.BoolVar(&opts.excludeTests, "exclude-tests", false, "Leave test files, such as *_test.go and test_*.py in the languages of --lang, out of hotspots, counting them per directory instead")
+	flags.Var(&opts.testPatternFlags, "test-pattern", `Also treat files matching this glob, e.g. "**/fixtures", as tests for --exclude-tests (repeatable)`)
+	flags.BoolVar(&opts.ignoreDeletions, "ignore-deletions", false, "Don't count commits deleting or moving away a file toward its hotspot, so only changes to live code are scored")
+	flags.BoolVar(&opts.respectGitIgnore, "respect-gitignore", false, "Skip files ignored by the repository's .gitignore files, .git/info/exclude and core.excludesFile, even if committed")
+	flags.BoolVar(&opts.includeSubmodules, "include-submodules", false, "Count submodule pointer updates as changed files")
+	flags.StringVar(&opts.group, "group", "directory", "Group files into directory hotspots by directory, or by go-module: the Go module of the nearest go.mod")
+	flags.StringVar(&opts.rootLabel, "root-label", "", `Group files in the repository root into a directory hotspot with this name, e.g. "<root>", instead of leaving them out of directories`)
+	flags.IntVar(&opts.displayDepth, "display-depth", 0, "Only show directories down to this depth, rolling the counts of deeper ones up into their ancestors without changing the analysis")
+	flags.StringVar(&opts.componentsFile, "components", "", "YAML file mapping path globs to components, to group hotspots by component instead of directory")
+	flags.BoolVar(&opts.ignoreWhitespace, "ignore-whitespace", false, "Ignore lines differing only in whitespace when counting lines changed (affects churn only)")
+	flags.BoolVar(&opts.explain, "explain", false, "Print the ref, date bounds and filters used to select commits and how many matched, then exit")
+	flags.BoolVar(&opts.countOnly, "count-only", false, "Print the numbers of commits analyzed, distinct files touched and distinct authors as key=value lines (or JSON with --format json), then exit")
+	flags.BoolVar(&opts.requireFullHistory, "require-full-history", false, "Fail instead of warning when a repository is a shallow clone")
+	flags.StringVar(&opts.cpuProfile, "cpuprofile", "", "Write a CPU profile of the analysis to this file, for go tool pprof")
+	flags.StringVar(&opts.memProfile, "memprofile", "", "Write a memory profile taken after the analysis to this file, for go tool pprof")
+	flags.IntVar(&opts.cacheSize, "cache-size", 0, "Size in MiB of the object cache used while reading the history (default 96, go-git's default)")
+	flags.StringVar(&opts.backend, "backend", string(git.BackendGoGit), "How to read commits: go-git, or git to run the system git binary for repositories go-git can't read")
+	flags.StringVar(&opts.lang, "lang", "", "Only analyze files in these comma-separated languages, e.g. go,python")
+	flags.Float64Var(&opts.sample, "sample", 0, "Analyze a random fraction of the commits, e.g. 0.1, for a quick estimate")
+	flags.Int64Var(&opts.seed, "seed", 0, "Seed for choosing the commits analyzed with --sample")
+	flags.IntVar(&opts.limitCommits, "limit-commits", 0, "Only walk the most recent N commits, for a quick approximate report")
+	flags.BoolVar(&opts.extrapolate, "extrapolate", false, "Scale counts up by the inverse of --sample to estimate them for all commits")
+	flags.BoolVar(&opts.allRefs, "all", false, "Analyze the commits reachable from every local branch, not just HEAD")
+	flags.BoolVar(&opts.remotes, "remotes", false, "With --all, also analyze the commits reachable from remote-tracking branches")
+	flags.StringVar(&opts.file, "file", "", "Show the full history of a single file, following renames, instead of ranking hotspots")
+	flags.BoolVar(&opts.onlyExisting, "only-existing", false, "Drop files and directories that no longer exist in HEAD")
+	flags.StringVar(&opts.pathStyle, "path-style", "relative", "Paths in the output: relative to the repository, or absolute")
+	flags.BoolVar(&opts.watch, "watch", false, "Keep running and re-run the analysis whenever HEAD moves, until interrupted")
+	flags.DurationVar(&opts.watchInterval, "watch-interval", 2*time.Second, "How often --watch checks whether HEAD moved")
+	flags.BoolVar(&opts.keepClone, "keep-clone", false, "Keep the temporary clones of repositories given by URL instead of removing them on exit")
+	flags.BoolVar(&opts.testMode, "test-mode", false, "Run in test mode: write JSON instead of launching the UI unless --format is given")
+	flags.BoolVar(&opts.summaryOnly, "summary", false, "Print a plain-text summary of the top hotspots instead of launching the UI")
+	return flags
+}
+
+// multiRepo reports whether every argument is a repository, with --merge or
+// --separate.
+func (opts *options) multiRepo() bool {
+	return opts.merge || opts.separate
+}
+
+// untouched reports whether files in HEAD without commits in the window are
+// listed, as coldspots are only complete with the files that never changed.
+func (opts *options) untouched() bool {
+	return opts.reverse || opts.includeUntouched
+}
+
+// validateFlags checks the flags in opts are valid together, parsing the
+// values derived from them into opts. Errors read as the rest of a line
+// starting with "Error: ".
+func validateFlags(opts *options) error {
+	multiRepo := opts.multiRepo()
+
+	// Validate mode and format
+	if opts.mode != "hotspots" && opts.mode != "knowledge-map" && opts.mode != "ownership-changes" && opts.mode != "trend" && opts.mode != "heatmap" && opts.mode != "defects" && opts.mode != "contributors" && opts.mode != "tree" {
+		return fmt.Errorf("unknown mode %q (expected hotspots, knowledge-map, ownership-changes, trend, heatmap, defects, contributors or tree)", opts.mode)
+	}
+	var err error
+	if opts.dateFormat, err = report.ParseDateFormat(opts.dateFormatFlag); err != nil {
+		return err
+	}
+	if opts.trendSplit <= 0 || opts.trendSplit >= 1 {
+		return fmt.Errorf("--trend-split must be between 0 and 1, got %v", opts.trendSplit)
+	}
+	if opts.heatmapBucket != string(git.BucketDay) && opts.heatmapBucket != string(git.BucketWeek) && opts.heatmapBucket != string(git.BucketMonth) {
+		return fmt.Errorf("unknown heatmap bucket %q (expected day, week or month)", opts.heatmapBucket)
+	}
+	if _, ok := outputFileNames[opts.format]; !ok && opts.format != "ui" && opts.format != "sqlite" {
+		return fmt.Errorf("unknown format %q (expected ui, table, json, jsonl, prometheus, sqlite, csv, md or html)", opts.format)
+	}
+	if (opts.format == "sqlite") != (opts.output != "") {
+		return errors.New("--format sqlite and --output must be used together")
+	}
+	if opts.format == "sqlite" && (opts.mode != "hotspots" || opts.separate || opts.file != "") {
+		return errors.New("--format sqlite is only supported in hotspots mode, without --separate or --file")
+	}
+	if (opts.format == "jsonl" || opts.format == "prometheus") && opts.file != "" {
+		return fmt.Errorf("--format %s isn't supported with --file", opts.format)
+	}
+	if (opts.format == "jsonl" || opts.format == "prometheus") && opts.mode != "hotspots" {
+		return fmt.Errorf("--format %s isn't supported in %s mode", opts.format, opts.mode)
+	}
+	if (opts.format == "csv" || opts.format == "md" || opts.format == "html") && (opts.mode != "hotspots" || opts.separate || opts.file != "") {
+		return fmt.Errorf("--format %s is only supported in hotspots mode, without --separate or --file", opts.format)
+	}
+	if opts.countOnly && (opts.mode != "hotspots" || opts.separate || opts.file != "" || opts.watch || opts.explain) {
+		return errors.New("--count-only can't be used with --mode, --separate, --file, --watch or --explain")
+	}
+	if opts.countOnly && opts.format != "ui" && opts.format != "table" && opts.format != "json" {
+		return fmt.Errorf("--format %s isn't supported with --count-only", opts.format)
+	}
+
+	// Write every requested format to its own file if requested, checking
+	// the directory can be written to before the analysis
+	if (opts.outputDir != "") != (opts.formatsFlag != "") {
+		return errors.New("--output-dir and --formats must be used together")
+	}
+	if opts.outputDir != "" {
+		for _, f := range strings.Split(opts.formatsFlag, ",") {
+			f = strings.TrimSpace(f)
+			if _, ok := outputFileNames[f]; !ok {
+				return fmt.Errorf("unknown format %q in --formats (expected table, json, jsonl, prometheus, csv, md or html)", f)
+			}
+			if !slices.Contains(opts.formats, f) {
+				opts.formats = append(opts.formats, f)
+			}
+		}
+		if opts.mode != "hotspots" || opts.separate || opts.file != "" || opts.countOnly || opts.watch || opts.explain || opts.summaryOnly || opts.format == "sqlite" {
+			return errors.New("--output-dir is only supported in hotspots mode, without --separate, --file, --count-only, --watch, --explain, --summary or --format sqlite")
+		}
+		if err := checkWritableDir(opts.outputDir); err != nil {
+			return fmt.Errorf("--output-dir: %v", err)
+		}
+	}
+
+	if opts.pathStyle != "relative" && opts.pathStyle != "absolute" {
+		return fmt.Errorf("unknown path style %q (expected relative or absolute)", opts.pathStyle)
+	}
+	if opts.pathStyle == "absolute" && opts.mode == "tree" {
+		return errors.New("--path-style absolute isn't supported in tree mode")
+	}
+	if opts.repoURL != "" && opts.format != "md" && opts.format != "html" && !slices.Contains(opts.formats, "md") && !slices.Contains(opts.formats, "html") {
+		return errors.New("--repo-url is only supported with --format md or html")
+	}
+	// Links only work for paths relative to a single repository
+	if opts.repoURL != "" && (multiRepo || opts.pathStyle == "absolute") {
+		return errors.New("--repo-url can't be used with --merge, --separate or --path-style absolute")
+	}
+	if opts.rankBy != string(git.RankByScore) && opts.rankBy != string(git.RankByHotPerDay) && opts.rankBy != string(git.RankByChurn) && opts.rankBy != string(git.RankByWeighted) && opts.rankBy != string(git.RankByReverts) && opts.rankBy != string(git.RankByConcentration) {
+		return fmt.Errorf("unknown ranking %q (expected score, hot-per-day, churn, weighted, reverts or concentration)", opts.rankBy)
+	}
+	if opts.scoreExprFlag != "" {
+		if opts.rankBy != string(git.RankByScore) {
+			return errors.New("--score-expr can't be used with --rank-by")
+		}
+		if opts.scoreExpr, err = git.ParseScoreExpr(opts.scoreExprFlag); err != nil {
+			return fmt.Errorf("--score-expr: %v", err)
+		}
+	}
+	if opts.untouched() && opts.mode != "hotspots" {
+		return errors.New("--reverse and --include-untouched are only supported in hotspots mode")
+	}
+	if opts.normalizeDirBySize && (multiRepo || opts.scoreExpr != nil || (opts.rankBy != string(git.RankByScore) && opts.rankBy != string(git.RankByHotPerDay) && opts.rankBy != string(git.RankByWeighted))) {
+		return errors.New("--normalize-dir-by-size only works on a single repository ranked by score, hot-per-day or weighted")
+	}
+	if opts.normalizeChurnBySize && (multiRepo || opts.scoreExpr != nil || opts.rankBy != string(git.RankByChurn)) {
+		return errors.New("--normalize-churn-by-size only works on a single repository ranked by churn")
+	}
+	if opts.backend != string(git.BackendGoGit) && opts.backend != string(git.BackendGit) {
+		return fmt.Errorf("unknown backend %q (expected go-git or git)", opts.backend)
+	}
+	if opts.mergeStrategy != string(git.MergeUnion) && opts.mergeStrategy != string(git.MergeFirstParent) && opts.mergeStrategy != string(git.MergeNone) {
+		return fmt.Errorf("unknown merge commit strategy %q (expected union, first-parent or none)", opts.mergeStrategy)
+	}
+	if opts.tieBreak != string(git.TieBreakName) && opts.tieBreak != string(git.TieBreakRecent) {
+		return fmt.Errorf("unknown contributor tie-break %q (expected name or recent)", opts.tieBreak)
+	}
+	opts.countLines = git.Ranking(opts.rankBy) == git.RankByChurn || (opts.scoreExpr != nil && opts.scoreExpr.Uses("churn"))
+	if git.Backend(opts.backend) == git.BackendGit && (opts.countLines || opts.file != "") {
+		return errors.New("--backend git can't count lines for --rank-by churn or churn in --score-expr, or follow --file")
+	}
+
+	if opts.remotes && !opts.allRefs {
+		return errors.New("--remotes requires --all")
+	}
+	if opts.allRefs && (opts.commitsFrom != "" || opts.file != "") {
+		return errors.New("--all can't be used with --commits-from or --file")
+	}
+	if opts.maxIdle > 0 && opts.activeWithin > 0 && opts.maxIdle >= opts.activeWithin {
+		return errors.New("--max-idle must be shorter than --active-within, or no hotspots can match")
+	}
+	if opts.ownerThreshold < 0 || opts.ownerThreshold >= 1 {
+		return fmt.Errorf("--owner-threshold must be between 0 and 1, got %v", opts.ownerThreshold)
+	}
+	if opts.displayDepth < 0 {
+		return fmt.Errorf("--display-depth can't be negative, got %d", opts.displayDepth)
+	}
+	if opts.group != "directory" && opts.group != "go-module" {
+		return fmt.Errorf("unknown grouping %q (expected directory or go-module)", opts.group)
+	}
+	if opts.group == "go-module" && (opts.componentsFile != "" || multiRepo) {
+		return errors.New("--group go-module can't be used with --components, --merge or --separate")
+	}
+	if opts.displayDepth > 0 && (opts.componentsFile != "" || opts.group != "directory" || opts.pathStyle == "absolute") {
+		return errors.New("--display-depth can't be used with --components, --group go-module or --path-style absolute")
+	}
+	if strings.Contains(opts.rootLabel, "/") {
+		return fmt.Errorf("--root-label %q can't contain a slash", opts.rootLabel)
+	}
+	if opts.rootLabel != "" && (opts.componentsFile != "" || opts.group != "directory") {
+		return errors.New("--root-label can't be used with --components or --group go-module")
+	}
+	if opts.merge && opts.separate {
+		return errors.New("--merge and --separate can't be used together")
+	}
+	if opts.separate && opts.mode != "hotspots" {
+		return fmt.Errorf("--separate isn't supported in %s mode", opts.mode)
+	}
+	if opts.file != "" && (multiRepo || opts.commitsFrom != "") {
+		return errors.New("--file can't be used with --merge, --separate or --commits-from")
+	}
+	if opts.file != "" && opts.lang != "" {
+		return errors.New("--lang can't be used with --file")
+	}
+	if opts.sample < 0 || opts.sample > 1 {
+		return fmt.Errorf("--sample must be between 0 and 1, got %v", opts.sample)
+	}
+	if opts.sample == 0 && (opts.extrapolate || opts.seed != 0) {
+		return errors.New("--seed and --extrapolate require --sample")
+	}
+	if opts.sample > 0 && opts.file != "" {
+		return errors.New("--sample can't be used with --file")
+	}
+	if opts.limitCommits < 0 {
+		return fmt.Errorf("--limit-commits can't be negative, got %d", opts.limitCommits)
+	}
+	if opts.limitCommits > 0 && opts.commitsFrom != "" {
+		return errors.New("--limit-commits can't be used with --commits-from")
+	}
+	if opts.lang != "" {
+		if opts.extensions, err = git.LanguageExtensions(strings.Split(opts.lang, ",")); err != nil {
+			return err
+		}
+	}
+	if len(opts.testPatternFlags) > 0 && !opts.excludeTests {
+		return errors.New("--test-pattern requires --exclude-tests")
+	}
+	if opts.excludeTests {
+		var languages []string
+		if opts.lang != "" {
+			languages = strings.Split(opts.lang, ",")
+		}
+		if opts.testPatterns, err = git.NewTestPatterns(languages, opts.testPatternFlags); err != nil {
+			return err
+		}
+	}
+	if opts.commitsFrom != "" && multiRepo {
+		return errors.New("--commits-from can't be used with --merge or --separate")
+	}
+	if opts.watch && (opts.mode != "hotspots" || multiRepo || opts.commitsFrom != "" || opts.file != "" || (opts.format != "ui" && opts.format != "table") || opts.summaryOnly) {
+		return errors.New("--watch only works in hotspots mode with the ui or table format, for a single repository without --commits-from, --file or --summary")
+	}
+	if opts.watchInterval <= 0 {
+		return fmt.Errorf("--watch-interval must be positive, got %v", opts.watchInterval)
+	}
+	if opts.noFiles && opts.noDirs {
+		return errors.New("--no-files and --no-dirs can't be used together")
+	}
+	if opts.noFiles && opts.mode != "hotspots" {
+		return fmt.Errorf("--no-files isn't supported in %s mode", opts.mode)
+	}
+	if (opts.failIfCommits > 0 || opts.failIfScore > 0) && opts.mode != "hotspots" {
+		return fmt.Errorf("--fail-if-commits and --fail-if-score aren't supported in %s mode", opts.mode)
+	}
+
+	// Weight commits by the tags of their messages if requested
+	if (opts.weightFlag != "" || opts.tagPattern != "") && git.Ranking(opts.rankBy) != git.RankByWeighted {
+		return errors.New("--weight and --tag-pattern require --rank-by weighted")
+	}
+	if git.Ranking(opts.rankBy) == git.RankByWeighted {
+		opts.tagWeights = &git.TagWeights{}
+		if opts.tagWeights.Weights, err = git.ParseTagWeights(opts.weightFlag); err != nil {
+			return fmt.Errorf("--weight: %v", err)
+		}
+		if opts.tagPattern != "" {
+			opts.tagWeights.Pattern, err = regexp.Compile(opts.tagPattern)
+			if err == nil && opts.tagWeights.Pattern.NumSubexp() == 0 {
+				err = fmt.Errorf("%q has no capture group for the tag", opts.tagPattern)
+			}
+			if err != nil {
+				return fmt.Errorf("--tag-pattern: %v", err)
+			}
+		}
+	}
+
+	// Count bug-fixing commits in defects mode
+	if opts.defectPattern != "" && opts.mode != "defects" {
+		return errors.New("--defect-pattern requires --mode defects")
+	}
+	if opts.mode == "defects" {
+		pattern := opts.defectPattern
+		if pattern == "" {
+			pattern = git.DefaultDefectPattern
+		}
+		if opts.defects, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("--defect-pattern: %v", err)
+		}
+	}
+	return nil
+}
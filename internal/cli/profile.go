@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// profiler writes pprof profiles of the analysis for --cpuprofile and
+// --memprofile, for inspection with go tool pprof.
+type profiler struct {
+	cpu     *os.File // Nil unless profiling the CPU
+	memPath string   // Empty unless writing a heap profile
+	stopped bool
+}
+
+// startProfiling starts profiling the CPU into cpuPath, if set, and returns a
+// profiler whose stop method also writes a heap profile to memPath, if set.
+func startProfiling(cpuPath, memPath string) (*profiler, error) {
+	p := &profiler{memPath: memPath}
+	if cpuPath == "" {
+		return p, nil
+	}
+	f, err := os.Create(cpuPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	p.cpu = f
+	return p, nil
+}
+
+// stop ends the analysis phase: it flushes the CPU profile and writes the
+// heap profile, reporting failures on stderr. It's called before the terminal
+// UI starts, so time spent browsing isn't profiled, and again on exit; only
+// the first call has any effect.
+func (p *profiler) stop(stderr io.Writer) {
+	if p == nil || p.stopped {
+		return
+	}
+	p.stopped = true
+
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		if err := p.cpu.Close(); err != nil {
+			fmt.Fprintf(stderr, "Failed to write CPU profile: %v\n", err)
+		}
+	}
+	if p.memPath != "" {
+		if err := writeHeapProfile(p.memPath); err != nil {
+			fmt.Fprintf(stderr, "Failed to write memory profile: %v\n", err)
+		}
+	}
+}
+
+// writeHeapProfile writes a profile of the live heap to path.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC() // Get up-to-date statistics
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git-hotspots/internal/git"
+	"git-hotspots/pkg/report"
+)
+
+// runServe runs the serve subcommand, which answers HTTP requests for the
+// JSON report of a repository until interrupted, and returns the exit status.
+func runServe(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("git-hotspots serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	cacheTTL := flags.Duration("cache-ttl", time.Minute, "How long a report is reused while the ref it was computed for doesn't move")
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	repoPath := "."
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stdout, "Error: serve takes a single repository.")
+		return 1
+	} else if flags.NArg() == 1 {
+		repoPath = flags.Arg(0)
+	}
+	if *cacheTTL < 0 {
+		fmt.Fprintf(stdout, "Error: --cache-ttl can't be negative, got %v\n", *cacheTTL)
+		return 1
+	}
+	absoluteRepoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error resolving path: %v\n", err)
+		return 1
+	}
+	if !git.IsGitRepository(absoluteRepoPath) {
+		fmt.Fprintf(stdout, "Error: %s is not a Git repository.\n", absoluteRepoPath)
+		return 1
+	}
+	repoRoot, err := git.RepositoryRoot(absoluteRepoPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	}
+	srv := &http.Server{Handler: newServer(repoRoot, *cacheTTL, stderr), ReadHeaderTimeout: readHeaderTimeout}
+
+	// Finish the requests in flight once interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	fmt.Fprintf(stderr, "Serving the hotspots of %s on http://%s\n", repoRoot, listener.Addr())
+	if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readHeaderTimeout is how long the server waits for the headers of a
+// request, so idle connections can't be held open indefinitely.
+const readHeaderTimeout = 10 * time.Second
+
+// maxCachedReports is the most reports the server caches at once, so clients
+// asking for many different analyses can't grow its memory without limit.
+const maxCachedReports = 32
+
+// server answers /api/hotspots with the JSON report of a repository and
+// /healthz with "ok". Reports are cached per ref, window and extensions, and
+// reused until the ref moves or they're older than ttl. At most maxReports
+// are kept, dropping the least recently requested first, and those not
+// requested within ttl are dropped too.
+type server struct {
+	repoRoot   string
+	ttl        time.Duration
+	maxReports int
+	stderr     io.Writer // Errors writing responses are logged to it
+
+	// analyze identifies the hotspots of the repository, replaced in tests
+	analyze func(opts git.AnalyzeOptions, now time.Time) (hotspotsReport, error)
+
+	mu      sync.Mutex
+	reports map[reportKey]*list.Element // Elements of recent
+	recent  list.List                   // Of *cachedReport, most recently requested first
+}
+
+// reportKey identifies the analyses a cached report can answer.
+type reportKey struct {
+	ref        string        // Empty for HEAD
+	window     time.Duration // Zero for the default window
+	extensions string        // Comma-separated, sorted
+}
+
+// hotspotsReport holds the hotspots of one analysis and their summary.
+type hotspotsReport struct {
+	files   []git.Hotspot
+	dirs    []git.Hotspot
+	summary report.Summary
+}
+
+// clone returns a copy of r whose hotspots can be sorted and read by one
+// request while the cached report is replaced for another.
+func (r hotspotsReport) clone() hotspotsReport {
+	return hotspotsReport{files: slices.Clone(r.files), dirs: slices.Clone(r.dirs), summary: r.summary}
+}
+
+// cachedReport holds the report of one analysis. Its lock is held while it's
+// read or computed, so concurrent requests for it wait for a single analysis.
+type cachedReport struct {
+	key       reportKey
+	requested time.Time // Time of the latest request, guarded by server.mu
+
+	mu       sync.Mutex
+	commit   string // Hash of the commit the ref pointed to when computed
+	computed time.Time
+	report   hotspotsReport
+}
+
+// hotspotsQuery holds the parameters of a request to /api/hotspots.
+type hotspotsQuery struct {
+	top        int
+	ref        string        // Empty for HEAD
+	window     time.Duration // Zero for the default window
+	extensions []string
+}
+
+// newServer returns the handler of the serve subcommand for the repository
+// at repoRoot, logging errors writing responses to stderr.
+func newServer(repoRoot string, ttl time.Duration, stderr io.Writer) http.Handler {
+	s := &server{
+		repoRoot:   repoRoot,
+		ttl:        ttl,
+		maxReports: maxCachedReports,
+		stderr:     stderr,
+		reports:    make(map[reportKey]*list.Element),
+	}
+	s.analyze = s.analyzeHotspots
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /api/hotspots", s.handleHotspots)
+	return mux
+}
+
+// handleHotspots writes the JSON report for the analysis the query selects.
+func (s *server) handleHotspots(w http.ResponseWriter, r *http.Request) {
+	query, err := parseHotspotsQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hotspots, err := s.report(query, time.Now())
+	if errors.Is(err, git.ErrUnknownRef) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("analyzing commits: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Sparklines span the window of the analysis
+	w.Header().Set("Content-Type", "application/json")
+	err = report.WriteJSON(w, hotspots.files, hotspots.dirs, report.Options{
+		TopCount: query.top,
+		Summary:  &hotspots.summary,
+		Since:    hotspots.summary.Since,
+	})
+	if err != nil {
+		fmt.Fprintf(s.stderr, "Error writing the report for %s: %v\n", r.URL, err)
+	}
+}
+
+// report returns a copy of the cached report for query, analyzing the
+// repository again if there's none yet, the ref moved since, or it's older
+// than the ttl. Only refs that resolve get a cache entry, so unknown ones
+// don't fill the cache.
+func (s *server) report(query hotspotsQuery, now time.Time) (hotspotsReport, error) {
+	commit, err := git.ResolveRef(s.repoRoot, query.ref)
+	if err != nil {
+		return hotspotsReport{}, err
+	}
+
+	key := reportKey{ref: query.ref, window: query.window, extensions: strings.Join(query.extensions, ",")}
+	s.mu.Lock()
+	s.dropExpired(now)
+	var cached *cachedReport
+	if element, ok := s.reports[key]; ok {
+		cached = element.Value.(*cachedReport)
+		s.recent.MoveToFront(element)
+	} else {
+		cached = &cachedReport{key: key}
+		s.reports[key] = s.recent.PushFront(cached)
+		for s.recent.Len() > s.maxReports {
+			s.remove(s.recent.Back())
+		}
+	}
+	cached.requested = now
+	s.mu.Unlock()
+
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+	if cached.commit == commit && now.Sub(cached.computed) < s.ttl {
+		return cached.report.clone(), nil
+	}
+
+	// Walk from the commit resolved above, in case the ref moves meanwhile
+	opts := git.AnalyzeOptions{Since: git.DefaultSince(now), Ref: commit, Extensions: query.extensions}
+	if query.window > 0 {
+		opts.Since = now.Add(-query.window)
+	}
+	fresh, err := s.analyze(opts, now)
+	if err != nil {
+		return hotspotsReport{}, err
+	}
+	cached.commit, cached.computed, cached.report = commit, now, fresh
+	return fresh.clone(), nil
+}
+
+// dropExpired drops the cached reports not requested within the ttl, which
+// would be computed again anyway. The caller holds s.mu.
+func (s *server) dropExpired(now time.Time) {
+	for element := s.recent.Back(); element != nil; element = s.recent.Back() {
+		if now.Sub(element.Value.(*cachedReport).requested) < s.ttl {
+			return
+		}
+		s.remove(element)
+	}
+}
+
+// remove drops the cached report of element. Requests already holding it
+// still finish with it. The caller holds s.mu.
+func (s *server) remove(element *list.Element) {
+	delete(s.reports, element.Value.(*cachedReport).key)
+	s.recent.Remove(element)
+}
+
+// analyzeHotspots identifies the hotspots of the repository, streaming
+// commits into an accumulator.
+func (s *server) analyzeHotspots(opts git.AnalyzeOptions, now time.Time) (hotspotsReport, error) {
+	acc := git.NewHotspotAccumulatorWithOptions(git.HotspotOptions{Now: now, ActivitySince: opts.Since, ActivityBuckets: report.ActivityBuckets})
+	err := git.AnalyzeCommitsFunc(s.repoRoot, opts, func(commit git.CommitInfo) error {
+		acc.Add(commit)
+		return nil
+	})
+	if err != nil {
+		return hotspotsReport{}, err
+	}
+	files, dirs := acc.Result()
+	return hotspotsReport{
+		files: files,
+		dirs:  dirs,
+		summary: report.Summary{
+			Summary:      acc.Summary(),
+			Repositories: []string{s.repoRoot},
+			Version:      toolVersion(),
+			Since:        opts.Since,
+		},
+	}, nil
+}
+
+// parseHotspotsQuery parses the parameters of a request to /api/hotspots:
+// top, the number of files and directories (default 10), ref, the branch,
+// tag or commit to walk the history from per git.ValidateRef (default HEAD),
+// since, the length of the window as a Go duration or a number of days such
+// as 90d (default a year), and ext, comma-separated file extensions such as
+// go,md.
+func parseHotspotsQuery(values url.Values) (hotspotsQuery, error) {
+	query := hotspotsQuery{top: 10}
+	if ref := values.Get("ref"); ref != "" {
+		if err := git.ValidateRef(ref); err != nil {
+			return query, err
+		}
+		query.ref = ref
+	}
+	if top := values.Get("top"); top != "" {
+		n, err := strconv.Atoi(top)
+		if err != nil || n <= 0 {
+			return query, fmt.Errorf("top must be a positive number, got %q", top)
+		}
+		query.top = n
+	}
+	if since := values.Get("since"); since != "" {
+		window, err := parseWindow(since)
+		if err != nil || window <= 0 {
+			return query, fmt.Errorf("since must be a positive duration such as 90d or 720h, got %q", since)
+		}
+		query.window = window
+	}
+	if ext := values.Get("ext"); ext != "" {
+		for _, e := range strings.Split(ext, ",") {
+			e = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(e), "."))
+			if e == "" {
+				return query, fmt.Errorf("ext has an empty extension: %q", ext)
+			}
+			query.extensions = append(query.extensions, "."+e)
+		}
+		slices.Sort(query.extensions)
+		query.extensions = slices.Compact(query.extensions)
+	}
+	return query, nil
+}
+
+// parseWindow parses a Go duration, or a whole number of days such as 90d.
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseInt(days, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if n > math.MaxInt64/int64(24*time.Hour) {
+			return 0, fmt.Errorf("%d days is too long", n)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
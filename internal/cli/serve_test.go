@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"container/list"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	hotspots "git-hotspots/internal/git"
+	"git-hotspots/internal/testutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestServeHotspots(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "README.md"}, "Initial commit", now.AddDate(0, 0, -200))
+	testutil.CreateCommit(t, tmpDir, []string{"util.go"}, "Add util", now.AddDate(0, 0, -10))
+
+	handler := newServer(tmpDir, time.Minute, io.Discard)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	if rec := get("/healthz"); rec.Code != http.StatusOK || rec.Body.String() != "ok\n" {
+		t.Errorf("Expected ok from /healthz, got %d: %q", rec.Code, rec.Body.String())
+	}
+
+	// The window and extensions select the commits and files analyzed
+	rec := get("/api/hotspots?top=5&since=90d&ext=go")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON report, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got struct {
+		Summary struct {
+			Commits int `json:"commits"`
+		} `json:"summary"`
+		Files []struct {
+			Path     string `json:"path"`
+			Activity []int  `json:"activity"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output: %v\nOutput: %s", err, rec.Body.String())
+	}
+	if got.Summary.Commits != 1 || len(got.Files) != 1 || got.Files[0].Path != "util.go" {
+		t.Fatalf("Expected util.go from the one commit in 90 days, got %+v", got)
+	}
+
+	// The sparkline spans the 90 days too, not a year, so 10 days ago falls
+	// in the 11th of 12 buckets rather than the last
+	if activity := got.Files[0].Activity; len(activity) != 12 || activity[10] != 1 {
+		t.Errorf("Expected the commit in the 11th bucket, got %v", activity)
+	}
+
+	if rec := get("/api/hotspots?since=soon"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a bad request for an invalid window, got %d", rec.Code)
+	}
+}
+
+func TestServeRef(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go"}, "Initial commit", now.Add(-2*time.Hour))
+
+	// old stays at the first commit while HEAD moves on
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("old"), head.Hash())); err != nil {
+		t.Fatalf("Failed to create branch: %v", err)
+	}
+	testutil.CreateCommit(t, tmpDir, []string{"util.go"}, "Add util", now.Add(-time.Hour))
+
+	handler := newServer(tmpDir, time.Minute, io.Discard)
+	commits := func(target string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected a report for %s, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var got struct {
+			Summary struct {
+				Commits int `json:"commits"`
+			} `json:"summary"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON output: %v\nOutput: %s", err, rec.Body.String())
+		}
+		return got.Summary.Commits
+	}
+	if got := commits("/api/hotspots"); got != 2 {
+		t.Errorf("Expected 2 commits from HEAD, got %d", got)
+	}
+	if got := commits("/api/hotspots?ref=old"); got != 1 {
+		t.Errorf("Expected 1 commit from old, got %d", got)
+	}
+
+	// Unknown refs and revision syntax are bad requests
+	for _, ref := range []string{"missing", "old~1", "HEAD@{1}"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/hotspots?ref="+url.QueryEscape(ref), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected a bad request for ref %q, got %d: %s", ref, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestServeConcurrentRequests(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "util.go"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	// Without a ttl every request analyzes again, replacing the cached
+	// report while others still write theirs, which go test -race checks
+	handler := newServer(tmpDir, 0, io.Discard)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/hotspots", nil))
+			var got struct {
+				Files []struct {
+					Path string `json:"path"`
+				} `json:"files"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); rec.Code != http.StatusOK || err != nil || len(got.Files) != 2 {
+				t.Errorf("Expected a report of 2 files, got %d: %s", rec.Code, rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestServeCachesReports(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"main.go"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	s := &server{repoRoot: tmpDir, ttl: time.Minute, maxReports: maxCachedReports, reports: make(map[reportKey]*list.Element)}
+	var mu sync.Mutex
+	analyses := 0
+	s.analyze = func(opts hotspots.AnalyzeOptions, now time.Time) (hotspotsReport, error) {
+		mu.Lock()
+		analyses++
+		mu.Unlock()
+		return s.analyzeHotspots(opts, now)
+	}
+
+	// Concurrent requests for the same analysis share a single one
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.report(hotspotsQuery{top: 10}, now); err != nil {
+				t.Errorf("report failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if analyses != 1 {
+		t.Errorf("Expected 1 analysis for concurrent requests, got %d", analyses)
+	}
+
+	// Other windows are analyzed separately, and reports expire with the ttl
+	s.report(hotspotsQuery{top: 10, window: time.Hour}, now)
+	s.report(hotspotsQuery{top: 10}, now.Add(time.Minute))
+	if analyses != 3 {
+		t.Errorf("Expected 3 analyses, got %d", analyses)
+	}
+
+	// A new commit moves HEAD, so the report is computed again
+	testutil.CreateCommit(t, tmpDir, []string{"util.go"}, "Add util", time.Now())
+	cached, err := s.report(hotspotsQuery{top: 10}, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if analyses != 4 || cached.summary.Commits != 2 {
+		t.Errorf("Expected a 4th analysis with 2 commits, got %d with %d", analyses, cached.summary.Commits)
+	}
+}
+
+func TestServeCacheLimits(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"main.go"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	s := &server{repoRoot: tmpDir, ttl: time.Minute, maxReports: 2, reports: make(map[reportKey]*list.Element)}
+	s.analyze = func(opts hotspots.AnalyzeOptions, now time.Time) (hotspotsReport, error) {
+		return hotspotsReport{}, nil
+	}
+	cachedWindows := func() []time.Duration {
+		var windows []time.Duration
+		for element := s.recent.Front(); element != nil; element = element.Next() {
+			windows = append(windows, element.Value.(*cachedReport).key.window)
+		}
+		return windows
+	}
+
+	// Past the limit, the least recently requested report is dropped
+	now := time.Now()
+	s.report(hotspotsQuery{top: 10, window: time.Hour}, now)
+	s.report(hotspotsQuery{top: 10, window: 2 * time.Hour}, now)
+	s.report(hotspotsQuery{top: 10, window: time.Hour}, now.Add(time.Second))
+	s.report(hotspotsQuery{top: 10, window: 3 * time.Hour}, now.Add(time.Second))
+	if want := []time.Duration{3 * time.Hour, time.Hour}; !reflect.DeepEqual(cachedWindows(), want) || len(s.reports) != 2 {
+		t.Errorf("Expected reports for windows %v, got %v", want, cachedWindows())
+	}
+
+	// Reports not requested within the ttl are dropped
+	s.report(hotspotsQuery{top: 10, window: 4 * time.Hour}, now.Add(time.Minute+time.Second))
+	if want := []time.Duration{4 * time.Hour}; !reflect.DeepEqual(cachedWindows(), want) || len(s.reports) != 1 {
+		t.Errorf("Expected reports for windows %v, got %v", want, cachedWindows())
+	}
+}
+
+func TestParseHotspotsQuery(t *testing.T) {
+	query, err := parseHotspotsQuery(url.Values{"top": {"20"}, "since": {"90d"}, "ext": {"go,.MD,go"}})
+	if err != nil {
+		t.Fatalf("parseHotspotsQuery failed: %v", err)
+	}
+	expected := hotspotsQuery{top: 20, window: 90 * 24 * time.Hour, extensions: []string{".go", ".md"}}
+	if !reflect.DeepEqual(query, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, query)
+	}
+
+	for _, values := range []url.Values{{"top": {"0"}}, {"since": {"-1d"}}, {"since": {"90"}}, {"since": {"300000d"}}, {"ext": {"go,"}}} {
+		if _, err := parseHotspotsQuery(values); err == nil {
+			t.Errorf("Expected an error for %v", values)
+		}
+	}
+}
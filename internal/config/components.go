@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"git-hotspots/internal/git"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadComponents reads a component mapping file: a YAML mapping from path
+// globs to component names, such as
+//
+//	"cmd/**": cli
+//	internal/git: analysis
+//
+// Rules are kept in file order, so earlier globs take precedence.
+func LoadComponents(path string) (*git.Components, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read components file %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse components file %s: %w", path, err)
+	}
+
+	// An empty file has no document node
+	var rules []git.ComponentRule
+	if len(doc.Content) > 0 {
+		mapping := doc.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("components file %s must map path globs to component names", path)
+		}
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			glob, component := mapping.Content[i], mapping.Content[i+1]
+			if component.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("components file %s: component of %q must be a name", path, glob.Value)
+			}
+			rules = append(rules, git.ComponentRule{Glob: glob.Value, Component: component.Value})
+		}
+	}
+
+	components, err := git.NewComponents(rules)
+	if err != nil {
+		return nil, fmt.Errorf("components file %s: %w", path, err)
+	}
+	return components, nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file looked up in the home directory
+// and the repository root.
+const FileName = ".git-hotspots.yaml"
+
+// Load reads default flag values from the config file in the home directory
+// and then the one in the repository root, so repository values take
+// precedence. Missing files are ignored. Each value is a list of items, with
+// a single item for values other than YAML lists.
+func Load(repoPath string) (map[string][]string, error) {
+	values := make(map[string][]string)
+
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, FileName))
+	}
+	paths = append(paths, filepath.Join(repoPath, FileName))
+
+	for _, path := range paths {
+		fileValues, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileValues {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// loadFile reads a single config file, returning no values if it doesn't exist.
+func loadFile(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string][]string)
+	for key, value := range raw {
+		switch v := value.(type) {
+		case []interface{}:
+			var items []string
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = items
+		default:
+			values[key] = []string{fmt.Sprint(v)}
+		}
+	}
+	return values, nil
+}
+
+// List is implemented by flag values that can be repeated, collecting every
+// value they're set to.
+type List interface {
+	flag.Value
+	IsList() bool
+}
+
+// Apply sets the given values on fs for every flag that wasn't set explicitly
+// on the command line, so command-line flags override config values. Flags
+// whose value is a List are set once per item, and others to the items
+// joined with commas.
+func Apply(fs *flag.FlagSet, values map[string][]string) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for key, items := range values {
+		f := fs.Lookup(key)
+		if f == nil {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+		if explicit[key] {
+			continue
+		}
+		if list, ok := f.Value.(List); !ok || !list.IsList() {
+			items = []string{strings.Join(items, ",")}
+		}
+		for _, item := range items {
+			if err := fs.Set(key, item); err != nil {
+				return fmt.Errorf("invalid config value for %q: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
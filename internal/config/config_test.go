@@ -0,0 +1,155 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"git-hotspots/internal/git"
+)
+
+// writeConfig writes a config file with the given contents into dir.
+func writeConfig(t *testing.T, dir, contents string) {
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	home := t.TempDir()
+	repo := t.TempDir()
+	t.Setenv("HOME", home)
+
+	writeConfig(t, home, "top: 5\nformat: json\n")
+	writeConfig(t, repo, "top: 20\next:\n  - go\n  - py\n")
+
+	values, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Repository values override home values
+	if !reflect.DeepEqual(values["top"], []string{"20"}) {
+		t.Errorf("Expected top to be 20 from the repository config, got %q", values["top"])
+	}
+	if !reflect.DeepEqual(values["format"], []string{"json"}) {
+		t.Errorf("Expected format to be json from the home config, got %q", values["format"])
+	}
+	if !reflect.DeepEqual(values["ext"], []string{"go", "py"}) {
+		t.Errorf("Expected list values to keep their items, got %q", values["ext"])
+	}
+}
+
+func TestLoadMissingFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	values, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(values) != 0 {
+		t.Errorf("Expected no values without config files, got %v", values)
+	}
+}
+
+func TestApply(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	top := fs.Int("top", 10, "")
+	format := fs.String("format", "ui", "")
+	if err := fs.Parse([]string{"--top", "3"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	if err := Apply(fs, map[string][]string{"top": {"20"}, "format": {"json"}}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// Command-line flags override config values
+	if *top != 3 {
+		t.Errorf("Expected top to stay 3 from the command line, got %d", *top)
+	}
+	if *format != "json" {
+		t.Errorf("Expected format to be json from the config, got %q", *format)
+	}
+
+	if err := Apply(fs, map[string][]string{"unknown": {"1"}}); err == nil {
+		t.Errorf("Expected an error for an unknown config key")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("top", 10, "")
+	if err := Apply(fs, map[string][]string{"top": {"many"}}); err == nil {
+		t.Errorf("Expected an error for an invalid config value")
+	}
+}
+
+// list is a repeatable flag value for TestApplyLists.
+type list []string
+
+func (l *list) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *list) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *list) IsList() bool {
+	return true
+}
+
+func TestApplyLists(t *testing.T) {
+	repo := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	writeConfig(t, repo, "exclude-author:\n  - \"*[bot]\"\n  - renovate\nlang: [go, python]\n")
+	values, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var authors list
+	fs.Var(&authors, "exclude-author", "")
+	lang := fs.String("lang", "", "")
+	if err := Apply(fs, values); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// Repeatable flags are set once per item, others to the joined items
+	if want := (list{"*[bot]", "renovate"}); !reflect.DeepEqual(authors, want) {
+		t.Errorf("Expected exclude-author %q, got %q", want, authors)
+	}
+	if *lang != "go,python" {
+		t.Errorf("Expected lang to be go,python, got %q", *lang)
+	}
+}
+
+func TestLoadComponents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "components.yaml")
+	if err := os.WriteFile(path, []byte("\"internal/git/*_test.go\": tests\ninternal: core\n"), 0644); err != nil {
+		t.Fatalf("Failed to write components file: %v", err)
+	}
+
+	components, err := LoadComponents(path)
+	if err != nil {
+		t.Fatalf("LoadComponents failed: %v", err)
+	}
+
+	// Rules apply in file order, so the test glob wins over its directory
+	commits := []git.CommitInfo{{Hash: "hash1", Author: "Test User", Files: []string{"internal/git/git_test.go", "internal/git/git.go"}}}
+	_, groups := git.IdentifyHotspotsWithOptions(commits, git.HotspotOptions{Components: components})
+	if len(groups) != 2 {
+		t.Fatalf("Expected the tests and core components, got %v", groups)
+	}
+
+	if err := os.WriteFile(path, []byte("\"[\": broken\n"), 0644); err != nil {
+		t.Fatalf("Failed to write components file: %v", err)
+	}
+	if _, err := LoadComponents(path); err == nil {
+		t.Errorf("Expected an error for an invalid glob")
+	}
+}
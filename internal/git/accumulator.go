@@ -0,0 +1,538 @@
+package git
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HotspotAccumulator identifies hotspots from commits added one at a time,
+// so a history can be analyzed without holding all of its commits in memory.
+type HotspotAccumulator struct {
+	opts  HotspotOptions
+	files map[string]*hotspotStats
+	dirs  map[string]*hotspotStats
+
+	authorEmails     map[string]string // author -> email of latest commit
+	authorEmailDates map[string]time.Time
+	summarizer       Summarizer
+
+	// dirTests counts the changes to test files left out with ExcludeTests
+	// under each directory, by key.
+	dirTests map[string]int
+
+	// activityEnd is the end of the window of opts.ActivitySince.
+	activityEnd time.Time
+}
+
+// hotspotStats accumulates the commits touching one file or directory.
+type hotspotStats struct {
+	commits      int
+	score        float64
+	firstSeen    time.Time
+	lastModified time.Time
+	linesAdded   int
+	linesDeleted int
+	defects      int
+	reverts      int
+	deletions    int
+	dates        []time.Time // Kept with KeepCommits, as are dateAuthors and history
+	dateAuthors  []string    // Author of each date in dates
+	history      []CommitRef
+	activity     []int
+	authors      map[string]int       // author -> commit count
+	authorDates  map[string]time.Time // author -> date of their latest commit
+
+	// name is the spelling of the path in its latest commit, if paths are
+	// keyed case-insensitively, and nameDate the date of that commit.
+	name     string
+	nameDate time.Time
+
+	// created is the date of the first commit adding the file, and added
+	// and deleted those of the last ones adding and deleting it.
+	created time.Time
+	added   time.Time
+	deleted time.Time
+}
+
+// NewHotspotAccumulator returns an empty accumulator using the default options.
+func NewHotspotAccumulator() *HotspotAccumulator {
+	return NewHotspotAccumulatorWithOptions(HotspotOptions{})
+}
+
+// NewHotspotAccumulatorWithOptions returns an empty accumulator using the given options.
+func NewHotspotAccumulatorWithOptions(opts HotspotOptions) *HotspotAccumulator {
+	activityEnd := opts.Now
+	if activityEnd.IsZero() {
+		activityEnd = time.Now()
+	}
+	return &HotspotAccumulator{
+		opts:             opts,
+		files:            make(map[string]*hotspotStats),
+		dirs:             make(map[string]*hotspotStats),
+		authorEmails:     make(map[string]string),
+		authorEmailDates: make(map[string]time.Time),
+		dirTests:         make(map[string]int),
+		activityEnd:      activityEnd,
+	}
+}
+
+// Add accumulates a commit. Commits may be added in any order.
+func (a *HotspotAccumulator) Add(commit CommitInfo) {
+	a.summarizer.Add(commit)
+	ref := newCommitRef(commit)
+
+	// Leave deleted files and test files out of the commit if requested
+	files := commit.Files
+	if a.opts.IgnoreDeletions || a.opts.ExcludeTests != nil {
+		files = a.countedFiles(commit)
+		if len(files) == 0 {
+			return
+		}
+	}
+	contributors := []string{commit.Author}
+	a.recordEmail(commit.Author, commit.AuthorEmail, commit.Date)
+
+	// Credit each co-author once, unless they're the author
+	if a.opts.CountCoAuthors {
+		for _, coAuthor := range commit.CoAuthors {
+			if slices.Contains(contributors, coAuthor.Name) {
+				continue
+			}
+			contributors = append(contributors, coAuthor.Name)
+			a.recordEmail(coAuthor.Name, coAuthor.Email, commit.Date)
+		}
+	}
+
+	// Weight the commit by its tag if ranking by weighted score
+	tagWeight := 1.0
+	if a.opts.RankBy == RankByWeighted {
+		tagWeight = a.opts.TagWeights.weight(commit.Message)
+	}
+
+	// Each file contributes a flat weight unless normalizing by commit size
+	weight := tagWeight
+	if a.opts.NormalizeByCommitSize && len(files) > 0 {
+		weight = tagWeight / float64(len(files))
+	}
+
+	// Count the commit as a defect of everything it touched if it matches
+	defect := a.opts.DefectPattern != nil && a.opts.DefectPattern.MatchString(commit.Message)
+	revert := IsRevert(commit.Message)
+
+	dirFiles := make(map[string]int)         // dir -> files touched by this commit
+	dirLines := make(map[string]LineChanges) // dir -> lines changed by this commit
+	dirNames := make(map[string]string)      // dir -> its spelling in this commit
+	dirDeleted := make(map[string]int)       // dir -> files deleted by this commit
+	credited := make(map[string]bool)        // Files already credited with this commit
+	for _, file := range files {
+		lines := commit.Lines[file]
+		deleted := commit.Actions[file] == FileDeleted
+		if !a.opts.SkipFiles {
+			key := a.pathKey(file)
+			stats := statsFor(a.files, key)
+			if !credited[key] { // Case variants of a file count the commit once
+				credited[key] = true
+				stats.add(commit, contributors, weight)
+				a.track(stats, commit, ref)
+				if defect {
+					stats.defects++
+				}
+				if revert {
+					stats.reverts++
+				}
+			}
+			stats.addLines(lines)
+			stats.addAction(commit.Actions[file], commit.Date)
+			if deleted {
+				stats.deletions++
+			}
+			if a.opts.CaseInsensitivePaths {
+				stats.rename(file, commit.Date)
+			}
+		}
+
+		// Count the files and lines this commit touched in each directory
+		if a.opts.SkipDirs {
+			continue
+		}
+		if dir, ok := a.groupFor(file); ok {
+			if a.opts.Components == nil {
+				dirNames[a.dirKey(dir)] = dir
+			}
+			dir = a.dirKey(dir)
+			dirFiles[dir]++
+			if deleted {
+				dirDeleted[dir]++
+			}
+			dirLines[dir] = LineChanges{
+				Added:   dirLines[dir].Added + lines.Added,
+				Deleted: dirLines[dir].Deleted + lines.Deleted,
+			}
+		}
+	}
+
+	// Track directory commits once per commit, however many files
+	// it touched in the directory
+	for dir, count := range dirFiles {
+		score := tagWeight
+		if a.opts.NormalizeByCommitSize {
+			score = float64(count) * weight
+		}
+		stats := statsFor(a.dirs, dir)
+		stats.add(commit, contributors, score)
+		a.track(stats, commit, ref)
+		stats.addLines(dirLines[dir])
+		stats.deletions += dirDeleted[dir]
+		if a.opts.CaseInsensitivePaths && dirNames[dir] != "" {
+			stats.rename(dirNames[dir], commit.Date)
+		}
+		if defect {
+			stats.defects++
+		}
+		if revert {
+			stats.reverts++
+		}
+	}
+}
+
+// countedFiles returns the files of commit that count toward hotspots: all
+// but those it deleted if IgnoreDeletions is set, and the test files if
+// ExcludeTests is set, which are counted in their directories' TestFiles.
+func (a *HotspotAccumulator) countedFiles(commit CommitInfo) []string {
+	var files []string
+	for _, file := range commit.Files {
+		if a.opts.IgnoreDeletions && commit.Actions[file] == FileDeleted {
+			continue
+		}
+		if a.opts.ExcludeTests.Matches(file) {
+			if dir, ok := a.groupFor(file); ok && !a.opts.SkipDirs {
+				a.dirTests[a.dirKey(dir)]++
+			}
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// groupFor returns the directory or, if components are set, the component
+// file is grouped into. Files in the root directory are grouped under
+// RootLabel, or reported false if it's unset. Git paths are always
+// slash-separated, so they are split with path rather than filepath, whatever
+// the OS.
+func (a *HotspotAccumulator) groupFor(file string) (string, bool) {
+	if a.opts.Components != nil {
+		return a.opts.Components.componentFor(file)
+	}
+	dir := path.Dir(file)
+	if dir == "." {
+		return a.opts.RootLabel, a.opts.RootLabel != ""
+	}
+	return dir, true
+}
+
+// pathKey returns the key path's commits are accumulated under: path itself,
+// or its lowercase form if paths are case-insensitive.
+func (a *HotspotAccumulator) pathKey(path string) string {
+	if a.opts.CaseInsensitivePaths {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// dirKey returns the key the commits of the directory hotspot or component
+// dir are accumulated under: its pathKey, or the component's name as it is.
+func (a *HotspotAccumulator) dirKey(dir string) string {
+	if a.opts.Components != nil {
+		return dir
+	}
+	return a.pathKey(dir)
+}
+
+// recordEmail remembers the email of author if date is their latest commit so far.
+func (a *HotspotAccumulator) recordEmail(author, email string, date time.Time) {
+	if _, ok := a.authorEmails[author]; !ok || date.After(a.authorEmailDates[author]) {
+		a.authorEmails[author] = email
+		a.authorEmailDates[author] = date
+	}
+}
+
+// Result returns the file and directory hotspots for the commits added so far.
+func (a *HotspotAccumulator) Result() ([]Hotspot, []Hotspot) {
+	fileHotspots := a.hotspots(a.files)
+	dirHotspots := a.hotspots(a.dirs)
+	for i, h := range dirHotspots {
+		dirHotspots[i].TestFiles = a.dirTests[a.dirKey(h.Path)]
+	}
+
+	// Flag hotspots mostly changed by one author if requested
+	if a.opts.OwnerThreshold > 0 {
+		markSoleOwned(fileHotspots, a.opts.OwnerThreshold)
+		markSoleOwned(dirHotspots, a.opts.OwnerThreshold)
+	}
+
+	// Date files added before the window from their first commit if requested
+	if a.opts.FirstCommitAsCreation {
+		for i, h := range fileHotspots {
+			if h.Created.IsZero() {
+				fileHotspots[i].Created = h.FirstSeen
+			}
+		}
+	}
+
+	// Estimate the counts for all commits from a sample if requested
+	if a.opts.Extrapolate > 0 {
+		extrapolate(fileHotspots, a.opts.Extrapolate)
+		extrapolate(dirHotspots, a.opts.Extrapolate)
+	}
+
+	// Rank by lines changed if requested
+	if a.opts.RankBy == RankByChurn {
+		for i := range fileHotspots {
+			fileHotspots[i].Score = float64(fileHotspots[i].LinesAdded + fileHotspots[i].LinesDeleted)
+		}
+		for i := range dirHotspots {
+			dirHotspots[i].Score = float64(dirHotspots[i].LinesAdded + dirHotspots[i].LinesDeleted)
+		}
+
+		// Turn the churn of files into a change rate if requested
+		if a.opts.FileLines != nil {
+			normalizeByFileLines(fileHotspots, a.opts.FileLines)
+		}
+	}
+
+	// Rank by reverts if requested
+	if a.opts.RankBy == RankByReverts {
+		for i := range fileHotspots {
+			fileHotspots[i].Score = float64(fileHotspots[i].Reverts)
+		}
+		for i := range dirHotspots {
+			dirHotspots[i].Score = float64(dirHotspots[i].Reverts)
+		}
+	}
+
+	// Rank by how concentrated commits are among authors if requested
+	if a.opts.RankBy == RankByConcentration {
+		for i := range fileHotspots {
+			fileHotspots[i].Score = fileHotspots[i].Concentration
+		}
+		for i := range dirHotspots {
+			dirHotspots[i].Score = dirHotspots[i].Concentration
+		}
+	}
+
+	now := a.opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Turn scores into commits per day of age if requested
+	if a.opts.RankBy == RankByHotPerDay {
+		for i := range fileHotspots {
+			fileHotspots[i].Score = hotPerDay(fileHotspots[i], now)
+		}
+		for i := range dirHotspots {
+			dirHotspots[i].Score = hotPerDay(dirHotspots[i], now)
+		}
+	}
+
+	// Spread directory scores over the files under them if requested
+	if a.opts.DirSizes != nil {
+		a.normalizeByDirSize(dirHotspots, a.opts.DirSizes)
+	}
+
+	// Compute scores with the custom formula if given
+	if a.opts.ScoreExpr != nil {
+		for i := range fileHotspots {
+			fileHotspots[i].Score = a.opts.ScoreExpr.Eval(fileHotspots[i], now)
+		}
+		for i := range dirHotspots {
+			dirHotspots[i].Score = a.opts.ScoreExpr.Eval(dirHotspots[i], now)
+		}
+	}
+
+	// Prune hotspots below the commit threshold
+	if a.opts.MinCommits > 0 {
+		fileHotspots = filterMinCommits(fileHotspots, a.opts.MinCommits)
+		dirHotspots = filterMinCommits(dirHotspots, a.opts.MinCommits)
+	}
+
+	// Drop directories that only changed in a single burst, like an import
+	if a.opts.ExcludeBursts > 0 {
+		dirHotspots = filterBursts(dirHotspots, a.opts.ExcludeBursts)
+	}
+
+	// Separate settled code from live code by how long it's been idle
+	if a.opts.MaxIdle > 0 || a.opts.ActiveWithin > 0 {
+		fileHotspots = filterIdle(fileHotspots, now, a.opts.MaxIdle, a.opts.ActiveWithin)
+		dirHotspots = filterIdle(dirHotspots, now, a.opts.MaxIdle, a.opts.ActiveWithin)
+	}
+
+	// Sort hotspots by score in descending order
+	// (Sorting is done by SortHotspots before display)
+
+	return fileHotspots, dirHotspots
+}
+
+// markSoleOwned sets SoleOwned on the hotspots whose top contributor made more
+// than threshold of their commits.
+func markSoleOwned(hotspots []Hotspot, threshold float64) {
+	for i, h := range hotspots {
+		hotspots[i].SoleOwned = h.Commits > 0 && float64(h.AuthorCommits) > threshold*float64(h.Commits)
+	}
+}
+
+// Summary returns the summary of the commits added so far.
+func (a *HotspotAccumulator) Summary() Summary {
+	return a.summarizer.Summary()
+}
+
+// hotspots creates hotspots with top contributor information from stats.
+func (a *HotspotAccumulator) hotspots(stats map[string]*hotspotStats) []Hotspot {
+	var hotspots []Hotspot
+	for path, s := range stats {
+		// Rank the contributors to this path, breaking ties by name or by
+		// their latest commit
+		contributors := make([]Contributor, 0, len(s.authors))
+		for author, authorCommits := range s.authors {
+			contributors = append(contributors, Contributor{Author: author, Commits: authorCommits})
+		}
+		sort.Slice(contributors, func(i, j int) bool {
+			if contributors[i].Commits != contributors[j].Commits {
+				return contributors[i].Commits > contributors[j].Commits
+			}
+			if a.opts.TieBreak == TieBreakRecent {
+				di, dj := s.authorDates[contributors[i].Author], s.authorDates[contributors[j].Author]
+				if !di.Equal(dj) {
+					return di.After(dj)
+				}
+			}
+			return contributors[i].Author < contributors[j].Author
+		})
+		topContributor := ""
+		topContributions := 0
+		if len(contributors) > 0 {
+			topContributor = contributors[0].Author
+			topContributions = contributors[0].Commits
+		}
+
+		if s.name != "" {
+			path = s.name
+		}
+
+		// A file added again after it was deleted exists again
+		var deleted time.Time
+		if s.deleted.After(s.added) {
+			deleted = s.deleted
+		}
+		hotspots = append(hotspots, Hotspot{
+			Path:           path,
+			Commits:        s.commits,
+			Score:          s.score,
+			TopContributor: topContributor,
+			AuthorCommits:  topContributions,
+			FirstSeen:      s.firstSeen,
+			LastModified:   s.lastModified,
+			CommitDates:    s.dates,
+			CommitAuthors:  s.dateAuthors,
+			History:        s.history,
+			Activity:       s.activity,
+			LinesAdded:     s.linesAdded,
+			LinesDeleted:   s.linesDeleted,
+			Defects:        s.defects,
+			Reverts:        s.reverts,
+			Deletions:      s.deletions,
+			Contributors:   contributors,
+			Concentration:  concentration(contributors),
+
+			TopContributorEmail: a.authorEmails[topContributor],
+			Created:             s.created,
+			Deleted:             deleted,
+		})
+	}
+	return hotspots
+}
+
+// statsFor returns the stats for path, creating them if needed.
+func statsFor(stats map[string]*hotspotStats, path string) *hotspotStats {
+	s, ok := stats[path]
+	if !ok {
+		s = &hotspotStats{authors: make(map[string]int), authorDates: make(map[string]time.Time)}
+		stats[path] = s
+	}
+	return s
+}
+
+// track keeps the commit in the stats of a path it touched if
+// opts.KeepCommits is set, and counts it in their activity if
+// opts.ActivitySince is.
+func (a *HotspotAccumulator) track(s *hotspotStats, commit CommitInfo, ref CommitRef) {
+	if a.opts.KeepCommits {
+		s.dates = append(s.dates, commit.Date)
+		s.dateAuthors = append(s.dateAuthors, commit.Author)
+		s.history = append(s.history, ref)
+	}
+	if a.opts.ActivitySince.IsZero() {
+		return
+	}
+	if s.activity == nil {
+		s.activity = make([]int, a.opts.ActivityBuckets)
+	}
+	if i := bucketIndex(commit.Date, a.opts.ActivitySince, a.activityEnd, a.opts.ActivityBuckets); i >= 0 {
+		s.activity[i]++
+	}
+}
+
+// add records a commit touching the path, contributing score to its total
+// and crediting each of contributors with the commit.
+func (s *hotspotStats) add(commit CommitInfo, contributors []string, score float64) {
+	s.commits++
+	s.score += score
+	if s.commits == 1 || commit.Date.Before(s.firstSeen) {
+		s.firstSeen = commit.Date
+	}
+	if commit.Date.After(s.lastModified) {
+		s.lastModified = commit.Date
+	}
+	for _, contributor := range contributors {
+		s.authors[contributor]++
+		if commit.Date.After(s.authorDates[contributor]) {
+			s.authorDates[contributor] = commit.Date
+		}
+	}
+}
+
+// rename sets the display name of the path to name, its spelling in a commit
+// made at date, if that's the latest commit seen so far.
+func (s *hotspotStats) rename(name string, date time.Time) {
+	if s.name == "" || date.After(s.nameDate) {
+		s.name = name
+		s.nameDate = date
+	}
+}
+
+// addAction records a commit made at date adding or deleting the file.
+func (s *hotspotStats) addAction(action FileAction, date time.Time) {
+	switch action {
+	case FileAdded:
+		if s.created.IsZero() || date.Before(s.created) {
+			s.created = date
+		}
+		if date.After(s.added) {
+			s.added = date
+		}
+	case FileDeleted:
+		if date.After(s.deleted) {
+			s.deleted = date
+		}
+	}
+}
+
+// addLines adds lines changed by a commit to the path's churn.
+func (s *hotspotStats) addLines(lines LineChanges) {
+	s.linesAdded += lines.Added
+	s.linesDeleted += lines.Deleted
+}
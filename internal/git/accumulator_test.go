@@ -0,0 +1,191 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+)
+
+func TestHotspotAccumulator(t *testing.T) {
+	commits := generateCommits(200, 30)
+
+	// Adding commits oldest first gives the same result as the newest-first
+	// order of the log
+	acc := NewHotspotAccumulator()
+	for i := len(commits) - 1; i >= 0; i-- {
+		acc.Add(commits[i])
+	}
+	fileHotspots, dirHotspots := acc.Result()
+
+	expectedFiles, expectedDirs := IdentifyHotspots(commits)
+	assertSameHotspots(t, expectedFiles, fileHotspots)
+	assertSameHotspots(t, expectedDirs, dirHotspots)
+}
+
+func TestAnalyzeCommitsFunc(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file2.txt"}, "Add file2", now.Add(-12*time.Hour))
+
+	acc := NewHotspotAccumulator()
+	err := AnalyzeCommitsFunc(tmpDir, AnalyzeOptions{}, func(commit CommitInfo) error {
+		acc.Add(commit)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsFunc failed: %v", err)
+	}
+	if fileHotspots, _ := acc.Result(); len(fileHotspots) != 2 {
+		t.Errorf("Expected 2 file hotspots, got %d", len(fileHotspots))
+	}
+
+	// Errors from the callback stop the analysis
+	errStop := errors.New("stop")
+	calls := 0
+	err = AnalyzeCommitsFunc(tmpDir, AnalyzeOptions{}, func(commit CommitInfo) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("Expected to stop after 1 commit with errStop, got %d commits and %v", calls, err)
+	}
+}
+
+func TestHotspotAccumulatorDeletions(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "src/old.go", "src/legacy.go"}, "Initial commit", now.Add(-3*time.Hour))
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Edit main.go", Date: now.Add(-2 * time.Hour), Write: map[string]string{"src/main.go": "changed"}})
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Remove dead code", Date: now.Add(-time.Hour), Delete: []string{"src/old.go", "src/legacy.go"}})
+
+	commits, err := AnalyzeCommits(tmpDir)
+	if err != nil {
+		t.Fatalf("AnalyzeCommits failed: %v", err)
+	}
+	byPath := func(hotspots []Hotspot) map[string]Hotspot {
+		m := make(map[string]Hotspot)
+		for _, h := range hotspots {
+			m[h.Path] = h
+		}
+		return m
+	}
+
+	// Deletions count as commits, and are tallied separately
+	acc := NewHotspotAccumulator()
+	for _, commit := range commits {
+		acc.Add(commit)
+	}
+	fileHotspots, dirHotspots := acc.Result()
+	files, dirs := byPath(fileHotspots), byPath(dirHotspots)
+	if h := files["src/old.go"]; h.Commits != 2 || h.Deletions != 1 {
+		t.Errorf("Expected src/old.go to have 2 commits and 1 deletion, got %d and %d", h.Commits, h.Deletions)
+	}
+	if h := files["src/main.go"]; h.Deletions != 0 {
+		t.Errorf("Expected src/main.go to have no deletions, got %d", h.Deletions)
+	}
+	if h := dirs["src"]; h.Commits != 3 || h.Deletions != 2 {
+		t.Errorf("Expected src to have 3 commits and 2 deletions, got %d and %d", h.Commits, h.Deletions)
+	}
+
+	// Ignoring them, the commit only deleting files is skipped
+	acc = NewHotspotAccumulatorWithOptions(HotspotOptions{IgnoreDeletions: true})
+	for _, commit := range commits {
+		acc.Add(commit)
+	}
+	fileHotspots, dirHotspots = acc.Result()
+	files, dirs = byPath(fileHotspots), byPath(dirHotspots)
+	if h := files["src/old.go"]; h.Commits != 1 || h.Deletions != 0 || !h.Deleted.IsZero() {
+		t.Errorf("Expected src/old.go to have 1 commit and no deletions, got %d, %d and %v", h.Commits, h.Deletions, h.Deleted)
+	}
+	if h := dirs["src"]; h.Commits != 2 || h.Deletions != 0 {
+		t.Errorf("Expected src to have 2 commits and no deletions, got %d and %d", h.Commits, h.Deletions)
+	}
+}
+
+func TestTopContributorTieBreak(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Zoe", Date: now.Add(-4 * time.Hour), Files: []string{"src/main.go"}},
+		{Hash: "hash2", Author: "Alice", Date: now.Add(-3 * time.Hour), Files: []string{"src/main.go"}},
+		{Hash: "hash3", Author: "Alice", Date: now.Add(-2 * time.Hour), Files: []string{"src/main.go"}},
+		{Hash: "hash4", Author: "Zoe", Date: now.Add(-time.Hour), Files: []string{"src/main.go"}},
+	}
+
+	// Authors tied at two commits each are split the same way every time,
+	// whatever order maps are iterated in
+	for _, tt := range []struct {
+		tieBreak TieBreak
+		want     string
+	}{
+		{"", "Alice"},
+		{TieBreakName, "Alice"},
+		{TieBreakRecent, "Zoe"},
+	} {
+		for i := 0; i < 20; i++ {
+			files, dirs := IdentifyHotspotsWithOptions(commits, HotspotOptions{TieBreak: tt.tieBreak})
+			if files[0].TopContributor != tt.want || files[0].AuthorCommits != 2 {
+				t.Fatalf("Expected %s with 2 commits to top src/main.go by %q, got %s with %d", tt.want, tt.tieBreak, files[0].TopContributor, files[0].AuthorCommits)
+			}
+			if dirs[0].TopContributor != tt.want {
+				t.Fatalf("Expected %s to top src by %q, got %s", tt.want, tt.tieBreak, dirs[0].TopContributor)
+			}
+		}
+	}
+}
+
+// assertSameHotspots checks that two sets of hotspots match regardless of order.
+func assertSameHotspots(t *testing.T, expected, actual []Hotspot) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d hotspots, got %d", len(expected), len(actual))
+	}
+
+	byPath := make(map[string]Hotspot)
+	for _, h := range actual {
+		byPath[h.Path] = h
+	}
+	for _, e := range expected {
+		h, ok := byPath[e.Path]
+		if !ok {
+			t.Errorf("Missing hotspot %s", e.Path)
+			continue
+		}
+		if h.Commits != e.Commits || h.Score != e.Score || h.AuthorCommits != e.AuthorCommits ||
+			!h.FirstSeen.Equal(e.FirstSeen) || !h.LastModified.Equal(e.LastModified) || len(h.History) != len(e.History) {
+			t.Errorf("Expected %+v, got %+v", e, h)
+		}
+	}
+}
+
+func TestHotspotAccumulatorSummary(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.Add(-24 * time.Hour), Files: []string{"a.go"}},
+		{Hash: "hash2", Author: "Another User", Date: now, Files: []string{"b.go"}},
+		{Hash: "hash3", Author: "Test User", Date: now.Add(-48 * time.Hour), Files: []string{"a.go", "b.go"}},
+	}
+
+	acc := NewHotspotAccumulator()
+	for _, commit := range commits {
+		acc.Add(commit)
+	}
+
+	expected := Summary{Commits: 3, Authors: 2, Files: 2, FirstCommit: now.Add(-48 * time.Hour), LastCommit: now}
+	if summary := acc.Summary(); summary != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary)
+	}
+	if summary := Summarize(commits); summary != expected {
+		t.Errorf("Expected Summarize to match the accumulator, got %+v", summary)
+	}
+	if summary := Summarize(nil); summary != (Summary{}) {
+		t.Errorf("Expected an empty summary without commits, got %+v", summary)
+	}
+}
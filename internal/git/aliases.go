@@ -0,0 +1,90 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Aliases maps raw author identities to canonical names, to merge the
+// identities of contributors who committed under several names or emails.
+type Aliases struct {
+	identities map[string]string // "name <email>" -> canonical name
+	emails     map[string]string // email -> canonical name
+	names      map[string]string // name -> canonical name
+}
+
+// NewAliases returns an empty set of aliases.
+func NewAliases() *Aliases {
+	return &Aliases{
+		identities: make(map[string]string),
+		emails:     make(map[string]string),
+		names:      make(map[string]string),
+	}
+}
+
+// Add parses an alias of the form "Name <email> = Canonical Name" and adds
+// it. The left side may also be just "<email>", matching any name, or just
+// "Name", matching any email. Emails are compared ignoring case.
+func (a *Aliases) Add(alias string) error {
+	identity, canonical, ok := strings.Cut(alias, "=")
+	identity, canonical = strings.TrimSpace(identity), strings.TrimSpace(canonical)
+	if !ok || identity == "" || canonical == "" {
+		return fmt.Errorf("invalid alias %q (expected \"Name <email> = Canonical Name\")", alias)
+	}
+
+	name, email := identity, ""
+	if open := strings.Index(identity, "<"); open >= 0 {
+		if !strings.HasSuffix(identity, ">") {
+			return fmt.Errorf("invalid alias %q (unterminated email)", alias)
+		}
+		name = strings.TrimSpace(identity[:open])
+		email = strings.ToLower(strings.TrimSpace(identity[open+1 : len(identity)-1]))
+	}
+	switch {
+	case name != "" && email != "":
+		a.identities[name+" <"+email+">"] = canonical
+	case email != "":
+		a.emails[email] = canonical
+	default:
+		a.names[name] = canonical
+	}
+	return nil
+}
+
+// Read adds the aliases in r, one per line. Blank lines and lines starting
+// with # are skipped.
+func (a *Aliases) Read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := a.Add(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Canonical returns the canonical name of the author with the given name
+// and email, or name itself if no alias matches. An alias for the exact
+// identity wins over one for the email, which wins over one for the name.
+func (a *Aliases) Canonical(name, email string) string {
+	if a == nil {
+		return name
+	}
+	email = strings.ToLower(email)
+	if canonical, ok := a.identities[name+" <"+email+">"]; ok {
+		return canonical
+	}
+	if canonical, ok := a.emails[email]; ok && email != "" {
+		return canonical
+	}
+	if canonical, ok := a.names[name]; ok {
+		return canonical
+	}
+	return name
+}
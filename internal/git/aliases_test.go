@@ -0,0 +1,63 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+)
+
+func TestAliasesCanonical(t *testing.T) {
+	aliases := NewAliases()
+	err := aliases.Read(strings.NewReader(`
+# Bots
+Bot <BOT@example.com> = Automation
+<ci@example.com> = Automation
+jdoe = Jane Doe
+`))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	tests := []struct {
+		name, email, want string
+	}{
+		{"Bot", "bot@example.com", "Automation"},
+		{"Bot", "other@example.com", "Bot"},
+		{"Anything", "CI@example.com", "Automation"},
+		{"jdoe", "jane@example.com", "Jane Doe"},
+		{"Jane Doe", "jane@example.com", "Jane Doe"},
+	}
+	for _, tt := range tests {
+		if got := aliases.Canonical(tt.name, tt.email); got != tt.want {
+			t.Errorf("Canonical(%q, %q) = %q, want %q", tt.name, tt.email, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"no equals sign", "= Name", "Bot <bot@example.com = Automation"} {
+		if err := NewAliases().Add(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestAnalyzeCommitsWithAliases(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	// The test commits are made by Test User <test@example.com>
+	aliases := NewAliases()
+	if err := aliases.Add("<test@example.com> = Canonical User"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Aliases: aliases})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Author != "Canonical User" {
+		t.Errorf("Expected the commit by Canonical User, got %v", commits)
+	}
+}
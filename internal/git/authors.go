@@ -0,0 +1,79 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// AuthorFilter excludes the commits of authors matching any of a set of
+// patterns, such as bots and service accounts.
+type AuthorFilter struct {
+	patterns []*regexp.Regexp
+}
+
+// NewAuthorFilter returns a filter excluding no authors.
+func NewAuthorFilter() *AuthorFilter {
+	return &AuthorFilter{}
+}
+
+// Add parses a pattern and adds it. A pattern between slashes, such as
+// /^ci-.*-bot$/, is a regular expression; anything else is a wildcard
+// pattern, where * matches any run of characters and ? any one character,
+// compared ignoring case, such as *[bot] or *@noreply.example.com. Patterns
+// are matched against an author's name, their email and "Name <email>", and
+// wildcard patterns must match one of them whole.
+func (f *AuthorFilter) Add(pattern string) error {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return fmt.Errorf("empty author pattern")
+	}
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return fmt.Errorf("invalid author pattern %q: %w", pattern, err)
+		}
+		f.patterns = append(f.patterns, re)
+		return nil
+	}
+
+	// Only * and ? are special, so names like dependabot[bot] match literally
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	f.patterns = append(f.patterns, regexp.MustCompile("(?i)^(?:"+quoted+")$"))
+	return nil
+}
+
+// Read adds the patterns in r, one per line. Blank lines and lines starting
+// with # are skipped.
+func (f *AuthorFilter) Read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := f.Add(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Excludes reports whether the author with the given name and email matches
+// any of the patterns. A nil filter excludes no one.
+func (f *AuthorFilter) Excludes(name, email string) bool {
+	if f == nil {
+		return false
+	}
+	identity := name + " <" + email + ">"
+	for _, re := range f.patterns {
+		if re.MatchString(name) || (email != "" && re.MatchString(email)) || re.MatchString(identity) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+)
+
+func TestAuthorFilterExcludes(t *testing.T) {
+	filter := NewAuthorFilter()
+	err := filter.Read(strings.NewReader(`
+# Bots
+dependabot[bot]
+*@noreply.example.com
+/^ci-.*-bot$/
+`))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if err := filter.Add("Renovate?Bot"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	tests := []struct {
+		name, email string
+		want        bool
+	}{
+		{"dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", true},
+		{"Dependabot[Bot]", "", true},
+		{"dependabotb", "dependabot@example.com", false},
+		{"Jane Doe", "actions@noreply.example.com", true},
+		{"ci-deploy-bot", "deploy@example.com", true},
+		{"ci-deploy-bot-2", "deploy@example.com", false},
+		{"renovate-bot", "renovate@example.com", true},
+		{"Jane Doe", "jane@example.com", false},
+	}
+	for _, tt := range tests {
+		if got := filter.Excludes(tt.name, tt.email); got != tt.want {
+			t.Errorf("Excludes(%q, %q) = %v, want %v", tt.name, tt.email, got, tt.want)
+		}
+	}
+
+	var none *AuthorFilter
+	if none.Excludes("dependabot[bot]", "") {
+		t.Error("Expected a nil filter to exclude no one")
+	}
+	for _, invalid := range []string{"", "  ", "/(/"} {
+		if err := NewAuthorFilter().Add(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestAnalyzeCommitsWithExcludedAuthors(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	// The test commits are made by Test User <test@example.com>
+	filter := NewAuthorFilter()
+	if err := filter.Add("*@example.com"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{ExcludeAuthors: filter})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 0 {
+		t.Errorf("Expected the commits of Test User to be skipped, got %v", commits)
+	}
+}
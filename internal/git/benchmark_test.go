@@ -0,0 +1,165 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// benchmarkSizes are the synthetic history sizes the benchmarks run against.
+var benchmarkSizes = []struct {
+	commits int
+	files   int
+}{
+	{100, 20},
+	{1000, 200},
+}
+
+// generateCommits returns numCommits in-memory commits, newest first, spread
+// over numFiles files in a few directories. Each commit touches one to three
+// files and is made by one of a handful of authors.
+func generateCommits(numCommits, numFiles int) []CommitInfo {
+	authors := []string{"Test User", "Another User", "Third User"}
+	now := time.Now()
+
+	commits := make([]CommitInfo, numCommits)
+	for i := range commits {
+		files := make([]string, 1+i%3)
+		for j := range files {
+			n := (i*7 + j*13) % numFiles
+			files[j] = fmt.Sprintf("dir%d/file%d.go", n%10, n)
+		}
+		author := authors[i%len(authors)]
+		commits[i] = CommitInfo{
+			Hash:        fmt.Sprintf("%040x", i),
+			Author:      author,
+			AuthorEmail: author + "@example.com",
+			Date:        now.Add(-time.Duration(i) * time.Hour),
+			Message:     fmt.Sprintf("Commit %d", i),
+			Files:       files,
+		}
+	}
+	return commits
+}
+
+// createSyntheticRepo creates a repository with the commits of
+// generateCommits, oldest first. Every commit writes new content so that
+// files can be modified repeatedly.
+func createSyntheticRepo(tb testing.TB, numCommits, numFiles int) string {
+	repoPath := testutil.NewRepo(tb)
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		tb.Fatalf("Failed to open repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		tb.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	commits := generateCommits(numCommits, numFiles)
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		for _, file := range commit.Files {
+			filePath := filepath.Join(repoPath, file)
+			if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+				tb.Fatalf("Failed to create dir for %s: %v", file, err)
+			}
+			if err := os.WriteFile(filePath, []byte(commit.Message), 0644); err != nil {
+				tb.Fatalf("Failed to write file %s: %v", file, err)
+			}
+			if _, err := wt.Add(file); err != nil {
+				tb.Fatalf("Failed to add file %s: %v", file, err)
+			}
+		}
+
+		signature := &object.Signature{Name: commit.Author, Email: commit.AuthorEmail, When: commit.Date}
+		if _, err := wt.Commit(commit.Message, &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+			tb.Fatalf("Failed to commit: %v", err)
+		}
+	}
+	return repoPath
+}
+
+func BenchmarkAnalyzeCommits(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("commits=%d/files=%d", size.commits, size.files), func(b *testing.B) {
+			repoPath := createSyntheticRepo(b, size.commits, size.files)
+			defer os.RemoveAll(repoPath)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := AnalyzeCommitsWithOptions(repoPath, AnalyzeOptions{}); err != nil {
+					b.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAnalyzeCommitsCacheSize(b *testing.B) {
+	repoPath := createSyntheticRepo(b, 1000, 200)
+	defer os.RemoveAll(repoPath)
+
+	for _, cacheSize := range []int{1, 0, 512} {
+		b.Run(fmt.Sprintf("cache=%dMiB", cacheSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := AnalyzeCommitsWithOptions(repoPath, AnalyzeOptions{CacheSize: cacheSize}); err != nil {
+					b.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIdentifyHotspots(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("commits=%d", size), func(b *testing.B) {
+			commits := generateCommits(size, size/10)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				IdentifyHotspots(commits)
+			}
+		})
+	}
+}
+
+func TestCreateSyntheticRepo(t *testing.T) {
+	repoPath := createSyntheticRepo(t, 30, 10)
+	defer os.RemoveAll(repoPath)
+
+	commits, err := AnalyzeCommitsWithOptions(repoPath, AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 30 {
+		t.Errorf("Expected 30 commits, got %d", len(commits))
+	}
+}
+
+func TestAnalyzeCommitsCacheSize(t *testing.T) {
+	repoPath := createSyntheticRepo(t, 30, 10)
+	defer os.RemoveAll(repoPath)
+
+	commits, err := AnalyzeCommitsWithOptions(repoPath, AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	cached, err := AnalyzeCommitsWithOptions(repoPath, AnalyzeOptions{CacheSize: 1})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions with a cache size failed: %v", err)
+	}
+	if !reflect.DeepEqual(commits, cached) {
+		t.Errorf("Expected the same commits with a custom cache size, got %d and %d commits", len(commits), len(cached))
+	}
+}
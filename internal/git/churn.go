@@ -0,0 +1,145 @@
+package git
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// LineChanges counts the lines added and deleted in a file.
+type LineChanges struct {
+	Added   int
+	Deleted int
+}
+
+// commitLineChanges returns the lines added and deleted in each text file
+// changed by commit, compared with its first parent. Merge commits change no
+// lines of their own, as with git log --numstat. If ignoreWhitespace is set,
+// lines differing only in whitespace count as unchanged.
+func commitLineChanges(commit *object.Commit, includeSubmodules, ignoreWhitespace bool) (map[string]LineChanges, error) {
+	lines := make(map[string]LineChanges)
+	if commit.NumParents() > 1 {
+		return lines, nil
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	// Diff against an empty tree for the first commit
+	parentTree := &object.Tree{}
+	if commit.NumParents() == 1 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := parentTree.Diff(tree)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		// Submodules and symlinks have no lines, and aren't counted as files anyway
+		if !isCountedEntry(change.From.TreeEntry.Mode, includeSubmodules) || !isCountedEntry(change.To.TreeEntry.Mode, includeSubmodules) {
+			continue
+		}
+
+		from, to, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+		src, ok, err := textContents(from)
+		if err != nil || !ok {
+			continue
+		}
+		dst, ok, err := textContents(to)
+		if err != nil || !ok {
+			continue
+		}
+
+		name := change.To.Name
+		if name == "" {
+			name = change.From.Name
+		}
+		lines[name] = countLineChanges(src, dst, ignoreWhitespace)
+	}
+	return lines, nil
+}
+
+// textContents returns the contents of f, or an empty string if f is nil.
+// It reports false for binary files, whose lines aren't counted.
+func textContents(f *object.File) (string, bool, error) {
+	if f == nil {
+		return "", true, nil
+	}
+	binary, err := f.IsBinary()
+	if err != nil || binary {
+		return "", false, err
+	}
+	contents, err := f.Contents()
+	return contents, err == nil, err
+}
+
+// countLineChanges counts the lines added and deleted to turn src into dst.
+func countLineChanges(src, dst string, ignoreWhitespace bool) LineChanges {
+	if ignoreWhitespace {
+		src, dst = stripWhitespace(src), stripWhitespace(dst)
+	}
+
+	var changes LineChanges
+	for _, d := range diff.Do(src, dst) {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			changes.Added += countLines(d.Text)
+		case diffmatchpatch.DiffDelete:
+			changes.Deleted += countLines(d.Text)
+		}
+	}
+	return changes
+}
+
+// stripWhitespace removes all whitespace within each line of text, like
+// git diff -w, keeping the line breaks.
+func stripWhitespace(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// countLines counts the lines in text, including a last line without a newline.
+func countLines(text string) int {
+	n := strings.Count(text, "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		n++
+	}
+	return n
+}
+
+// normalizeByFileLines divides the score of each file hotspot by its number
+// of lines in lines, or sets it to 0 if it has none, such as a deleted, empty
+// or binary file.
+func normalizeByFileLines(fileHotspots []Hotspot, lines map[string]int) {
+	for i, h := range fileHotspots {
+		n := lines[h.Path]
+		if n == 0 {
+			fileHotspots[i].Score = 0
+			continue
+		}
+		fileHotspots[i].Score = h.Score / float64(n)
+	}
+}
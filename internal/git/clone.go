@@ -0,0 +1,50 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// scpLikeURL matches scp-style remote addresses such as git@github.com:org/repo.git.
+var scpLikeURL = regexp.MustCompile(`^[\w.-]+@[\w.-]+:`)
+
+// IsRemoteURL reports whether s is the URL of a remote repository, such as
+// https://github.com/org/repo.git or git@github.com:org/repo.git, rather than
+// a local path.
+func IsRemoteURL(s string) bool {
+	for _, scheme := range []string{"https://", "http://", "ssh://", "git://", "file://"} {
+		if strings.HasPrefix(s, scheme) {
+			return true
+		}
+	}
+	return scpLikeURL.MatchString(s)
+}
+
+// Clone clones the default branch of the remote repository at url into dir,
+// checking out its files so its config and .gitignore files apply. Progress
+// is written to progress if it isn't nil. If token is set, it authenticates
+// over HTTPS with it, as hosts like GitHub accept for private repositories,
+// but it's never sent over plain HTTP. SSH remotes use the SSH agent.
+func Clone(url, dir, token string, progress io.Writer) error {
+	var auth transport.AuthMethod
+	if token != "" && strings.HasPrefix(url, "https://") {
+		auth = &http.BasicAuth{Username: "git-hotspots", Password: token}
+	}
+	_, err := git.PlainClone(dir, false, &git.CloneOptions{
+		URL:          url,
+		Auth:         auth,
+		SingleBranch: true,
+		Tags:         git.NoTags,
+		Progress:     progress,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone %s: %w", url, err)
+	}
+	return nil
+}
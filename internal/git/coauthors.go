@@ -0,0 +1,41 @@
+package git
+
+import (
+	"bufio"
+	"strings"
+)
+
+// coAuthorTrailer is the trailer crediting additional authors of a commit,
+// matched case-insensitively as git does.
+const coAuthorTrailer = "co-authored-by:"
+
+// CoAuthor is a person credited in a commit's Co-authored-by trailer.
+type CoAuthor struct {
+	Name  string
+	Email string
+}
+
+// ParseCoAuthors returns the co-authors credited by the Co-authored-by
+// trailers in a commit message, in order. Trailers without a name are skipped.
+func ParseCoAuthors(message string) []CoAuthor {
+	var coAuthors []CoAuthor
+	scanner := bufio.NewScanner(strings.NewReader(message))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < len(coAuthorTrailer) || !strings.EqualFold(line[:len(coAuthorTrailer)], coAuthorTrailer) {
+			continue
+		}
+
+		// Split "Name <email>" into its parts
+		value := strings.TrimSpace(line[len(coAuthorTrailer):])
+		name, email := value, ""
+		if start := strings.Index(value, "<"); start >= 0 {
+			name = strings.TrimSpace(value[:start])
+			email = strings.TrimSuffix(strings.TrimSpace(value[start+1:]), ">")
+		}
+		if name != "" {
+			coAuthors = append(coAuthors, CoAuthor{Name: name, Email: email})
+		}
+	}
+	return coAuthors
+}
@@ -0,0 +1,67 @@
+package git
+
+import "sort"
+
+// SortColdspots sorts hotspots by score in ascending order, so the least
+// changed come first. Ties are broken by commits, fewest first, and then by
+// path.
+func SortColdspots(hotspots []Hotspot) {
+	SortColdspotsBy(hotspots, func(h Hotspot) float64 {
+		return h.Score
+	})
+}
+
+// SortColdspotsBy sorts hotspots by key in ascending order, breaking ties
+// like SortColdspots, and sets their Rank.
+func SortColdspotsBy(hotspots []Hotspot, key func(h Hotspot) float64) {
+	sort.Slice(hotspots, func(i, j int) bool {
+		if ki, kj := key(hotspots[i]), key(hotspots[j]); ki != kj {
+			return ki < kj
+		}
+		if hotspots[i].Commits != hotspots[j].Commits {
+			return hotspots[i].Commits < hotspots[j].Commits
+		}
+		return hotspots[i].Path < hotspots[j].Path
+	})
+	setRanks(hotspots)
+}
+
+// AddUnchanged adds a hotspot without commits for each of files, such as
+// those of HeadTree.Files, that isn't among fileHotspots, and likewise for
+// the directories or components they're grouped into, so files that never
+// changed in the window can be listed as coldspots. Nothing is added where
+// opts would have dropped it, i.e. with MinCommits or ActiveWithin set, or
+// for test files with ExcludeTests.
+func AddUnchanged(fileHotspots, dirHotspots []Hotspot, files []string, opts HotspotOptions) ([]Hotspot, []Hotspot) {
+	if opts.MinCommits > 0 || opts.ActiveWithin > 0 {
+		return fileHotspots, dirHotspots
+	}
+
+	seen := make(map[string]bool)
+	for _, h := range fileHotspots {
+		seen[h.Path] = true
+	}
+	seenDirs := make(map[string]bool)
+	for _, h := range dirHotspots {
+		seenDirs[h.Path] = true
+	}
+
+	acc := NewHotspotAccumulatorWithOptions(opts)
+	for _, file := range files {
+		if opts.ExcludeTests.Matches(file) {
+			continue
+		}
+		if !opts.SkipFiles && !seen[file] {
+			seen[file] = true
+			fileHotspots = append(fileHotspots, Hotspot{Path: file})
+		}
+		if opts.SkipDirs {
+			continue
+		}
+		if dir, ok := acc.groupFor(file); ok && !seenDirs[dir] {
+			seenDirs[dir] = true
+			dirHotspots = append(dirHotspots, Hotspot{Path: dir})
+		}
+	}
+	return fileHotspots, dirHotspots
+}
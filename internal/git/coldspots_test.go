@@ -0,0 +1,55 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortColdspots(t *testing.T) {
+	hotspots := []Hotspot{
+		{Path: "c.go", Score: 2, Commits: 2},
+		{Path: "b.go", Score: 2, Commits: 3},
+		{Path: "a.go", Score: 5, Commits: 5},
+		{Path: "d.go", Score: 2, Commits: 2},
+		{Path: "e.go"},
+	}
+	SortColdspots(hotspots)
+
+	var paths []string
+	for _, h := range hotspots {
+		paths = append(paths, h.Path)
+	}
+	if expected := []string{"e.go", "c.go", "d.go", "b.go", "a.go"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}
+
+func TestAddUnchanged(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "1", Author: "Test User", Date: time.Now(), Files: []string{"src/main.go"}},
+	}
+	files := []string{"src/main.go", "src/util.go", "docs/guide.md", "README.md"}
+	fileHotspots, dirHotspots := IdentifyHotspots(commits)
+
+	fileHotspots, dirHotspots = AddUnchanged(fileHotspots, dirHotspots, files, HotspotOptions{})
+	SortColdspots(fileHotspots)
+	SortColdspots(dirHotspots)
+	var paths []string
+	for _, h := range append(fileHotspots, dirHotspots...) {
+		paths = append(paths, h.Path)
+	}
+	expected := []string{"README.md", "docs/guide.md", "src/util.go", "src/main.go", "docs", "src"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+	if fileHotspots[0].Commits != 0 || !fileHotspots[0].LastModified.IsZero() {
+		t.Errorf("Expected an unchanged file to have no commits, got %+v", fileHotspots[0])
+	}
+
+	// Files without commits would be dropped by a commit threshold
+	fileHotspots, _ = AddUnchanged(nil, nil, files, HotspotOptions{MinCommits: 1})
+	if len(fileHotspots) != 0 {
+		t.Errorf("Expected nothing added with MinCommits, got %v", fileHotspots)
+	}
+}
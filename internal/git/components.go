@@ -0,0 +1,83 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// OtherComponent is the component of files matching none of the rules.
+const OtherComponent = "other"
+
+// ComponentRule assigns the files matching Glob to a component. Globs use
+// path.Match syntax on slash-separated paths, where "**" also matches any
+// number of directories. A glob matching a directory matches every file in it.
+type ComponentRule struct {
+	Glob      string
+	Component string
+}
+
+// Components groups files into logical components, for repositories whose
+// directories don't map cleanly onto them.
+type Components struct {
+	rules []ComponentRule
+
+	// modules maps the directories of Go modules to their module paths, for
+	// components made by GoModuleComponents
+	modules map[string]string
+}
+
+// NewComponents returns components assigned by rules. The first rule
+// matching a file wins.
+func NewComponents(rules []ComponentRule) (*Components, error) {
+	for _, rule := range rules {
+		if rule.Component == "" {
+			return nil, fmt.Errorf("no component given for %q", rule.Glob)
+		}
+		for _, segment := range strings.Split(rule.Glob, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %w", rule.Glob, err)
+			}
+		}
+	}
+	return &Components{rules: rules}, nil
+}
+
+// componentFor returns the component of file, or OtherComponent if no rule
+// matches. Go modules fall back to the directory of files outside any
+// module, reporting false for files in the root directory.
+func (c *Components) componentFor(file string) (string, bool) {
+	if c.modules != nil {
+		return c.moduleFor(file)
+	}
+	segments := strings.Split(file, "/")
+	for _, rule := range c.rules {
+		if matchGlob(strings.Split(rule.Glob, "/"), segments) {
+			return rule.Component, true
+		}
+	}
+	return OtherComponent, true
+}
+
+// matchGlob reports whether the path segments match the glob segments or
+// lie under a directory that does.
+func matchGlob(glob, segments []string) bool {
+	if len(glob) == 0 {
+		return true
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(glob[1:], segments[1:])
+}
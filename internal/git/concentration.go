@@ -0,0 +1,38 @@
+package git
+
+// concentration returns how unevenly the commits to a hotspot are spread
+// among its contributors, from 0 when every contributor made as many commits
+// as the others to 1 when a single one made them all.
+func concentration(contributors []Contributor) float64 {
+	counts := make([]int, len(contributors))
+	for i, c := range contributors {
+		counts[i] = c.Commits
+	}
+	return gini(counts)
+}
+
+// gini returns the Gini coefficient of counts, corrected by n/(n-1) for the
+// number n of counts so it reaches 1 when one holds nearly everything, as a
+// sole author does, rather than at most (n-1)/n. A single positive count is
+// fully concentrated, 1, and no counts or only zeros are 0.
+func gini(counts []int) float64 {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	if len(counts) == 1 {
+		return 1
+	}
+
+	// Sum the absolute differences between every pair of counts
+	differences := 0
+	for i, a := range counts {
+		for _, b := range counts[i+1:] {
+			differences += max(a-b, b-a)
+		}
+	}
+	return float64(differences) / float64((len(counts)-1)*total)
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGini(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts []int
+		want   float64
+	}{
+		{"no authors", nil, 0},
+		{"no commits", []int{0, 0}, 0},
+		{"sole author", []int{7}, 1},
+		{"even split", []int{5, 5, 5, 5}, 0},
+		{"one author of two", []int{10, 0}, 1},
+		{"99 to 1", []int{99, 1}, 0.98},
+		{"3 to 1", []int{3, 1}, 0.5},
+		{"1, 2 and 3", []int{1, 2, 3}, 2.0 / 6}, // Differences of 1, 2 and 1 over 2 × 6
+		{"order doesn't matter", []int{3, 1, 2}, 2.0 / 6},
+		{"one dominant of five", []int{96, 1, 1, 1, 1}, 0.95},
+	}
+	for _, tt := range tests {
+		if got := gini(tt.counts); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: gini(%v) = %v, want %v", tt.name, tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifyHotspotsRankByConcentration(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Alice", Files: []string{"shared.go", "silo.go"}},
+		{Hash: "hash2", Author: "Bob", Files: []string{"shared.go", "silo.go"}},
+		{Hash: "hash3", Author: "Alice", Files: []string{"silo.go"}},
+		{Hash: "hash4", Author: "Alice", Files: []string{"silo.go"}},
+	}
+
+	// silo.go splits 3 to 1, shared.go 1 to 1
+	files, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{RankBy: RankByConcentration})
+	SortHotspots(files)
+	if len(files) != 2 || files[0].Path != "silo.go" || files[0].Score != 0.5 || files[1].Score != 0 {
+		t.Errorf("Expected silo.go ranked first with a concentration of 0.5, got %v", files)
+	}
+
+	// Concentration is reported whatever the ranking
+	files, _ = IdentifyHotspots(commits)
+	for _, h := range files {
+		if want := map[string]float64{"silo.go": 0.5, "shared.go": 0}[h.Path]; h.Concentration != want {
+			t.Errorf("Expected %s to have a concentration of %v, got %v", h.Path, want, h.Concentration)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package git
+
+import "sort"
+
+// ContributorStat is an author's activity across the whole repository.
+type ContributorStat struct {
+	Author  string
+	Commits int // Commits by the author
+	Files   int // Distinct files touched by those commits
+}
+
+// IdentifyContributors returns every commit author with their number of
+// commits and distinct files touched, ordered by commits and then by files,
+// most first.
+func IdentifyContributors(commits []CommitInfo) []ContributorStat {
+	commitCounts := make(map[string]int)
+	files := make(map[string]map[string]bool) // author -> files touched
+	for _, commit := range commits {
+		commitCounts[commit.Author]++
+		if files[commit.Author] == nil {
+			files[commit.Author] = make(map[string]bool)
+		}
+		for _, file := range commit.Files {
+			files[commit.Author][file] = true
+		}
+	}
+
+	var stats []ContributorStat
+	for author, count := range commitCounts {
+		stats = append(stats, ContributorStat{Author: author, Commits: count, Files: len(files[author])})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Commits != stats[j].Commits {
+			return stats[i].Commits > stats[j].Commits
+		}
+		if stats[i].Files != stats[j].Files {
+			return stats[i].Files > stats[j].Files
+		}
+		return stats[i].Author < stats[j].Author
+	})
+	return stats
+}
@@ -0,0 +1,27 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIdentifyContributors(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "1", Author: "Alice", Date: now, Files: []string{"a.go", "b.go"}},
+		{Hash: "2", Author: "Alice", Date: now, Files: []string{"a.go"}},
+		{Hash: "3", Author: "Bob", Date: now, Files: []string{"c.go"}},
+		{Hash: "4", Author: "Carol", Date: now, Files: []string{"a.go", "c.go"}},
+	}
+
+	// Files are counted once per author, and ties on commits go to the broader contributor
+	expected := []ContributorStat{
+		{Author: "Alice", Commits: 2, Files: 2},
+		{Author: "Carol", Commits: 1, Files: 2},
+		{Author: "Bob", Commits: 1, Files: 1},
+	}
+	if got := IdentifyContributors(commits); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,30 @@
+package git
+
+import "sort"
+
+// DefaultDefectPattern matches the messages of commits that fix bugs: those
+// mentioning a fix or a bug, or referencing an issue like #456 or JIRA-123.
+const DefaultDefectPattern = `(?i:\b(?:fix(?:es|ed)?|bug)\b)|#\d+|\b[A-Z][A-Z0-9]+-\d+\b`
+
+// RankDefects returns the hotspots touched by at least one defect commit, as
+// counted with HotspotOptions.DefectPattern, ordered by defect commits and
+// then by total commits, most first.
+func RankDefects(hotspots []Hotspot) []Hotspot {
+	var defects []Hotspot
+	for _, h := range hotspots {
+		if h.Defects > 0 {
+			defects = append(defects, h)
+		}
+	}
+
+	sort.Slice(defects, func(i, j int) bool {
+		if defects[i].Defects != defects[j].Defects {
+			return defects[i].Defects > defects[j].Defects
+		}
+		if defects[i].Commits != defects[j].Commits {
+			return defects[i].Commits > defects[j].Commits
+		}
+		return defects[i].Path < defects[j].Path
+	})
+	return defects
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestDefaultDefectPattern(t *testing.T) {
+	pattern := regexp.MustCompile(DefaultDefectPattern)
+	for message, want := range map[string]bool{
+		"Fix crash on empty input":     true,
+		"fixes the parser":             true,
+		"Handle timeouts (#456)":       true,
+		"JIRA-123: handle timeouts":    true,
+		"Add a bugfix-free feature":    false,
+		"Prefix names with the module": false,
+		"Move to the v2 API":           false,
+	} {
+		if got := pattern.MatchString(message); got != want {
+			t.Errorf("Match(%q) = %v, want %v", message, got, want)
+		}
+	}
+}
+
+func TestRankDefects(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "1", Author: "Test User", Date: now, Message: "Fix crash", Files: []string{"a.go", "b.go"}},
+		{Hash: "2", Author: "Test User", Date: now, Message: "PROJ-7 handle nil", Files: []string{"b.go"}},
+		{Hash: "3", Author: "Test User", Date: now, Message: "Add feature", Files: []string{"a.go", "c.go"}},
+		{Hash: "4", Author: "Test User", Date: now, Message: "Add more", Files: []string{"a.go"}},
+	}
+	opts := HotspotOptions{DefectPattern: regexp.MustCompile(DefaultDefectPattern)}
+	fileHotspots, _ := IdentifyHotspotsWithOptions(commits, opts)
+
+	defects := RankDefects(fileHotspots)
+	if len(defects) != 2 {
+		t.Fatalf("Expected 2 files with defects, got %+v", defects)
+	}
+	if defects[0].Path != "b.go" || defects[0].Defects != 2 || defects[0].Commits != 2 {
+		t.Errorf("Expected b.go with 2 defects first, got %+v", defects[0])
+	}
+	if defects[1].Path != "a.go" || defects[1].Defects != 1 || defects[1].Commits != 3 {
+		t.Errorf("Expected a.go with 1 defect of 3 commits second, got %+v", defects[1])
+	}
+}
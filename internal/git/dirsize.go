@@ -0,0 +1,31 @@
+package git
+
+// CountDirFiles counts the files among files, such as those in HEAD, that
+// are grouped into each directory hotspot or component with opts, for
+// HotspotOptions.DirSizes.
+func CountDirFiles(files []string, opts HotspotOptions) map[string]int {
+	a := NewHotspotAccumulatorWithOptions(opts)
+	sizes := make(map[string]int)
+	for _, file := range files {
+		if opts.ExcludeTests.Matches(file) {
+			continue
+		}
+		if dir, ok := a.groupFor(file); ok {
+			sizes[a.dirKey(dir)]++
+		}
+	}
+	return sizes
+}
+
+// normalizeByDirSize divides the score of each directory hotspot by the
+// number of files under it in sizes, or sets it to 0 if it has none.
+func (a *HotspotAccumulator) normalizeByDirSize(dirHotspots []Hotspot, sizes map[string]int) {
+	for i, h := range dirHotspots {
+		size := sizes[a.dirKey(h.Path)]
+		if size == 0 {
+			dirHotspots[i].Score = 0
+			continue
+		}
+		dirHotspots[i].Score = h.Score / float64(size)
+	}
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// HeadTree looks up paths in the tree of a repository's HEAD commit, to tell
+// whether hotspots still exist.
+type HeadTree struct {
+	tree    *object.Tree
+	subpath string
+}
+
+// OpenHeadTree loads the HEAD tree of the repository containing repoPath.
+// Paths looked up in it are relative to subpath, like those of hotspots
+// analyzed with AnalyzeOptions.Path.
+func OpenHeadTree(repoPath, subpath string) (*HeadTree, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+	return &HeadTree{tree: tree, subpath: cleanSubpath(subpath)}, nil
+}
+
+// Exists reports whether the file or directory at the slash-separated path
+// file exists in HEAD.
+func (t *HeadTree) Exists(file string) bool {
+	if t.subpath == "" {
+		_, err := t.tree.FindEntry(file)
+		return err == nil
+	}
+	if _, err := t.tree.FindEntry(t.subpath + "/" + file); err == nil {
+		return true
+	}
+
+	// A subpath naming a single file reports it by its base name
+	if file != path.Base(t.subpath) {
+		return false
+	}
+	_, err := t.tree.FindEntry(t.subpath)
+	return err == nil
+}
+
+// Files returns the paths of the regular files in HEAD under the subpath,
+// relative to it, with one of extensions if any are given. Symlinks and
+// submodules are skipped, as they are when analyzing commits.
+func (t *HeadTree) Files(extensions []string) ([]string, error) {
+	tree := t.tree
+	if t.subpath != "" {
+		entry, err := t.tree.FindEntry(t.subpath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s in HEAD: %w", t.subpath, err)
+		}
+
+		// A subpath naming a single file reports it by its base name
+		if entry.Mode.IsFile() {
+			name := path.Base(t.subpath)
+			if entry.Mode == filemode.Symlink || !hasExtension(name, extensions) {
+				return nil, nil
+			}
+			return []string{name}, nil
+		}
+		if tree, err = t.tree.Tree(t.subpath); err != nil {
+			return nil, fmt.Errorf("failed to read %s in HEAD: %w", t.subpath, err)
+		}
+	}
+
+	var files []string
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files in HEAD: %w", err)
+		}
+		if !entry.Mode.IsFile() || entry.Mode == filemode.Symlink || !hasExtension(name, extensions) {
+			continue
+		}
+		files = append(files, name)
+	}
+}
+
+// CountLines counts the lines of each of files in HEAD, given their paths
+// relative to the subpath as Files lists them, for HotspotOptions.FileLines.
+// Binary files are left out.
+func (t *HeadTree) CountLines(files []string) (map[string]int, error) {
+	lines := make(map[string]int, len(files))
+	for _, file := range files {
+		f, err := t.tree.File(t.fullPath(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s in HEAD: %w", file, err)
+		}
+		contents, text, err := textContents(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s in HEAD: %w", file, err)
+		}
+		if text {
+			lines[file] = countLines(contents)
+		}
+	}
+	return lines, nil
+}
+
+// IsFile reports whether the subpath names a single file in HEAD rather
+// than a directory, so paths are reported by its base name.
+func (t *HeadTree) IsFile() bool {
+	if t.subpath == "" {
+		return false
+	}
+	entry, err := t.tree.FindEntry(t.subpath)
+	return err == nil && entry.Mode.IsFile()
+}
+
+// fullPath returns the path in the tree of file, given relative to the
+// subpath, or by its base name if the subpath names a single file.
+func (t *HeadTree) fullPath(file string) string {
+	if t.subpath == "" {
+		return file
+	}
+	if t.IsFile() {
+		return t.subpath
+	}
+	return t.subpath + "/" + file
+}
+
+// FilterExisting returns the hotspots whose paths exist according to exists,
+// dropping files and directories that have been deleted.
+func FilterExisting(hotspots []Hotspot, exists func(path string) bool) []Hotspot {
+	var existing []Hotspot
+	for _, h := range hotspots {
+		if exists(h.Path) {
+			existing = append(existing, h)
+		}
+	}
+	return existing
+}
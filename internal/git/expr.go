@@ -0,0 +1,233 @@
+package git
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// scoreVariables are the metrics a ScoreExpr can refer to, by name.
+var scoreVariables = map[string]func(h Hotspot, now time.Time) float64{
+	"commits":     func(h Hotspot, now time.Time) float64 { return float64(h.Commits) },
+	"churn":       func(h Hotspot, now time.Time) float64 { return float64(h.LinesAdded + h.LinesDeleted) },
+	"authorCount": func(h Hotspot, now time.Time) float64 { return float64(len(h.Contributors)) },
+	"ageDays":     func(h Hotspot, now time.Time) float64 { return now.Sub(h.FirstSeen).Hours() / 24 },
+	"idleDays":    func(h Hotspot, now time.Time) float64 { return now.Sub(h.LastModified).Hours() / 24 },
+	"reverts":     func(h Hotspot, now time.Time) float64 { return float64(h.Reverts) },
+	"deletions":   func(h Hotspot, now time.Time) float64 { return float64(h.Deletions) },
+}
+
+// ScoreVariables returns the names of the variables a ScoreExpr can use, sorted.
+func ScoreVariables() []string {
+	names := make([]string, 0, len(scoreVariables))
+	for name := range scoreVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScoreExpr is a custom ranking formula such as "commits * 2 + churn",
+// computing each hotspot's score from its metrics. Expressions are made of
+// numbers, the variables named by ScoreVariables, the operators + - * / and
+// parentheses.
+type ScoreExpr struct {
+	source string
+	root   exprNode
+	vars   map[string]bool
+}
+
+// exprNode is a node of a parsed expression.
+type exprNode interface {
+	eval(h Hotspot, now time.Time) float64
+}
+
+type exprNumber float64
+
+type exprVariable string
+
+type exprUnary struct {
+	operand exprNode
+}
+
+type exprBinary struct {
+	op          byte
+	left, right exprNode
+}
+
+func (n exprNumber) eval(Hotspot, time.Time) float64 { return float64(n) }
+
+func (n exprVariable) eval(h Hotspot, now time.Time) float64 {
+	return scoreVariables[string(n)](h, now)
+}
+
+func (n exprUnary) eval(h Hotspot, now time.Time) float64 { return -n.operand.eval(h, now) }
+
+func (n exprBinary) eval(h Hotspot, now time.Time) float64 {
+	left, right := n.left.eval(h, now), n.right.eval(h, now)
+	switch n.op {
+	case '+':
+		return left + right
+	case '-':
+		return left - right
+	case '*':
+		return left * right
+	default:
+		return left / right
+	}
+}
+
+// ParseScoreExpr parses a score expression, returning an error for a syntax
+// error or a variable that doesn't exist.
+func ParseScoreExpr(source string) (*ScoreExpr, error) {
+	p := &exprParser{source: source, vars: make(map[string]bool)}
+	p.next()
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.token, p.offset)
+	}
+	return &ScoreExpr{source: source, root: root, vars: p.vars}, nil
+}
+
+// String returns the expression as it was given.
+func (e *ScoreExpr) String() string {
+	return e.source
+}
+
+// Uses reports whether the expression refers to the named variable, e.g. to
+// know whether lines must be counted for "churn".
+func (e *ScoreExpr) Uses(variable string) bool {
+	return e.vars[variable]
+}
+
+// Eval computes the score of h, with ages counted up to now. Results that
+// aren't finite numbers, such as from dividing by zero, count as 0.
+func (e *ScoreExpr) Eval(h Hotspot, now time.Time) float64 {
+	score := e.root.eval(h, now)
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return 0
+	}
+	return score
+}
+
+// exprParser is a recursive descent parser for score expressions.
+type exprParser struct {
+	source string
+	pos    int
+	token  string // Current token, empty at the end
+	offset int    // Offset of the current token in source
+	vars   map[string]bool
+}
+
+// next advances to the next token.
+func (p *exprParser) next() {
+	for p.pos < len(p.source) && unicode.IsSpace(rune(p.source[p.pos])) {
+		p.pos++
+	}
+	p.offset = p.pos
+	if p.pos == len(p.source) {
+		p.token = ""
+		return
+	}
+
+	start := p.pos
+	c := rune(p.source[p.pos])
+	switch {
+	case unicode.IsDigit(c) || c == '.':
+		for p.pos < len(p.source) && (unicode.IsDigit(rune(p.source[p.pos])) || p.source[p.pos] == '.') {
+			p.pos++
+		}
+	case unicode.IsLetter(c) || c == '_':
+		for p.pos < len(p.source) && (unicode.IsLetter(rune(p.source[p.pos])) || unicode.IsDigit(rune(p.source[p.pos])) || p.source[p.pos] == '_') {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.token = p.source[start:p.pos]
+}
+
+// parseSum parses terms separated by + or -.
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "+" || p.token == "-" {
+		op := p.token[0]
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseProduct parses factors separated by * or /.
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "*" || p.token == "/" {
+		op := p.token[0]
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFactor parses a number, a variable, a negation or a parenthesized sum.
+func (p *exprParser) parseFactor() (exprNode, error) {
+	token, offset := p.token, p.offset
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "-":
+		p.next()
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return exprUnary{operand: operand}, nil
+	case token == "(":
+		p.next()
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			return nil, fmt.Errorf("missing ) for ( at offset %d", offset)
+		}
+		p.next()
+		return inner, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", token, offset)
+		}
+		p.next()
+		return exprNumber(value), nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		if _, ok := scoreVariables[token]; !ok {
+			return nil, fmt.Errorf("unknown variable %q (available: %s)", token, strings.Join(ScoreVariables(), ", "))
+		}
+		p.vars[token] = true
+		p.next()
+		return exprVariable(token), nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", token, offset)
+}
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScoreExprEval(t *testing.T) {
+	now := time.Now()
+	h := Hotspot{
+		Commits:      4,
+		LinesAdded:   10,
+		LinesDeleted: 5,
+		FirstSeen:    now.Add(-10 * 24 * time.Hour),
+		LastModified: now.Add(-2 * 24 * time.Hour),
+		Reverts:      1,
+		Contributors: []Contributor{{Author: "Alice", Commits: 3}, {Author: "Bob", Commits: 1}},
+	}
+	for source, want := range map[string]float64{
+		"commits * 2 + churn":          23,
+		"commits * (2 + churn)":        68,
+		"-commits + 10":                6,
+		"churn / authorCount":          7.5,
+		"ageDays - idleDays":           8,
+		"reverts * 100 + 0.5":          100.5,
+		"commits / (authorCount - 2)":  0,
+		"  commits*commits/commits  ":  4,
+		"commits - authorCount - 1":    1,
+		"commits / authorCount / 2":    1,
+		"(commits)":                    4,
+		"3":                            3,
+		"commits - -1":                 5,
+		"churn * .5":                   7.5,
+		"commits + reverts * commits":  8,
+		"ageDays / (ageDays - 10) + 1": 0,
+	} {
+		expr, err := ParseScoreExpr(source)
+		if err != nil {
+			t.Errorf("ParseScoreExpr(%q) failed: %v", source, err)
+			continue
+		}
+		if got := expr.Eval(h, now); got != want {
+			t.Errorf("Eval(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestParseScoreExprErrors(t *testing.T) {
+	for source, want := range map[string]string{
+		"commits * lines": `unknown variable "lines"`,
+		"commits +":       "unexpected end of expression",
+		"(commits + 1":    "missing )",
+		"commits 2":       `unexpected "2"`,
+		"commits % 2":     `unexpected "%"`,
+		"1.2.3":           `invalid number "1.2.3"`,
+		"":                "unexpected end of expression",
+	} {
+		_, err := ParseScoreExpr(source)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseScoreExpr(%q) error = %v, want %q", source, err, want)
+		}
+	}
+}
+
+func TestIdentifyHotspotsScoreExpr(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "1", Author: "Alice", Date: now, Files: []string{"a.go", "b.go"}},
+		{Hash: "2", Author: "Bob", Date: now, Files: []string{"b.go"}},
+		{Hash: "3", Author: "Alice", Date: now, Files: []string{"a.go"}},
+		{Hash: "4", Author: "Alice", Date: now, Files: []string{"a.go"}},
+	}
+	expr, err := ParseScoreExpr("authorCount * 10 + commits")
+	if err != nil {
+		t.Fatalf("ParseScoreExpr failed: %v", err)
+	}
+	if !expr.Uses("authorCount") || expr.Uses("churn") {
+		t.Errorf("Expected the expression to use authorCount but not churn")
+	}
+
+	fileHotspots, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{ScoreExpr: expr, Now: now})
+	SortHotspots(fileHotspots)
+	if fileHotspots[0].Path != "b.go" || fileHotspots[0].Score != 22 {
+		t.Errorf("Expected b.go with score 22 first, got %+v", fileHotspots[0])
+	}
+	if fileHotspots[1].Path != "a.go" || fileHotspots[1].Score != 13 {
+		t.Errorf("Expected a.go with score 13 second, got %+v", fileHotspots[1])
+	}
+}
@@ -1,153 +1,966 @@
 package git
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/go-git/go-git/v5/utils/merkletrie"
 )
 
-// IsGitRepository checks if the given path is a Git repository.
+// IsGitRepository checks if the given path is inside a Git repository.
 func IsGitRepository(path string) bool {
-	_, err := git.PlainOpen(path)
+	_, err := openRepository(path)
 	return err == nil
 }
 
+// RepositoryRoot returns the root of the working tree of the Git repository containing path.
+// For repositories without a working tree, path itself is returned.
+func RepositoryRoot(path string) (string, error) {
+	repo, err := openRepository(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err == git.ErrIsBareRepository {
+		return path, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+	return wt.Filesystem.Root(), nil
+}
+
+// HeadRef returns the name of the ref HEAD points to, such as
+// "refs/heads/main", or "HEAD" if it's detached, and the commit it resolves to.
+// The history is walked from this commit.
+func HeadRef(path string) (name, hash string, err error) {
+	repo, err := openRepository(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open git repository: %w", err)
+	}
+	ref, err := repo.Head()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+	return ref.Name().String(), ref.Hash().String(), nil
+}
+
+// IsShallow reports whether the repository containing path is a shallow
+// clone, e.g. from git clone --depth 1, whose history is truncated.
+func IsShallow(path string) (bool, error) {
+	repo, err := openRepository(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return false, fmt.Errorf("failed to read shallow commits: %w", err)
+	}
+	return len(shallow) > 0, nil
+}
+
+// openRepository opens the Git repository containing path, walking up parent
+// directories to find the .git directory. Like git itself, the GIT_DIR
+// environment variable takes precedence when set. A path that doesn't exist
+// is an error rather than part of the repository above it.
+func openRepository(path string) (*git.Repository, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		return git.PlainOpen(gitDir)
+	}
+	return git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+}
+
+// openRepositoryWithCache opens the Git repository containing path like
+// openRepository, with an object cache of cacheSize MiB instead of go-git's
+// default of 96 MiB. A cacheSize of zero keeps the default.
+func openRepositoryWithCache(path string, cacheSize int) (*git.Repository, error) {
+	repo, err := openRepository(path)
+	if err != nil || cacheSize <= 0 {
+		return repo, err
+	}
+
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return repo, nil
+	}
+	var worktree billy.Filesystem
+	if wt, err := repo.Worktree(); err == nil {
+		worktree = wt.Filesystem
+	}
+	objects := cache.NewObjectLRU(cache.FileSize(cacheSize) * cache.MiByte)
+	return git.Open(filesystem.NewStorage(storage.Filesystem(), objects), worktree)
+}
+
 // CommitInfo holds information about a commit.
 type CommitInfo struct {
-	Hash    string
-	Author  string
-	Date    time.Time
-	Message string
-	Files   []string
+	Hash        string
+	Author      string
+	AuthorEmail string
+	Date        time.Time
+	Message     string
+	Files       []string
+	CoAuthors   []CoAuthor // From the message's Co-authored-by trailers
+
+	// Lines holds the lines added and deleted in each of Files if
+	// AnalyzeOptions.CountLines was set. Binary files have no entry.
+	Lines map[string]LineChanges
+
+	// Actions holds whether the commit added or deleted each of Files, as
+	// compared with its first parent. Modified files have no entry.
+	Actions map[string]FileAction
+}
+
+// FileAction is what a commit did to a file.
+type FileAction int
+
+const (
+	// FileModified is a change to an existing file.
+	FileModified FileAction = iota
+
+	// FileAdded is the creation of a file, or its addition under a new path.
+	FileAdded
+
+	// FileDeleted is the removal of a file, or of its old path when moved.
+	FileDeleted
+)
+
+// DefaultSince returns the start of the default analysis window, one year before now.
+func DefaultSince(now time.Time) time.Time {
+	return now.AddDate(-1, 0, 0)
+}
+
+// AnalyzeOptions controls which commits are analyzed.
+type AnalyzeOptions struct {
+	// Since is the start of the analysis window. A zero value analyzes the full history.
+	Since time.Time
+
+	// Path restricts the analysis to files under this slash-separated path
+	// relative to the repository root. File paths are then reported relative
+	// to it. An empty value analyzes the whole repository.
+	Path string
+
+	// Ref, if set, walks the history from this branch, tag or commit instead
+	// of HEAD, per ValidateRef. It's ignored with Hashes and AllRefs.
+	Ref string
+
+	// Hashes lists the commits to analyze instead of walking the history from
+	// HEAD. Any revision go-git can resolve is accepted, e.g. abbreviated
+	// hashes. Since is ignored for these commits.
+	Hashes []string
+
+	// MaxFilesPerCommit skips commits touching more files than this, such as
+	// vendored dependency updates or generated code. Zero keeps all commits.
+	MaxFilesPerCommit int
+
+	// OnLargeCommit, if set, is called for each commit skipped because of
+	// MaxFilesPerCommit.
+	OnLargeCommit func(commit CommitInfo)
+
+	// OnCommitIssue, if set, is called for each issue that kept an analyzed
+	// commit from being fully read, such as a parent that couldn't be diffed.
+	OnCommitIssue func(commit CommitInfo, issue CommitIssue)
+
+	// IncludeSubmodules counts changes to submodule pointers (gitlinks) as
+	// changed files. By default they're skipped, so bumping a submodule
+	// doesn't show up as a code hotspot. Symlinks are always skipped.
+	IncludeSubmodules bool
+
+	// CountLines counts the lines each commit added and deleted in each file,
+	// for ranking by churn. It's off by default because diffing every file
+	// is much slower than listing the changed ones.
+	CountLines bool
+
+	// CacheSize is the size in MiB of the cache of decoded objects. Larger
+	// caches avoid decoding the same trees and blobs again on large
+	// repositories, at the cost of memory. Zero uses go-git's default.
+	CacheSize int
+
+	// IgnoreWhitespace counts lines differing only in whitespace as
+	// unchanged when counting lines, so reformatting doesn't add churn.
+	// It has no effect on which files a commit touched.
+	IgnoreWhitespace bool
+
+	// Extensions restricts the analysis to files with one of these
+	// extensions, such as ".go", ignoring case. Commits touching no such
+	// files are skipped. An empty value analyzes every file.
+	Extensions []string
+
+	// Aliases, if set, replaces the names of authors and co-authors with
+	// their canonical names, merging contributors with several identities.
+	Aliases *Aliases
+
+	// ExcludeAuthors, if set, drops the commits of the authors it matches,
+	// such as bots, per their identity before Aliases are applied.
+	ExcludeAuthors *AuthorFilter
+
+	// Sample analyzes a random fraction of the commits, such as 0.1 for one
+	// in ten, for a quick estimate on large repositories. Zero analyzes all.
+	Sample float64
+
+	// Seed seeds the random choice of commits for Sample, so runs with the
+	// same seed analyze the same commits.
+	Seed int64
+
+	// LimitCommits stops the walk after the most recent LimitCommits commits,
+	// like git log -n, for a quick look at a large repository. They're
+	// counted before Sample and every other filter drops any, and with
+	// AllRefs they're the most recent across every branch. With Since,
+	// whichever is reached first ends the walk. Zero walks every commit. It's
+	// ignored with Hashes.
+	LimitCommits int
+
+	// OnLimitReached, if set, is called if the walk stopped at LimitCommits
+	// with older commits left, so results are truncated.
+	OnLimitReached func()
+
+	// AllRefs walks the commits reachable from HEAD and every local branch
+	// instead of HEAD alone, counting a commit on several branches once, so
+	// work on unmerged branches is included. Remotes adds the
+	// remote-tracking branches as well.
+	AllRefs bool
+	Remotes bool
+
+	// SkipRootCommits drops commits without parents, such as an initial bulk
+	// import that would otherwise count as a change to every file it added.
+	SkipRootCommits bool
+
+	// Backend selects how commits are read. The zero value uses go-git.
+	Backend Backend
+
+	// MergeStrategy selects which changes of merge commits are counted. The
+	// zero value is MergeUnion.
+	MergeStrategy MergeStrategy
+
+	// RespectGitIgnore skips files the repository's git ignore configuration
+	// ignores, per ReadGitIgnore, even if they were committed, such as
+	// generated files. Commits touching only such files are skipped.
+	RespectGitIgnore bool
+
+	// gitIgnore is read by AnalyzeCommitsFunc if RespectGitIgnore is set.
+	gitIgnore *GitIgnore
 }
 
+// MergeStrategy selects which changes of a merge commit are counted.
+type MergeStrategy string
+
+const (
+	// MergeUnion counts the files the merge changed compared with any of its
+	// parents, so the work merged in from a branch counts again on top of
+	// the branch's own commits.
+	MergeUnion MergeStrategy = "union"
+
+	// MergeFirstParent counts only the files the merge changed compared
+	// with its first parent: what it brought into the branch merged into.
+	MergeFirstParent MergeStrategy = "first-parent"
+
+	// MergeNone skips merge commits, counting only the commits that made
+	// the changes.
+	MergeNone MergeStrategy = "none"
+)
+
 // AnalyzeCommits analyzes git commits in the last year and returns commit information.
 func AnalyzeCommits(repoPath string) ([]CommitInfo, error) {
+	return AnalyzeCommitsWithOptions(repoPath, AnalyzeOptions{
+		Since: DefaultSince(time.Now()),
+	})
+}
+
+// AnalyzeCommitsWithOptions analyzes git commits using the given options and returns commit information.
+func AnalyzeCommitsWithOptions(repoPath string, opts AnalyzeOptions) ([]CommitInfo, error) {
 	var commits []CommitInfo
+	err := AnalyzeCommitsFunc(repoPath, opts, func(commit CommitInfo) error {
+		commits = append(commits, commit)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// AnalyzeCommitsFunc analyzes git commits using the given options, passing each
+// commit to fn as it's read instead of collecting them, e.g. to feed a
+// HotspotAccumulator. It stops at the first error returned by fn.
+func AnalyzeCommitsFunc(repoPath string, opts AnalyzeOptions, fn func(commit CommitInfo) error) error {
+	if opts.RespectGitIgnore {
+		gitIgnore, err := ReadGitIgnore(repoPath)
+		if err != nil {
+			return err
+		}
+		opts.gitIgnore = gitIgnore
+	}
+
+	// Read the commits with the git binary if requested
+	if opts.Backend == BackendGit {
+		return analyzeCommitsWithGit(repoPath, opts, fn)
+	}
 
 	// Open the repository
-	repo, err := git.PlainOpen(repoPath)
+	repo, err := openRepositoryWithCache(repoPath, opts.CacheSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open git repository: %w", err)
+		return fmt.Errorf("failed to open git repository: %w", err)
 	}
+	reindex := reindexFunc(repo)
 
-	// Get the HEAD reference
-	ref, err := repo.Head()
+	// Start from HEAD, or the requested ref
+	from, err := resolveRef(repo, opts.Ref)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+		return err
+	}
+
+	// Make sure the subpath exists before walking the history
+	subpath := cleanSubpath(opts.Path)
+	if subpath != "" {
+		if err := checkPathInHead(repo, from, subpath); err != nil {
+			return err
+		}
+	}
+
+	// Analyze exactly the requested commits if given
+	sampled := opts.sampler()
+	if len(opts.Hashes) > 0 {
+		for _, hash := range opts.Hashes {
+			if !sampled() {
+				continue
+			}
+			resolved, err := repo.ResolveRevision(plumbing.Revision(hash))
+			if err != nil {
+				return fmt.Errorf("failed to resolve commit %q: %w", hash, err)
+			}
+			c, err := repo.CommitObject(*resolved)
+			if err != nil {
+				return fmt.Errorf("failed to get commit %q: %w", hash, err)
+			}
+
+			commitInfo, ok, err := newCommitInfo(c, subpath, opts, reindex)
+			if err != nil {
+				return err
+			}
+			if ok && !opts.skipLargeCommit(commitInfo) {
+				if err := fn(commitInfo); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
 	}
 
 	// Create a new log options
-	since := time.Now().AddDate(-1, 0, 0) // Last year
 	logOptions := &git.LogOptions{
-		From:  ref.Hash(),
+		From:  from,
 		Order: git.LogOrderCommitterTime,
-		Since: &since,
+	}
+	if !opts.Since.IsZero() {
+		logOptions.Since = &opts.Since
 	}
 
-	// Get the commit iterator
-	commitIter, err := repo.Log(logOptions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get commit iterator: %w", err)
+	// Let go-git skip commits that don't touch the subpath
+	if subpath != "" {
+		logOptions.PathFilter = func(file string) bool {
+			_, ok := relativeToSubpath(file, subpath)
+			return ok
+		}
 	}
 
-	// Iterate through the commits
+	// Get the commit iterator, over every branch if requested
+	var commitIter object.CommitIter
+	if opts.AllRefs {
+		refs, err := branchRefs(repo, opts.Remotes)
+		if err != nil {
+			return err
+		}
+		commitIter, err = logAllRefs(repo, refs, logOptions)
+		if err != nil {
+			return fmt.Errorf("failed to get commit iterator: %w", err)
+		}
+	} else {
+		commitIter, err = repo.Log(logOptions)
+		if err != nil {
+			return fmt.Errorf("failed to get commit iterator: %w", err)
+		}
+	}
+
+	// Iterate through the commits, up to the limit if any
+	withinLimit := opts.limiter()
 	err = commitIter.ForEach(func(c *object.Commit) error {
-		// Get the files changed in this commit
-		fileStats, err := getFilesInCommit(c)
+		if !withinLimit() {
+			return storer.ErrStop
+		}
+		if !sampled() {
+			return nil
+		}
+		commitInfo, ok, err := newCommitInfo(c, subpath, opts, reindex)
 		if err != nil {
-			return fmt.Errorf("failed to get files in commit %s: %w", c.Hash.String(), err)
+			return err
+		}
+		if ok && !opts.skipLargeCommit(commitInfo) {
+			return fn(commitInfo)
 		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to iterate through commits: %w", err)
+	}
+
+	return nil
+}
+
+// skipLargeCommit reports whether commit touches more files than allowed,
+// notifying OnLargeCommit if so.
+func (opts AnalyzeOptions) skipLargeCommit(commit CommitInfo) bool {
+	if opts.MaxFilesPerCommit <= 0 || len(commit.Files) <= opts.MaxFilesPerCommit {
+		return false
+	}
+	if opts.OnLargeCommit != nil {
+		opts.OnLargeCommit(commit)
+	}
+	return true
+}
+
+// newCommitInfo builds the CommitInfo for c, with file paths relative to
+// subpath if set. It reports false for root commits if opts.SkipRootCommits
+// is set, for merge commits if opts.MergeStrategy is MergeNone, for commits
+// by authors opts.ExcludeAuthors matches, and for commits that didn't touch
+// anything under the subpath or with one of opts.Extensions: the log's path
+// filter compares each commit with the next one in the log rather than its
+// actual parents, so it can let unrelated commits through. reindex is passed
+// on to getFilesInCommit.
+func newCommitInfo(c *object.Commit, subpath string, opts AnalyzeOptions, reindex func()) (CommitInfo, bool, error) {
+	// Drop root commits, which list every file they add, if requested
+	if opts.SkipRootCommits && c.NumParents() == 0 {
+		return CommitInfo{}, false, nil
+	}
+	if opts.MergeStrategy == MergeNone && c.NumParents() > 1 {
+		return CommitInfo{}, false, nil
+	}
+
+	// Drop the commits of excluded authors, such as bots, before diffing them
+	if opts.ExcludeAuthors.Excludes(c.Author.Name, c.Author.Email) {
+		return CommitInfo{}, false, nil
+	}
+
+	// Get the files changed in this commit
+	fileStats, allActions, issues, err := getFilesInCommit(c, opts.IncludeSubmodules, opts.MergeStrategy, reindex)
+	if err != nil {
+		return CommitInfo{}, false, fmt.Errorf("failed to get files in commit %s: %w", c.Hash.String(), err)
+	}
 
-		var files []string
-		for _, fs := range fileStats {
-			files = append(files, fs)
+	// Count the lines changed in them if requested
+	var allLines map[string]LineChanges
+	if opts.CountLines {
+		allLines, err = commitLineChanges(c, opts.IncludeSubmodules, opts.IgnoreWhitespace)
+		if err != nil {
+			return CommitInfo{}, false, fmt.Errorf("failed to count lines in commit %s: %w", c.Hash.String(), err)
 		}
+	}
+
+	commitInfo, ok := buildCommitInfo(c.Hash.String(), c.Author, c.Message, fileStats, allActions, allLines, subpath, opts)
+	if !ok {
+		return CommitInfo{}, false, nil
+	}
 
-		// Create a CommitInfo object
-		commitInfo := CommitInfo{
-			Hash:    c.Hash.String(),
-			Author:  c.Author.Name,
-			Date:    c.Author.When,
-			Message: c.Message,
-			Files:   files,
+	// Commits skipped for their size aren't analyzed, so their issues don't matter
+	large := opts.MaxFilesPerCommit > 0 && len(commitInfo.Files) > opts.MaxFilesPerCommit
+	if opts.OnCommitIssue != nil && !large {
+		for _, issue := range issues {
+			opts.OnCommitIssue(commitInfo, issue)
 		}
+	}
+	return commitInfo, true, nil
+}
 
-		commits = append(commits, commitInfo)
-		return nil
-	})
+// buildCommitInfo builds the CommitInfo of the commit with the given hash,
+// author and message that changed the given files, with paths relative to
+// subpath if set, keeping the actions and lines of those kept. It reports
+// false if none are under the subpath or have one of opts.Extensions.
+func buildCommitInfo(hash string, author object.Signature, message string, changed []string, allActions map[string]FileAction, allLines map[string]LineChanges, subpath string, opts AnalyzeOptions) (CommitInfo, bool) {
+	var files []string
+	var lines map[string]LineChanges
+	if opts.CountLines {
+		lines = make(map[string]LineChanges)
+	}
+	var actions map[string]FileAction
+	if len(allActions) > 0 {
+		actions = make(map[string]FileAction)
+	}
+	for _, fs := range changed {
+		if !hasExtension(fs, opts.Extensions) || opts.gitIgnore.Ignores(fs) {
+			continue
+		}
+		name := fs
+		if subpath != "" {
+			rel, ok := relativeToSubpath(fs, subpath)
+			if !ok {
+				continue
+			}
+			name = rel
+		}
+		files = append(files, name)
+		if changes, ok := allLines[fs]; ok {
+			lines[name] = changes
+		}
+		if action, ok := allActions[fs]; ok {
+			actions[name] = action
+		}
+	}
+	if (subpath != "" || len(opts.Extensions) > 0 || opts.gitIgnore != nil) && len(files) == 0 {
+		return CommitInfo{}, false
+	}
+
+	coAuthors := ParseCoAuthors(message)
+	for i, coAuthor := range coAuthors {
+		coAuthors[i].Name = opts.Aliases.Canonical(coAuthor.Name, coAuthor.Email)
+	}
+	commitInfo := CommitInfo{
+		Hash:        hash,
+		Author:      opts.Aliases.Canonical(author.Name, author.Email),
+		AuthorEmail: author.Email,
+		Date:        author.When,
+		Message:     message,
+		Files:       files,
+		CoAuthors:   coAuthors,
+		Lines:       lines,
+		Actions:     actions,
+	}
+	return commitInfo, true
+}
+
+// ReadCommitHashes reads commit hashes from r, one per line, as printed by
+// e.g. git rev-list. Blank lines are skipped, and only the first field of
+// each line is used so output like `git rev-list --parents` works too.
+func ReadCommitHashes(r io.Reader) ([]string, error) {
+	var hashes []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 {
+			hashes = append(hashes, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read commit hashes: %w", err)
+	}
+	return hashes, nil
+}
 
+// cleanSubpath normalizes a user-supplied subpath to a slash-separated path
+// without leading "./" or trailing slashes. The repository root becomes "".
+func cleanSubpath(subpath string) string {
+	cleaned := path.Clean(filepath.ToSlash(subpath))
+	cleaned = strings.Trim(cleaned, "/")
+	if cleaned == "." {
+		return ""
+	}
+	return cleaned
+}
+
+// checkPathInHead returns an error if subpath doesn't exist in the tree of the given commit.
+func checkPathInHead(repo *git.Repository, head plumbing.Hash, subpath string) error {
+	commit, err := repo.CommitObject(head)
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
 	if err != nil {
-		return nil, fmt.Errorf("failed to iterate through commits: %w", err)
+		return fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+	if _, err := tree.FindEntry(subpath); err != nil {
+		return fmt.Errorf("path %q does not exist in the repository", subpath)
 	}
+	return nil
+}
 
-	return commits, nil
+// relativeToSubpath returns file relative to subpath, and whether file is under subpath at all.
+// A subpath naming a single file matches only that file.
+func relativeToSubpath(file, subpath string) (string, bool) {
+	if file == subpath {
+		return path.Base(file), true
+	}
+	if strings.HasPrefix(file, subpath+"/") {
+		return strings.TrimPrefix(file, subpath+"/"), true
+	}
+	return "", false
 }
 
 // Hotspot represents a file or directory with its commit count and top contributor.
 type Hotspot struct {
 	Path           string
 	Commits        int
+	Score          float64
 	TopContributor string
 	AuthorCommits  int
+	FirstSeen      time.Time
+	LastModified   time.Time
+	CommitDates    []time.Time   // Dates of the commits, if HotspotOptions.KeepCommits is set
+	CommitAuthors  []string      // Author of each commit in CommitDates
+	History        []CommitRef   // Commits touching the hotspot, in analysis order, if HotspotOptions.KeepCommits is set
+	Activity       []int         // Commits in each span of the window, if HotspotOptions.ActivitySince is set
+	LinesAdded     int           // Lines added, if counted
+	LinesDeleted   int           // Lines deleted, if counted
+	Defects        int           // Commits matching HotspotOptions.DefectPattern
+	Reverts        int           // Commits reverting earlier ones, per IsRevert
+	Deletions      int           // Commits deleting the file, or files deleted under the directory
+	TestFiles      int           // Changes to test files under the directory left out by HotspotOptions.ExcludeTests
+	Contributors   []Contributor // Commits by each author, most first
+	SoleOwned      bool          // Top contributor's share is over HotspotOptions.OwnerThreshold
+	Concentration  float64       // Gini coefficient of Contributors' commits, 1 for a sole author
+	Rank           int           // 1-based position after SortHotspots or SortColdspots, 0 if unsorted
+
+	// TopContributorEmail is the email of TopContributor from their most recent commit.
+	TopContributorEmail string
+
+	// Created is when the file was first added and Deleted when it was last
+	// deleted, unless it was added again since. Either is zero if no such
+	// commit was analyzed. They're always zero for directories.
+	Created time.Time
+	Deleted time.Time
 }
 
-// getFilesInCommit returns a list of files changed in a commit
-func getFilesInCommit(commit *object.Commit) ([]string, error) {
-	var files []string
+// Lifetime returns how long the file has existed: from Created to Deleted,
+// or to now if it still exists. It reports false if Created is unknown.
+func (h Hotspot) Lifetime(now time.Time) (time.Duration, bool) {
+	if h.Created.IsZero() {
+		return 0, false
+	}
+	if !h.Deleted.IsZero() {
+		return h.Deleted.Sub(h.Created), true
+	}
+	return now.Sub(h.Created), true
+}
+
+// CommitRef identifies a commit that touched a hotspot.
+type CommitRef struct {
+	Hash    string
+	Date    time.Time
+	Author  string
+	Subject string // First line of the commit message
+}
+
+// Contributor is an author's share of the commits touching a hotspot.
+type Contributor struct {
+	Author  string
+	Commits int
+}
+
+// newCommitRef returns the reference to commit.
+func newCommitRef(commit CommitInfo) CommitRef {
+	subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	return CommitRef{
+		Hash:    commit.Hash,
+		Date:    commit.Date,
+		Author:  commit.Author,
+		Subject: strings.TrimSpace(subject),
+	}
+}
+
+// HotspotOptions controls how hotspots are scored.
+type HotspotOptions struct {
+	// KeepCommits keeps the date, author and reference of every commit
+	// touching each hotspot in its CommitDates, CommitAuthors and History,
+	// as the UI's detail view, trends, heatmaps and ownership changes need.
+	// Otherwise hotspots only hold counts and their first and last dates,
+	// sparing the memory of long histories.
+	KeepCommits bool
+
+	// ActivitySince, if set, counts the commits to each hotspot in each of
+	// ActivityBuckets equal spans from ActivitySince to Now in its Activity,
+	// for activity sparklines without KeepCommits.
+	ActivitySince   time.Time
+	ActivityBuckets int
+
+	// NormalizeByCommitSize makes each file in a commit contribute
+	// 1/len(commit.Files) to its score instead of a flat 1, so sweeping
+	// commits that touch many files weigh less than focused ones.
+	NormalizeByCommitSize bool
+
+	// MinCommits drops hotspots with fewer commits than this.
+	MinCommits int
+
+	// SkipFiles and SkipDirs skip identifying file or directory hotspots,
+	// for when only the other kind is wanted.
+	SkipFiles bool
+	SkipDirs  bool
+
+	// Extrapolate scales counts up by 1/Extrapolate, the fraction of commits
+	// analyzed with AnalyzeOptions.Sample, to estimate them for all commits.
+	// Zero leaves them as counted.
+	Extrapolate float64
+
+	// ExcludeBursts drops directory hotspots whose commits all fall within
+	// this span of each other, such as a bulk import that never changed
+	// again. Zero keeps them.
+	ExcludeBursts time.Duration
+
+	// MaxIdle keeps only the hotspots idle for at least this long, last
+	// modified at least MaxIdle before Now, to find code that has settled.
+	// ActiveWithin inversely keeps only those modified within this long
+	// before Now. Zero keeps them all.
+	MaxIdle      time.Duration
+	ActiveWithin time.Duration
+
+	// RankBy selects how the score is turned into a ranking. The zero value
+	// ranks by score.
+	RankBy Ranking
+
+	// ScoreExpr, if set, replaces each hotspot's score with the value of this
+	// custom formula, overriding RankBy.
+	ScoreExpr *ScoreExpr
+
+	// Now is the end of the analysis window, used to compute ages when
+	// ranking by RankByHotPerDay or with ScoreExpr and idle times for
+	// MaxIdle and ActiveWithin. The zero value means time.Now().
+	Now time.Time
+
+	// CountCoAuthors credits a commit's co-authors as well as its author
+	// when finding top contributors.
+	CountCoAuthors bool
+
+	// OwnerThreshold, if positive, marks the hotspots whose top contributor
+	// made more than this fraction of the commits, such as 0.8, as
+	// SoleOwned: knowledge silos at risk if that author leaves.
+	OwnerThreshold float64
+
+	// TieBreak decides which of the authors tied for the most commits to a
+	// hotspot is its top contributor, and how tied contributors are ordered.
+	// The zero value is TieBreakName.
+	TieBreak TieBreak
+
+	// IgnoreDeletions leaves the files a commit deleted, or moved away from,
+	// out of it, so removing code doesn't count toward scores, commits or
+	// contributors, and commits that only deleted files are skipped. Deleted
+	// files then have no Deleted date and Deletions is always zero.
+	IgnoreDeletions bool
+
+	// ExcludeTests, if set, leaves the test files it matches out of commits,
+	// so they have no file hotspots and don't count toward directories, and
+	// commits that only changed tests are skipped. Each directory's changes
+	// to test files are counted in its TestFiles instead.
+	ExcludeTests *TestPatterns
+
+	// CaseInsensitivePaths accumulates paths differing only in case, such as
+	// File.go and file.go from a history made on a case-insensitive
+	// filesystem, as one hotspot, shown with its spelling in its latest
+	// commit. Component names are kept as they are.
+	CaseInsensitivePaths bool
+
+	// FirstCommitAsCreation takes the first commit touching a file as its
+	// creation if the commit adding it wasn't analyzed, such as when it
+	// predates the window, so every file hotspot has a Created date and
+	// lifetime. Lifetimes are then a lower bound for files older than that.
+	FirstCommitAsCreation bool
+
+	// DirSizes, if set, ranks directories by their score per file under them,
+	// per CountDirFiles, so small directories that change a lot rise to the
+	// top. Directories without any files, such as deleted ones, score 0.
+	DirSizes map[string]int
+
+	// FileLines, if set, ranks files by their churn per line in HEAD, per
+	// HeadTree.CountLines, when ranking by RankByChurn, so files that change
+	// a lot for their size rise to the top. Files without lines, such as
+	// deleted, empty or binary ones, score 0. Directories keep their churn.
+	FileLines map[string]int
+
+	// Components, if set, groups files by component instead of directory,
+	// so the directory hotspots are component hotspots.
+	Components *Components
+
+	// RootLabel, if set, groups the files in the root directory into a
+	// directory hotspot with this path, such as "<root>", so churn in files
+	// like go.mod and Makefile shows up among directories. Otherwise they
+	// have none. Components group root files their own way.
+	RootLabel string
+
+	// TagWeights, if set, weights each commit by the tag of its message
+	// when ranking by RankByWeighted.
+	TagWeights *TagWeights
+
+	// DefectPattern, if set, counts the commits whose message matches it,
+	// such as bug fixes referencing an issue, as defects of each hotspot.
+	DefectPattern *regexp.Regexp
+}
+
+// TieBreak selects how contributors with as many commits to a hotspot as
+// each other are ordered.
+type TieBreak string
+
+const (
+	// TieBreakName orders tied contributors by name, so the first
+	// alphabetically is the top contributor.
+	TieBreakName TieBreak = "name"
+
+	// TieBreakRecent orders tied contributors by the date of their latest
+	// commit to the hotspot, so the most recently active one is the top
+	// contributor, and by name if that's tied too.
+	TieBreakRecent TieBreak = "recent"
+)
+
+// Ranking selects how hotspots are ranked.
+type Ranking string
+
+const (
+	// RankByScore ranks hotspots by their score.
+	RankByScore Ranking = "score"
+
+	// RankByHotPerDay ranks hotspots by their score per day since they were
+	// first seen in the window, so new files that change a lot rise to the top.
+	RankByHotPerDay Ranking = "hot-per-day"
+
+	// RankByChurn ranks hotspots by the number of lines added and deleted.
+	// Lines must be counted with AnalyzeOptions.CountLines.
+	RankByChurn Ranking = "churn"
+
+	// RankByWeighted ranks hotspots by their score with each commit weighted
+	// by the tag of its message, per HotspotOptions.TagWeights, so that e.g.
+	// bug fixes count more than features.
+	RankByWeighted Ranking = "weighted"
+
+	// RankByReverts ranks hotspots by the number of commits reverting earlier
+	// ones, per IsRevert, as a sign of unstable code.
+	RankByReverts Ranking = "reverts"
+
+	// RankByConcentration ranks hotspots by how concentrated their commits
+	// are among their contributors, per Hotspot.Concentration, so those
+	// dominated by one author, a low bus factor, rise to the top.
+	RankByConcentration Ranking = "concentration"
+)
+
+// hotPerDay divides a hotspot's score by its age in days, counting anything
+// younger than a day as one day old.
+func hotPerDay(h Hotspot, now time.Time) float64 {
+	days := now.Sub(h.FirstSeen).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+	return h.Score / days
+}
+
+// fileSet collects file paths in insertion order, ignoring duplicates.
+type fileSet struct {
+	files   []string
+	seen    map[string]bool
+	actions map[string]FileAction // Files added or deleted
+}
+
+func newFileSet() *fileSet {
+	return &fileSet{seen: make(map[string]bool), actions: make(map[string]FileAction)}
+}
+
+// add adds name to the set unless it's empty or already present.
+func (s *fileSet) add(name string) {
+	if name == "" || s.seen[name] {
+		return
+	}
+	s.files = append(s.files, name)
+	s.seen[name] = true
+}
+
+// isCountedEntry reports whether a tree entry with the given mode counts as a
+// file. Symlinks aren't, and submodules only if includeSubmodules is set.
+func isCountedEntry(mode filemode.FileMode, includeSubmodules bool) bool {
+	switch mode {
+	case filemode.Symlink:
+		return false
+	case filemode.Submodule:
+		return includeSubmodules
+	default:
+		return true
+	}
+}
+
+// addTreeFiles adds every file in tree to files, for commits without a
+// parent to diff against.
+func addTreeFiles(files *fileSet, tree *object.Tree, includeSubmodules bool) error {
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if entry.Mode != filemode.Dir && isCountedEntry(entry.Mode, includeSubmodules) {
+			files.add(name)
+		}
+	}
+}
+
+// getFilesInCommit returns the deduplicated list of files changed in a commit,
+// skipping symlinks and, unless includeSubmodules is set, submodules. Paths
+// are the raw bytes of the tree entries, never C-quoted like git's output and
+// not necessarily UTF-8, so they can be looked up again; formatters escape
+// them for display with report.QuotePath. It also returns the issues that
+// kept the commit from being fully read, each at most once, and the files it
+// added or deleted compared with its first parent; a root commit adds every
+// file. Merge commits list the files changed compared with any parent, or
+// only the first with MergeFirstParent. reindex, if set, reloads the object
+// store when a parent can't be found, per parentTree.
+func getFilesInCommit(commit *object.Commit, includeSubmodules bool, strategy MergeStrategy, reindex func()) ([]string, map[string]FileAction, []CommitIssue, error) {
+	files := newFileSet()
+	var issues []CommitIssue
 
 	// Get the commit tree
 	tree, err := commit.Tree()
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// Check if this commit has parents
-	parents := commit.Parents()
+	// Check if this commit has parents, diffing merges against the first
+	// one only if requested
 	parentsCount := commit.NumParents()
+	if strategy == MergeFirstParent && parentsCount > 1 {
+		parentsCount = 1
+	}
 
 	if parentsCount == 0 {
 		// If this is the first commit (no parents), list all files in the tree
-		err = tree.Files().ForEach(func(f *object.File) error {
-			files = append(files, f.Name)
-			return nil
-		})
-		if err != nil {
-			return nil, err
+		if err := addTreeFiles(files, tree, includeSubmodules); err != nil {
+			return nil, nil, nil, err
+		}
+		for _, name := range files.files {
+			files.actions[name] = FileAdded
 		}
 	} else {
-		// For each parent, get the changes
-		seenFiles := make(map[string]bool)
+		// Count changes to skipped entries, so a commit that only bumps a
+		// submodule doesn't fall back to listing the whole tree
+		skipped := 0
+		unreadable := false
 		
-		// Close the parents iterator when done
-		defer parents.Close()
-		
-		// Iterate through all parents
-		for {
-			parent, err := parents.Next()
-			if err == plumbing.ErrObjectNotFound {
-				// Skip this parent if not found
-				continue
-			} else if err != nil {
-				// End of parents or other error
-				break
-			}
-			
-			// Get parent tree
-			parentTree, err := parent.Tree()
+		// Iterate through all parents, taking the union of their changes.
+		// Parents that can't be read even after reindexing, such as those
+		// missing from a shallow clone, are skipped and reported
+		for i := 0; i < parentsCount; i++ {
+			parentTree, err := parentTree(commit, i, reindex)
 			if err != nil {
-				continue // Skip this parent if we can't get its tree
+				unreadable = true
+				continue
 			}
 			
 			// Get changes between parent and this commit
 			changes, err := tree.Diff(parentTree)
 			if err != nil {
-				continue // Skip this parent if we can't get changes
+				unreadable = true
+				continue
 			}
 			
 			// Extract file paths from changes
@@ -157,116 +970,185 @@ func getFilesInCommit(commit *object.Commit) ([]string, error) {
 					continue
 				}
 				
+				// Classify the entry by its mode after the change, or before
+				// it for deletions
+				mode := change.To.TreeEntry.Mode
+				if change.To.Name == "" {
+					mode = change.From.TreeEntry.Mode
+				}
+				if !isCountedEntry(mode, includeSubmodules) {
+					skipped++
+					continue
+				}
+
 				// Only include files that were added, modified, or deleted
 				if action == merkletrie.Insert || action == merkletrie.Modify || action == merkletrie.Delete {
-					if change.From.Name != "" && !seenFiles[change.From.Name] {
-						files = append(files, change.From.Name)
-						seenFiles[change.From.Name] = true
-					} else if change.To.Name != "" && !seenFiles[change.To.Name] {
-						files = append(files, change.To.Name)
-						seenFiles[change.To.Name] = true
+					if change.From.Name != "" {
+						files.add(change.From.Name)
+					} else {
+						files.add(change.To.Name)
 					}
 				}
+
+				// The diff goes from this commit to its parent, so files the
+				// commit added are deletions in it and vice versa. Whether a
+				// merge added or deleted a file depends on the parent, so only
+				// the first parent's line of history counts
+				if i == 0 && action == merkletrie.Delete {
+					files.actions[change.From.Name] = FileAdded
+				} else if i == 0 && action == merkletrie.Insert {
+					files.actions[change.To.Name] = FileDeleted
+				}
 			}
 		}
 		
+		if unreadable {
+			issues = append(issues, UnreadableParent)
+		}
+
 		// If we couldn't get any files from parents, try to list all files in the tree
-		if len(files) == 0 {
-			err = tree.Files().ForEach(func(f *object.File) error {
-				files = append(files, f.Name)
-				return nil
-			})
-			if err != nil {
-				return nil, err
+		if len(files.files) == 0 && skipped == 0 {
+			issues = append(issues, WholeTree)
+			if err := addTreeFiles(files, tree, includeSubmodules); err != nil {
+				return nil, nil, nil, err
 			}
 		}
 	}
 
-	return files, nil
+	if len(files.files) == 0 {
+		issues = append(issues, NoFiles)
+	}
+	return files.files, files.actions, issues, nil
 }
 
 // IdentifyHotspots identifies hotspot files and directories.
 func IdentifyHotspots(commits []CommitInfo) ([]Hotspot, []Hotspot) {
-	fileCommits := make(map[string]int)
-	dirCommits := make(map[string]int)
-	fileAuthors := make(map[string]map[string]int) // file -> author -> commit count
-	dirAuthors := make(map[string]map[string]int)  // dir -> author -> commit count
+	return IdentifyHotspotsWithOptions(commits, HotspotOptions{})
+}
 
-	// Initialize maps
+// IdentifyHotspotsWithOptions identifies hotspot files and directories using the given options.
+func IdentifyHotspotsWithOptions(commits []CommitInfo, opts HotspotOptions) ([]Hotspot, []Hotspot) {
+	acc := NewHotspotAccumulatorWithOptions(opts)
 	for _, commit := range commits {
-		author := commit.Author
-		for _, file := range commit.Files {
-			// Track file commits
-			fileCommits[file]++
-			
-			// Track file authors
-			if _, ok := fileAuthors[file]; !ok {
-				fileAuthors[file] = make(map[string]int)
-			}
-			fileAuthors[file][author]++
-			
-			// Track directory commits
-			dir := filepath.Dir(file)
-			if dir != "." {
-				dirCommits[dir]++
-				
-				// Track directory authors
-				if _, ok := dirAuthors[dir]; !ok {
-					dirAuthors[dir] = make(map[string]int)
-				}
-				dirAuthors[dir][author]++
-			}
+		acc.Add(commit)
+	}
+	return acc.Result()
+}
+
+// filterMinCommits returns the hotspots with at least minCommits commits.
+func filterMinCommits(hotspots []Hotspot, minCommits int) []Hotspot {
+	var filtered []Hotspot
+	for _, h := range hotspots {
+		if h.Commits >= minCommits {
+			filtered = append(filtered, h)
 		}
 	}
+	return filtered
+}
 
-	// Create file hotspots with top contributor information
-	var fileHotspots []Hotspot
-	for path, count := range fileCommits {
-		topContributor := ""
-		topContributions := 0
-		
-		// Find top contributor for this file
-		for author, authorCommits := range fileAuthors[path] {
-			if authorCommits > topContributions {
-				topContributor = author
-				topContributions = authorCommits
-			}
+// IsBurst reports whether all of h's commits fall within span of each other.
+func IsBurst(h Hotspot, span time.Duration) bool {
+	return h.LastModified.Sub(h.FirstSeen) <= span
+}
+
+// filterBursts returns the hotspots that aren't bursts within span.
+func filterBursts(hotspots []Hotspot, span time.Duration) []Hotspot {
+	var filtered []Hotspot
+	for _, h := range hotspots {
+		if !IsBurst(h, span) {
+			filtered = append(filtered, h)
 		}
-		
-		fileHotspots = append(fileHotspots, Hotspot{
-			Path:           path,
-			Commits:        count,
-			TopContributor: topContributor,
-			AuthorCommits:  topContributions,
-		})
-	}
-
-	// Create directory hotspots with top contributor information
-	var dirHotspots []Hotspot
-	for path, count := range dirCommits {
-		topContributor := ""
-		topContributions := 0
-		
-		// Find top contributor for this directory
-		for author, authorCommits := range dirAuthors[path] {
-			if authorCommits > topContributions {
-				topContributor = author
-				topContributions = authorCommits
-			}
+	}
+	return filtered
+}
+
+// filterIdle returns the hotspots last modified at least minIdle and less
+// than activeWithin before now. A zero duration disables that bound.
+func filterIdle(hotspots []Hotspot, now time.Time, minIdle, activeWithin time.Duration) []Hotspot {
+	var filtered []Hotspot
+	for _, h := range hotspots {
+		idle := now.Sub(h.LastModified)
+		if minIdle > 0 && idle < minIdle {
+			continue
+		}
+		if activeWithin > 0 && idle >= activeWithin {
+			continue
+		}
+		filtered = append(filtered, h)
+	}
+	return filtered
+}
+
+// Thresholds are the limits a hotspot must stay within, e.g. to gate CI.
+// A zero value disables the corresponding check.
+type Thresholds struct {
+	Commits int
+	Score   float64
+}
+
+// Exceeding returns the hotspots with more commits or a higher score than allowed.
+func (t Thresholds) Exceeding(hotspots []Hotspot) []Hotspot {
+	var exceeding []Hotspot
+	for _, h := range hotspots {
+		if (t.Commits > 0 && h.Commits > t.Commits) || (t.Score > 0 && h.Score > t.Score) {
+			exceeding = append(exceeding, h)
 		}
-		
-		dirHotspots = append(dirHotspots, Hotspot{
-			Path:           path,
-			Commits:        count,
-			TopContributor: topContributor,
-			AuthorCommits:  topContributions,
-		})
 	}
+	return exceeding
+}
 
-	// Sort hotspots by commit count in descending order
-	// (Sorting will be done in a separate utility function or later in UI)
+// SortHotspots sorts hotspots by score in descending order. Ties are broken
+// by commits, most first, and then by path, so the order is deterministic.
+func SortHotspots(hotspots []Hotspot) {
+	SortHotspotsBy(hotspots, func(h Hotspot) float64 {
+		return h.Score
+	})
+}
+
+// SortHotspotsBy sorts hotspots by key in descending order, breaking ties
+// like SortHotspots, and sets their Rank.
+func SortHotspotsBy(hotspots []Hotspot, key func(h Hotspot) float64) {
+	sort.Slice(hotspots, func(i, j int) bool {
+		if ki, kj := key(hotspots[i]), key(hotspots[j]); ki != kj {
+			return ki > kj
+		}
+		if hotspots[i].Commits != hotspots[j].Commits {
+			return hotspots[i].Commits > hotspots[j].Commits
+		}
+		return hotspots[i].Path < hotspots[j].Path
+	})
+	setRanks(hotspots)
+}
 
-	return fileHotspots, dirHotspots
+// setRanks numbers sorted hotspots from 1.
+func setRanks(hotspots []Hotspot) {
+	for i := range hotspots {
+		hotspots[i].Rank = i + 1
+	}
 }
 
+// BucketCommitDates counts the dates falling into each of the given number of
+// equally sized buckets between start and end.
+func BucketCommitDates(dates []time.Time, start, end time.Time, buckets int) []int {
+	counts := make([]int, buckets)
+	for _, date := range dates {
+		if i := bucketIndex(date, start, end, buckets); i >= 0 {
+			counts[i]++
+		}
+	}
+	return counts
+}
 
+// bucketIndex returns the index of the bucket of date among buckets equal
+// spans from start to end, or -1 if it's outside them.
+func bucketIndex(date, start, end time.Time, buckets int) int {
+	span := end.Sub(start)
+	if buckets <= 0 || span <= 0 || date.Before(start) || date.After(end) {
+		return -1
+	}
+	i := int(float64(date.Sub(start)) / float64(span) * float64(buckets))
+	if i >= buckets {
+		i = buckets - 1 // A date exactly at end belongs to the last bucket
+	}
+	return i
+}
@@ -1,95 +1,29 @@
 package git
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"git-hotspots/internal/testutil"
+
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
-// setupTestRepo creates a temporary git repository for testing.
-func setupTestRepo(t *testing.T) string {
-	// Create a temporary directory
-	tmpDir, err := ioutil.TempDir("", "git-test-")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-
-	// Initialize a git repository
-	_, err = git.PlainInit(tmpDir, false)
-	if err != nil {
-		t.Fatalf("Failed to init git repo: %v", err)
-	}
-
-	// We'll set the user config in the createCommit function instead
-	// as we don't need global config for our tests
-
-	return tmpDir
-}
-
-// createCommit creates a commit with the given files and message.
-func createCommit(t *testing.T, repoPath string, files []string, message string, commitTime time.Time) {
-	// Open the repository
-	repo, err := git.PlainOpen(repoPath)
-	if err != nil {
-		t.Fatalf("Failed to open repository: %v", err)
-	}
-
-	// Get the worktree
-	wt, err := repo.Worktree()
-	if err != nil {
-		t.Fatalf("Failed to get worktree: %v", err)
-	}
-
-	// Create and add files
-	for _, file := range files {
-		filePath := filepath.Join(repoPath, file)
-		dir := filepath.Dir(filePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			t.Fatalf("Failed to create dir %s: %v", dir, err)
-		}
-		if err := ioutil.WriteFile(filePath, []byte("test content"), 0644); err != nil {
-			t.Fatalf("Failed to write file %s: %v", filePath, err)
-		}
-		
-		// Add the file to the staging area
-		_, err = wt.Add(file)
-		if err != nil {
-			t.Fatalf("Failed to add file %s: %v", file, err)
-		}
-	}
-
-	// Create commit with the specified time
-	commit, err := wt.Commit(message, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "Test User",
-			Email: "test@example.com",
-			When:  commitTime,
-		},
-		Committer: &object.Signature{
-			Name:  "Test User",
-			Email: "test@example.com",
-			When:  commitTime,
-		},
-	})
-	if err != nil {
-		t.Fatalf("Failed to commit: %v", err)
-	}
-
-	// Verify the commit was created
-	_, err = repo.CommitObject(commit)
-	if err != nil {
-		t.Fatalf("Failed to get commit object: %v", err)
-	}
-}
-
 func TestIsGitRepository(t *testing.T) {
-	tmpDir := setupTestRepo(t)
+	tmpDir := testutil.NewRepo(t)
 	defer os.RemoveAll(tmpDir)
 
 	if !IsGitRepository(tmpDir) {
@@ -105,16 +39,22 @@ func TestIsGitRepository(t *testing.T) {
 	if IsGitRepository(nonGitDir) {
 		t.Errorf("Expected %s not to be a git repository, but it is", nonGitDir)
 	}
+
+	// A missing path isn't part of the repository it would be in
+	missing := filepath.Join(tmpDir, "does", "not", "exist")
+	if IsGitRepository(missing) {
+		t.Errorf("Expected missing %s not to be a git repository, but it is", missing)
+	}
 }
 
 func TestAnalyzeCommits(t *testing.T) {
-	tmpDir := setupTestRepo(t)
+	tmpDir := testutil.NewRepo(t)
 	defer os.RemoveAll(tmpDir)
 
 	now := time.Now()
-	createCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", now.Add(-24*time.Hour))
-	createCommit(t, tmpDir, []string{"file1.txt", "file2.txt"}, "Add file2", now.Add(-12*time.Hour))
-	createCommit(t, tmpDir, []string{"dir1/file3.txt"}, "Add file3 in dir1", now.Add(-6*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt", "file2.txt"}, "Add file2", now.Add(-12*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"dir1/file3.txt"}, "Add file3 in dir1", now.Add(-6*time.Hour))
 
 	commits, err := AnalyzeCommits(tmpDir)
 	if err != nil {
@@ -136,7 +76,7 @@ func TestAnalyzeCommits(t *testing.T) {
 
 	// Test --since=1 year filter
 	oldCommitTime := now.Add(-366 * 24 * time.Hour) // More than 1 year ago
-	createCommit(t, tmpDir, []string{"old_file.txt"}, "Old commit", oldCommitTime)
+	testutil.CreateCommit(t, tmpDir, []string{"old_file.txt"}, "Old commit", oldCommitTime)
 
 	commitsAfterOld, err := AnalyzeCommits(tmpDir)
 	if err != nil {
@@ -239,4 +179,1734 @@ func TestIdentifyHotspots(t *testing.T) {
 	}
 }
 
+func TestIdentifyHotspotsNormalizeByCommitSize(t *testing.T) {
+	// One broad commit touching many files and several focused ones
+	broad := CommitInfo{Hash: "broad", Author: "Test User", Date: time.Now()}
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go", "e.go", "f.go", "g.go", "h.go", "i.go", "j.go"} {
+		broad.Files = append(broad.Files, "pkg/"+name)
+	}
+	commits := []CommitInfo{
+		broad,
+		{Hash: "focused1", Author: "Test User", Date: time.Now(), Files: []string{"core.go"}},
+		{Hash: "focused2", Author: "Test User", Date: time.Now(), Files: []string{"core.go"}},
+	}
+
+	// Without normalization every file scores one point per commit
+	fileHotspots, _ := IdentifyHotspots(commits)
+	for _, h := range fileHotspots {
+		if h.Score != float64(h.Commits) {
+			t.Errorf("Expected %s to score %d without normalization, got %.2f", h.Path, h.Commits, h.Score)
+		}
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, HotspotOptions{NormalizeByCommitSize: true})
+	SortHotspots(fileHotspots)
+
+	// The focused file outranks every file of the broad commit
+	if fileHotspots[0].Path != "core.go" || fileHotspots[0].Score != 2 {
+		t.Errorf("Expected core.go to rank first with score 2, got %s with %.2f", fileHotspots[0].Path, fileHotspots[0].Score)
+	}
+	for _, h := range fileHotspots[1:] {
+		if h.Score != 0.1 {
+			t.Errorf("Expected %s to score 0.1, got %.2f", h.Path, h.Score)
+		}
+		if h.Commits != 1 {
+			t.Errorf("Expected %s to keep a commit count of 1, got %d", h.Path, h.Commits)
+		}
+	}
+
+	// The broad commit contributes at most 1 to its directory
+	if len(dirHotspots) != 1 || dirHotspots[0].Score < 0.999 || dirHotspots[0].Score > 1.001 {
+		t.Errorf("Expected pkg to score 1, got %v", dirHotspots)
+	}
+}
+
+func TestIdentifyHotspotsLastModified(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.Add(-48 * time.Hour), Files: []string{"dir1/fileA.txt", "dir1/fileB.txt"}},
+		{Hash: "hash2", Author: "Test User", Date: now.Add(-24 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash3", Author: "Test User", Date: now.Add(-72 * time.Hour), Files: []string{"dir1/fileB.txt"}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspots(commits)
+
+	fileMap := make(map[string]Hotspot)
+	for _, h := range fileHotspots {
+		fileMap[h.Path] = h
+	}
+
+	// Commits aren't in date order, so the latest one must win
+	if !fileMap["dir1/fileA.txt"].LastModified.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("Expected dir1/fileA.txt last modified 24h ago, got %v", fileMap["dir1/fileA.txt"].LastModified)
+	}
+	if !fileMap["dir1/fileB.txt"].LastModified.Equal(now.Add(-48 * time.Hour)) {
+		t.Errorf("Expected dir1/fileB.txt last modified 48h ago, got %v", fileMap["dir1/fileB.txt"].LastModified)
+	}
+	if len(dirHotspots) != 1 || !dirHotspots[0].LastModified.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("Expected dir1 last modified 24h ago, got %v", dirHotspots)
+	}
+}
+
+func TestIdentifyHotspotsFirstSeen(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.Add(-48 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash2", Author: "Test User", Date: now.Add(-72 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash3", Author: "Test User", Date: now.Add(-96 * time.Hour), Files: []string{"dir1/fileB.txt"}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspots(commits)
+
+	fileMap := make(map[string]Hotspot)
+	for _, h := range fileHotspots {
+		fileMap[h.Path] = h
+	}
+
+	if !fileMap["dir1/fileA.txt"].FirstSeen.Equal(now.Add(-72 * time.Hour)) {
+		t.Errorf("Expected dir1/fileA.txt first seen 72h ago, got %v", fileMap["dir1/fileA.txt"].FirstSeen)
+	}
+	if !fileMap["dir1/fileB.txt"].FirstSeen.Equal(now.Add(-96 * time.Hour)) {
+		t.Errorf("Expected dir1/fileB.txt first seen 96h ago, got %v", fileMap["dir1/fileB.txt"].FirstSeen)
+	}
+	if len(dirHotspots) != 1 || !dirHotspots[0].FirstSeen.Equal(now.Add(-96*time.Hour)) {
+		t.Errorf("Expected dir1 first seen 96h ago, got %v", dirHotspots)
+	}
+}
+
+func TestBucketCommitDates(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(4 * 7 * 24 * time.Hour) // Four weekly buckets
+
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: start.Add(24 * time.Hour), Files: []string{"fileA.txt"}},
+		{Hash: "hash2", Author: "Test User", Date: start.Add(48 * time.Hour), Files: []string{"fileA.txt"}},
+		{Hash: "hash3", Author: "Test User", Date: start.Add(15 * 24 * time.Hour), Files: []string{"fileA.txt"}},
+		{Hash: "hash4", Author: "Test User", Date: end, Files: []string{"fileA.txt"}},
+		{Hash: "hash5", Author: "Test User", Date: start.Add(-time.Hour), Files: []string{"fileA.txt"}},
+	}
+
+	fileHotspots, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{KeepCommits: true})
+	if len(fileHotspots[0].CommitDates) != 5 {
+		t.Fatalf("Expected 5 retained commit dates, got %d", len(fileHotspots[0].CommitDates))
+	}
+
+	counts := BucketCommitDates(fileHotspots[0].CommitDates, start, end, 4)
+	expected := []int{2, 0, 1, 1}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("Expected buckets %v, got %v", expected, counts)
+			break
+		}
+	}
+
+	// Without KeepCommits the dates aren't retained, but the same buckets
+	// are counted as commits are added
+	fileHotspots, _ = IdentifyHotspotsWithOptions(commits, HotspotOptions{Now: end, ActivitySince: start, ActivityBuckets: 4})
+	if fileHotspots[0].CommitDates != nil || fileHotspots[0].CommitAuthors != nil || fileHotspots[0].History != nil {
+		t.Errorf("Expected no commits retained, got %+v", fileHotspots[0])
+	}
+	if !reflect.DeepEqual(fileHotspots[0].Activity, expected) {
+		t.Errorf("Expected activity %v, got %v", expected, fileHotspots[0].Activity)
+	}
+}
+
+func TestAnalyzeCommitsWithOptionsSince(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"old_file.txt"}, "Old commit", now.Add(-400*24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Recent commit", now.Add(-60*24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file2.txt"}, "Latest commit", now.Add(-24*time.Hour))
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		expected int
+	}{
+		{"full history", time.Time{}, 3},
+		{"last year", DefaultSince(now), 2},
+		{"last 30 days", now.AddDate(0, 0, -30), 1},
+	}
+
+	for _, tt := range tests {
+		commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Since: tt.since})
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions failed for %s: %v", tt.name, err)
+		}
+		if len(commits) != tt.expected {
+			t.Errorf("Expected %d commits for %s, got %d", tt.expected, tt.name, len(commits))
+		}
+	}
+}
+
+func TestAnalyzeCommitsFromSubdirectory(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/pkg/file1.txt"}, "Add file1", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file2.txt"}, "Add file2", now.Add(-12*time.Hour))
+
+	nested := filepath.Join(tmpDir, "src", "pkg")
+	if !IsGitRepository(nested) {
+		t.Errorf("Expected %s to be inside a git repository", nested)
+	}
+
+	root, err := RepositoryRoot(nested)
+	if err != nil {
+		t.Fatalf("RepositoryRoot failed: %v", err)
+	}
+	expectedRoot, _ := filepath.EvalSymlinks(tmpDir)
+	if actualRoot, _ := filepath.EvalSymlinks(root); actualRoot != expectedRoot {
+		t.Errorf("Expected repository root %s, got %s", expectedRoot, actualRoot)
+	}
+
+	// Analysis from a nested path covers the whole repository
+	commits, err := AnalyzeCommits(nested)
+	if err != nil {
+		t.Fatalf("AnalyzeCommits failed from a subdirectory: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Errorf("Expected 2 commits, got %d", len(commits))
+	}
+}
+
+func TestAnalyzeCommitsWithGitDir(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Add file1", time.Now().Add(-24*time.Hour))
+
+	// GIT_DIR takes precedence over the given path
+	otherDir := t.TempDir()
+	t.Setenv("GIT_DIR", filepath.Join(tmpDir, ".git"))
+
+	if !IsGitRepository(otherDir) {
+		t.Errorf("Expected GIT_DIR to be used for %s", otherDir)
+	}
+	commits, err := AnalyzeCommits(otherDir)
+	if err != nil {
+		t.Fatalf("AnalyzeCommits failed with GIT_DIR: %v", err)
+	}
+	if len(commits) != 1 {
+		t.Errorf("Expected 1 commit, got %d", len(commits))
+	}
+}
+
+func TestAnalyzeCommitsWithSubpath(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/server/api/handler.go", "src/client/app.js"}, "Initial commit", now.Add(-48*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/client/style.css"}, "Style client", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/server/main.go", "README.md"}, "Add server main", now.Add(-12*time.Hour))
+
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Path: "./src/server/"})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+
+	// The client-only commit is skipped and paths are relative to the subpath
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits touching src/server, got %d", len(commits))
+	}
+	if len(commits[0].Files) != 1 || commits[0].Files[0] != "main.go" {
+		t.Errorf("Expected latest commit to affect main.go, got %v", commits[0].Files)
+	}
+	if len(commits[1].Files) != 1 || commits[1].Files[0] != "api/handler.go" {
+		t.Errorf("Expected initial commit to affect api/handler.go, got %v", commits[1].Files)
+	}
+
+	// Directory hotspots are reported relative to the subpath root
+	_, dirHotspots := IdentifyHotspots(commits)
+	if len(dirHotspots) != 1 || dirHotspots[0].Path != "api" {
+		t.Errorf("Expected a single 'api' directory hotspot, got %v", dirHotspots)
+	}
+
+	// A subpath that doesn't exist is an error
+	_, err = AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Path: "src/missing"})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected an error for a missing subpath, got %v", err)
+	}
+}
+
+func TestAnalyzeCommitsWithFileSubpath(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"docs/guide.md", "src/main.go"}, "Initial commit", now.Add(-48*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/util.go"}, "Add util", now.Add(-24*time.Hour))
+
+	// Only commits touching the file are yielded, and only that file is listed
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Path: "docs/guide.md"})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 commit touching docs/guide.md, got %d", len(commits))
+	}
+	if len(commits[0].Files) != 1 || commits[0].Files[0] != "guide.md" {
+		t.Errorf("Expected the commit to list only guide.md, got %v", commits[0].Files)
+	}
+}
+
+func TestIdentifyHotspotsDirectoryCommitsDeduplicated(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"dir1/fileA.txt", "dir1/fileB.txt"}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, HotspotOptions{KeepCommits: true})
+
+	if len(fileHotspots) != 2 {
+		t.Errorf("Expected 2 file hotspots, got %d", len(fileHotspots))
+	}
+
+	// One commit touching two files in the same directory counts once
+	if len(dirHotspots) != 1 {
+		t.Fatalf("Expected 1 directory hotspot, got %d", len(dirHotspots))
+	}
+	dir1 := dirHotspots[0]
+	if dir1.Commits != 1 || dir1.Score != 1 || dir1.AuthorCommits != 1 {
+		t.Errorf("Expected dir1 to have 1 commit, score 1 and 1 author commit, got %d, %.2f and %d",
+			dir1.Commits, dir1.Score, dir1.AuthorCommits)
+	}
+	if len(dir1.CommitDates) != 1 {
+		t.Errorf("Expected dir1 to retain 1 commit date, got %d", len(dir1.CommitDates))
+	}
+}
+
+// commitContent writes content to file and commits it with the given parents.
+func commitContent(t *testing.T, repoPath, file, content string, parents []plumbing.Hash) plumbing.Hash {
+	t.Helper()
+	return testutil.Commit(t, repoPath, testutil.Change{
+		Message: "Update " + file,
+		Write:   map[string]string{file: content},
+		Parents: parents,
+	})
+}
+
+func TestGetFilesInCommitDeduplicatesMergeChanges(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	// Two branches modify the same file, and the merge changes it again,
+	// so the diff against each parent lists the file
+	base := commitContent(t, tmpDir, "shared.txt", "base", nil)
+	left := commitContent(t, tmpDir, "shared.txt", "left", []plumbing.Hash{base})
+	right := commitContent(t, tmpDir, "shared.txt", "right", []plumbing.Hash{base})
+	merge := commitContent(t, tmpDir, "shared.txt", "merged", []plumbing.Hash{left, right})
+
+	commit, err := repo.CommitObject(merge)
+	if err != nil {
+		t.Fatalf("Failed to get merge commit: %v", err)
+	}
+
+	files, _, issues, err := getFilesInCommit(commit, false, MergeUnion, nil)
+	if err != nil {
+		t.Fatalf("getFilesInCommit failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "shared.txt" {
+		t.Errorf("Expected shared.txt to be listed once, got %v", files)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestAnalyzeCommitsLimitCommits(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"old.go"}, "Old", now.Add(-3*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"mid.go"}, "Mid", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"new.go"}, "New", now.Add(-time.Hour))
+
+	backends := []Backend{BackendGoGit}
+	if _, err := exec.LookPath("git"); err == nil {
+		backends = append(backends, BackendGit)
+	}
+	for _, backend := range backends {
+		for _, tt := range []struct {
+			limit     int
+			since     time.Time
+			expected  []string
+			truncated bool
+		}{
+			{2, time.Time{}, []string{"mid.go", "new.go"}, true},
+			{3, time.Time{}, []string{"mid.go", "new.go", "old.go"}, false},
+			{0, time.Time{}, []string{"mid.go", "new.go", "old.go"}, false},
+
+			// Whichever of the limit and the window is reached first ends the walk
+			{2, now.Add(-90 * time.Minute), []string{"new.go"}, false},
+		} {
+			truncated := false
+			opts := AnalyzeOptions{Backend: backend, LimitCommits: tt.limit, Since: tt.since, OnLimitReached: func() { truncated = true }}
+			commits, err := AnalyzeCommitsWithOptions(tmpDir, opts)
+			if err != nil {
+				t.Fatalf("AnalyzeCommitsWithOptions failed with %s and limit %d: %v", backend, tt.limit, err)
+			}
+			var files []string
+			for _, commit := range commits {
+				files = append(files, commit.Files...)
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tt.expected) || truncated != tt.truncated {
+				t.Errorf("Expected %v truncated %v with %s and limit %d, got %v truncated %v", tt.expected, tt.truncated, backend, tt.limit, files, truncated)
+			}
+		}
+	}
+}
+
+func TestAnalyzeCommitsMergeStrategy(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	// Each branch changes a different file, so the merge differs from its
+	// first parent in b.txt and from its second in a.txt
+	base := testutil.Commit(t, tmpDir, testutil.Change{Write: map[string]string{"a.txt": "base", "b.txt": "base"}})
+	left := testutil.Commit(t, tmpDir, testutil.Change{Write: map[string]string{"a.txt": "left"}, Parents: []plumbing.Hash{base}})
+	right := testutil.Commit(t, tmpDir, testutil.Change{Write: map[string]string{"a.txt": "base", "b.txt": "right"}, Parents: []plumbing.Hash{base}})
+	merge := testutil.Commit(t, tmpDir, testutil.Change{Message: "Merge", Write: map[string]string{"a.txt": "left"}, Parents: []plumbing.Hash{left, right}})
+
+	backends := []Backend{BackendGoGit}
+	if _, err := exec.LookPath("git"); err == nil {
+		backends = append(backends, BackendGit)
+	}
+	for _, backend := range backends {
+		for _, tt := range []struct {
+			strategy MergeStrategy
+			expected []string
+		}{
+			{"", []string{"a.txt", "b.txt"}},
+			{MergeUnion, []string{"a.txt", "b.txt"}},
+			{MergeFirstParent, []string{"b.txt"}},
+			{MergeNone, nil},
+		} {
+			opts := AnalyzeOptions{Backend: backend, MergeStrategy: tt.strategy, Hashes: []string{merge.String()}}
+			commits, err := AnalyzeCommitsWithOptions(tmpDir, opts)
+			if err != nil {
+				t.Fatalf("AnalyzeCommitsWithOptions failed with %s and %q: %v", backend, tt.strategy, err)
+			}
+			var files []string
+			for _, commit := range commits {
+				files = append(files, commit.Files...)
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tt.expected) {
+				t.Errorf("Expected %v with %s and %q, got %v", tt.expected, backend, tt.strategy, files)
+			}
+		}
+
+		// Commits other than merges are read the same way
+		commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Backend: backend, MergeStrategy: MergeNone, Hashes: []string{right.String()}})
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions failed with %s: %v", backend, err)
+		}
+		if len(commits) != 1 || !reflect.DeepEqual(commits[0].Files, []string{"b.txt"}) {
+			t.Errorf("Expected b.txt changed on the right branch with %s, got %+v", backend, commits)
+		}
+	}
+}
+
+func TestGetFilesInCommitIssues(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	// A commit without changes falls back to listing the whole tree
+	commitContent(t, tmpDir, "a.txt", "base", nil)
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+	signature := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}
+	empty, err := wt.Commit("Empty", &git.CommitOptions{
+		Author:            signature,
+		Committer:         signature,
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	// A parent missing from the repository, as in a shallow clone, is skipped
+	// rather than looped over forever
+	missing := plumbing.NewHash("0123456789012345678901234567890123456789")
+	orphan := commitContent(t, tmpDir, "b.txt", "orphan", []plumbing.Hash{empty, missing})
+
+	tests := []struct {
+		hash   plumbing.Hash
+		files  int
+		issues []CommitIssue
+	}{
+		{empty, 1, []CommitIssue{WholeTree}},
+		{orphan, 1, []CommitIssue{UnreadableParent}},
+	}
+	for _, tt := range tests {
+		commit, err := repo.CommitObject(tt.hash)
+		if err != nil {
+			t.Fatalf("Failed to get commit: %v", err)
+		}
+		files, _, issues, err := getFilesInCommit(commit, false, MergeUnion, nil)
+		if err != nil {
+			t.Fatalf("getFilesInCommit failed: %v", err)
+		}
+		if len(files) != tt.files || !reflect.DeepEqual(issues, tt.issues) {
+			t.Errorf("Expected %d files with issues %v, got %v with %v", tt.files, tt.issues, files, issues)
+		}
+	}
+
+	// Issues of analyzed commits are reported. go-git's log can't walk past
+	// the missing parent, so the commits are given by hash
+	var counts CommitIssues
+	err = AnalyzeCommitsFunc(tmpDir, AnalyzeOptions{
+		Hashes: []string{empty.String(), orphan.String()},
+		OnCommitIssue: func(commit CommitInfo, issue CommitIssue) {
+			counts.Add(issue)
+		},
+	}, func(CommitInfo) error { return nil })
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsFunc failed: %v", err)
+	}
+	if counts != (CommitIssues{UnreadableParents: 1, WholeTree: 1}) {
+		t.Errorf("Expected one unreadable parent and one whole tree, got %+v", counts)
+	}
+}
+
+func TestFileSet(t *testing.T) {
+	files := newFileSet()
+	for _, name := range []string{"b.txt", "a.txt", "", "b.txt", "c.txt", "a.txt"} {
+		files.add(name)
+	}
+
+	expected := []string{"b.txt", "a.txt", "c.txt"}
+	if strings.Join(files.files, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v in insertion order without duplicates, got %v", expected, files.files)
+	}
+}
+
+func TestIdentifyHotspotsTopContributorEmail(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", AuthorEmail: "old@example.com", Date: now.Add(-48 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash2", Author: "Test User", AuthorEmail: "new@example.com", Date: now.Add(-24 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash3", Author: "Another User", AuthorEmail: "another@example.com", Date: now, Files: []string{"dir1/fileA.txt"}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspots(commits)
 
+	// The email comes from the top contributor's most recent commit
+	if fileHotspots[0].TopContributorEmail != "new@example.com" {
+		t.Errorf("Expected top contributor email new@example.com, got %q", fileHotspots[0].TopContributorEmail)
+	}
+	if dirHotspots[0].TopContributorEmail != "new@example.com" {
+		t.Errorf("Expected dir1 top contributor email new@example.com, got %q", dirHotspots[0].TopContributorEmail)
+	}
+}
+
+func TestIdentifyHotspotsMinCommits(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"dir1/fileA.txt", "dir2/fileB.txt"}},
+		{Hash: "hash2", Author: "Test User", Date: time.Now(), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash3", Author: "Test User", Date: time.Now(), Files: []string{"dir1/fileC.txt"}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, HotspotOptions{MinCommits: 2})
+
+	if len(fileHotspots) != 1 || fileHotspots[0].Path != "dir1/fileA.txt" {
+		t.Errorf("Expected only dir1/fileA.txt to have at least 2 commits, got %v", fileHotspots)
+	}
+	if len(dirHotspots) != 1 || dirHotspots[0].Path != "dir1" {
+		t.Errorf("Expected only dir1 to have at least 2 commits, got %v", dirHotspots)
+	}
+}
+
+func TestThresholdsExceeding(t *testing.T) {
+	hotspots := []Hotspot{
+		{Path: "busy.go", Commits: 10, Score: 10},
+		{Path: "heavy.go", Commits: 3, Score: 7.5},
+		{Path: "quiet.go", Commits: 5, Score: 5},
+	}
+
+	tests := []struct {
+		thresholds Thresholds
+		expected   []string
+	}{
+		{Thresholds{}, nil},
+		{Thresholds{Commits: 5}, []string{"busy.go"}},
+		{Thresholds{Score: 5}, []string{"busy.go", "heavy.go"}},
+		{Thresholds{Commits: 20, Score: 20}, nil},
+	}
+
+	for _, tt := range tests {
+		exceeding := tt.thresholds.Exceeding(hotspots)
+		if len(exceeding) != len(tt.expected) {
+			t.Errorf("%+v: expected %v, got %v", tt.thresholds, tt.expected, exceeding)
+			continue
+		}
+		for i, h := range exceeding {
+			if h.Path != tt.expected[i] {
+				t.Errorf("%+v: expected %s, got %s", tt.thresholds, tt.expected[i], h.Path)
+			}
+		}
+	}
+}
+
+func TestAnalyzeCommitsWithHashes(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", now.Add(-3*365*24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file2.txt"}, "Add file2", now.Add(-12*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file3.txt"}, "Add file3", now.Add(-6*time.Hour))
+
+	all, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+
+	// Analyze only the oldest and newest commits, one by abbreviated hash.
+	// The oldest is outside Since, which doesn't apply to listed commits.
+	hashes := []string{all[2].Hash, all[0].Hash[:7]}
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Since: now.AddDate(-1, 0, 0), Hashes: hashes})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].Files[0] != "file1.txt" || commits[1].Files[0] != "file3.txt" {
+		t.Errorf("Expected file1.txt and file3.txt in the given order, got %v and %v", commits[0].Files, commits[1].Files)
+	}
+
+	// Unknown hashes are an error
+	_, err = AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Hashes: []string{"0123456789abcdef0123456789abcdef01234567"}})
+	if err == nil {
+		t.Errorf("Expected an error for an unknown commit")
+	}
+}
+
+func TestReadCommitHashes(t *testing.T) {
+	input := "abc123\n\n  def456 parent1 parent2\nfedcba\n"
+
+	hashes, err := ReadCommitHashes(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadCommitHashes failed: %v", err)
+	}
+
+	expected := []string{"abc123", "def456", "fedcba"}
+	if len(hashes) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, hashes)
+	}
+	for i, hash := range hashes {
+		if hash != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], hash)
+		}
+	}
+}
+
+func TestAnalyzeCommitsMaxFilesPerCommit(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"vendor/a.go", "vendor/b.go", "vendor/c.go"}, "Update vendored code", now.Add(-12*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file2.txt", "file3.txt"}, "Add files", now.Add(-6*time.Hour))
+
+	var skipped []string
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{
+		MaxFilesPerCommit: 2,
+		OnLargeCommit: func(commit CommitInfo) {
+			skipped = append(skipped, commit.Message)
+		},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+
+	if len(commits) != 2 {
+		t.Errorf("Expected 2 commits, got %d", len(commits))
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "Update vendored code") {
+		t.Errorf("Expected the vendored update to be skipped, got %v", skipped)
+	}
+}
+
+func TestAnalyzeCommitsSkipRootCommits(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial import", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt", "file2.txt"}, "Change files", now.Add(-12*time.Hour))
+
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{SkipRootCommits: true})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 1 || !strings.Contains(commits[0].Message, "Change files") {
+		t.Errorf("Expected only the second commit, got %v", commits)
+	}
+}
+
+func TestIdentifyHotspotsHistory(t *testing.T) {
+	older := time.Now().Add(-48 * time.Hour)
+	newer := time.Now().Add(-24 * time.Hour)
+	commits := []CommitInfo{
+		{Hash: "hash2", Author: "Another User", Date: newer, Message: "Fix bug\n\nLonger description", Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash1", Author: "Test User", Date: older, Message: "Initial commit", Files: []string{"dir1/fileA.txt", "fileB.txt"}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, HotspotOptions{KeepCommits: true})
+
+	var fileA Hotspot
+	for _, h := range fileHotspots {
+		if h.Path == "dir1/fileA.txt" {
+			fileA = h
+		}
+	}
+	expected := []CommitRef{
+		{Hash: "hash2", Date: newer, Author: "Another User", Subject: "Fix bug"},
+		{Hash: "hash1", Date: older, Author: "Test User", Subject: "Initial commit"},
+	}
+	if len(fileA.History) != len(expected) {
+		t.Fatalf("Expected %d commits in history, got %v", len(expected), fileA.History)
+	}
+	for i, ref := range fileA.History {
+		if ref != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], ref)
+		}
+	}
+
+	// Directories keep their history too
+	if len(dirHotspots) != 1 || len(dirHotspots[0].History) != 2 {
+		t.Errorf("Expected dir1 to have 2 commits in history, got %v", dirHotspots)
+	}
+}
+
+func TestIdentifyHotspotsRankByHotPerDay(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.AddDate(0, 0, -100), Files: []string{"old.go"}},
+		{Hash: "hash2", Author: "Test User", Date: now.AddDate(0, 0, -50), Files: []string{"old.go"}},
+		{Hash: "hash3", Author: "Test User", Date: now.AddDate(0, 0, -10), Files: []string{"old.go", "new.go"}},
+		{Hash: "hash4", Author: "Test User", Date: now.Add(-time.Hour), Files: []string{"new.go", "brand_new.go"}},
+	}
+
+	fileHotspots, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{RankBy: RankByHotPerDay, Now: now})
+	SortHotspots(fileHotspots)
+
+	// brand_new.go is less than a day old, so counts as one day
+	expected := []struct {
+		path  string
+		score float64
+	}{
+		{"brand_new.go", 1},
+		{"new.go", 0.2},
+		{"old.go", 0.03},
+	}
+	if len(fileHotspots) != len(expected) {
+		t.Fatalf("Expected %d hotspots, got %d", len(expected), len(fileHotspots))
+	}
+	for i, e := range expected {
+		h := fileHotspots[i]
+		if h.Path != e.path || math.Abs(h.Score-e.score) > 0.001 {
+			t.Errorf("Expected %s with score %.3f at %d, got %s with %.3f", e.path, e.score, i, h.Path, h.Score)
+		}
+	}
+}
+
+func TestParseCoAuthors(t *testing.T) {
+	message := "Add pairing feature\n\nLonger description.\n\n" +
+		"Co-authored-by: Jane Doe <jane@example.com>\n" +
+		"co-authored-by: John Roe <john@example.com>\n"
+
+	coAuthors := ParseCoAuthors(message)
+
+	expected := []CoAuthor{
+		{Name: "Jane Doe", Email: "jane@example.com"},
+		{Name: "John Roe", Email: "john@example.com"},
+	}
+	if len(coAuthors) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, coAuthors)
+	}
+	for i, coAuthor := range coAuthors {
+		if coAuthor != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], coAuthor)
+		}
+	}
+}
+
+func TestIdentifyHotspotsCountCoAuthors(t *testing.T) {
+	now := time.Now()
+	pairMessage := "Pair on fileA\n\n" +
+		"Co-authored-by: Jane Doe <jane@example.com>\n" +
+		"Co-authored-by: John Roe <john@example.com>\n"
+	reviewMessage := "Apply review\n\nCo-authored-by: Jane Doe <jane@work.example.com>\n"
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", AuthorEmail: "test@example.com", Date: now.Add(-48 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash2", Author: "Test User", AuthorEmail: "test@example.com", Date: now.Add(-24 * time.Hour), Files: []string{"dir1/fileA.txt"},
+			Message: reviewMessage, CoAuthors: ParseCoAuthors(reviewMessage)},
+		{Hash: "hash3", Author: "Jane Doe", AuthorEmail: "jane@example.com", Date: now.Add(-time.Hour), Files: []string{"dir1/fileA.txt"},
+			Message: pairMessage, CoAuthors: ParseCoAuthors(pairMessage)},
+		{Hash: "hash4", Author: "Another User", AuthorEmail: "another@example.com", Date: now, Files: []string{"dir1/fileA.txt"},
+			Message: reviewMessage, CoAuthors: ParseCoAuthors(reviewMessage)},
+	}
+
+	// Without co-authors, Test User has the most commits
+	fileHotspots, _ := IdentifyHotspots(commits)
+	if fileHotspots[0].TopContributor != "Test User" || fileHotspots[0].AuthorCommits != 2 {
+		t.Errorf("Expected Test User with 2 commits, got %s with %d", fileHotspots[0].TopContributor, fileHotspots[0].AuthorCommits)
+	}
+
+	// Jane Doe is credited once for the commit she authored and co-authored,
+	// and once for each commit she co-authored
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, HotspotOptions{CountCoAuthors: true})
+	for _, hotspot := range []Hotspot{fileHotspots[0], dirHotspots[0]} {
+		if hotspot.Commits != 4 {
+			t.Errorf("Expected %s to keep 4 commits, got %d", hotspot.Path, hotspot.Commits)
+		}
+		if hotspot.TopContributor != "Jane Doe" || hotspot.AuthorCommits != 3 {
+			t.Errorf("Expected Jane Doe with 3 commits to %s, got %s with %d", hotspot.Path, hotspot.TopContributor, hotspot.AuthorCommits)
+		}
+		if hotspot.TopContributorEmail != "jane@work.example.com" {
+			t.Errorf("Expected the email of Jane Doe's latest commit, got %q", hotspot.TopContributorEmail)
+		}
+	}
+}
+
+// storeObject encodes obj into the repository's object storage.
+func storeObject(t *testing.T, repo *git.Repository, obj interface {
+	Encode(plumbing.EncodedObject) error
+}) plumbing.Hash {
+	encoded := repo.Storer.NewEncodedObject()
+	if err := obj.Encode(encoded); err != nil {
+		t.Fatalf("Failed to encode object: %v", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(encoded)
+	if err != nil {
+		t.Fatalf("Failed to store object: %v", err)
+	}
+	return hash
+}
+
+// storeBlob stores content as a blob in the repository.
+func storeBlob(t *testing.T, repo *git.Repository, content string) plumbing.Hash {
+	encoded := repo.Storer.NewEncodedObject()
+	encoded.SetType(plumbing.BlobObject)
+	w, err := encoded.Writer()
+	if err != nil {
+		t.Fatalf("Failed to write blob: %v", err)
+	}
+	w.Write([]byte(content))
+	w.Close()
+	hash, err := repo.Storer.SetEncodedObject(encoded)
+	if err != nil {
+		t.Fatalf("Failed to store blob: %v", err)
+	}
+	return hash
+}
+
+func TestAnalyzeCommitsAllRefs(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+	checkout := func(branch string, create bool) {
+		err := wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: create})
+		if err != nil {
+			t.Fatalf("Failed to check out %s: %v", branch, err)
+		}
+	}
+
+	// main.go is shared, feature.go is only on an unmerged branch and
+	// remote.go only on a remote-tracking branch
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go"}, "Shared commit", now.Add(-3*time.Hour))
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	checkout("feature", true)
+	testutil.CreateCommit(t, tmpDir, []string{"feature.go"}, "Feature commit", now.Add(-2*time.Hour))
+	checkout("remote", true)
+	testutil.CreateCommit(t, tmpDir, []string{"remote.go"}, "Remote commit", now.Add(-time.Hour))
+	remote, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	checkout(head.Name().Short(), false)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference("refs/remotes/origin/wip", remote.Hash())); err != nil {
+		t.Fatalf("Failed to create remote ref: %v", err)
+	}
+	if err := repo.Storer.RemoveReference(plumbing.NewBranchReferenceName("remote")); err != nil {
+		t.Fatalf("Failed to remove branch: %v", err)
+	}
+
+	files := func(opts AnalyzeOptions) []string {
+		commits, err := AnalyzeCommitsWithOptions(tmpDir, opts)
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+		}
+		var files []string
+		for _, commit := range commits {
+			files = append(files, commit.Files...)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	if got := files(AnalyzeOptions{}); !reflect.DeepEqual(got, []string{"main.go"}) {
+		t.Errorf("Expected only HEAD's commit, got %v", got)
+	}
+	if got := files(AnalyzeOptions{AllRefs: true}); !reflect.DeepEqual(got, []string{"feature.go", "main.go"}) {
+		t.Errorf("Expected the commits of every local branch once, got %v", got)
+	}
+	want := []string{"feature.go", "main.go", "remote.go"}
+	if got := files(AnalyzeOptions{AllRefs: true, Remotes: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v with remotes, got %v", want, got)
+	}
+
+	// The commits of every branch are interleaved by date, so a limit keeps
+	// the most recent ones wherever they are, not those of HEAD
+	backends := []Backend{BackendGoGit}
+	if _, err := exec.LookPath("git"); err == nil {
+		backends = append(backends, BackendGit)
+	}
+	for _, backend := range backends {
+		opts := AnalyzeOptions{AllRefs: true, Remotes: true, LimitCommits: 2, Backend: backend}
+		if got := files(opts); !reflect.DeepEqual(got, []string{"feature.go", "remote.go"}) {
+			t.Errorf("Expected the 2 most recent commits with %s, got %v", backend, got)
+		}
+	}
+
+	refs, err := BranchRefs(tmpDir, true)
+	if err != nil {
+		t.Fatalf("BranchRefs failed: %v", err)
+	}
+	wantRefs := []string{head.Name().String(), "refs/heads/feature", "refs/remotes/origin/wip"}
+	sort.Strings(refs)
+	sort.Strings(wantRefs)
+	if !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("Expected refs %v, got %v", wantRefs, refs)
+	}
+
+	// A parent missing, as in a shallow clone, is skipped and reported
+	// rather than ending the walk
+	missing := plumbing.NewHash("0123456789012345678901234567890123456789")
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Merge shallow", Date: now, Write: map[string]string{"merged.go": "merged"}, Parents: []plumbing.Hash{head.Hash(), missing}})
+	var issues CommitIssues
+	opts := AnalyzeOptions{AllRefs: true, OnCommitIssue: func(_ CommitInfo, issue CommitIssue) { issues.Add(issue) }}
+	if got := files(opts); !reflect.DeepEqual(got, []string{"feature.go", "main.go", "merged.go"}) {
+		t.Errorf("Expected the commits of every local branch past the missing parent, got %v", got)
+	}
+	if issues.UnreadableParents != 1 {
+		t.Errorf("Expected 1 commit with an unreadable parent, got %+v", issues)
+	}
+}
+
+func TestAnalyzeCommitsUnusualPaths(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	// Paths are kept as raw bytes, spaces and non-UTF-8 alike
+	files := []string{"docs/release notes.md", "docs/naïve.md", "caf\xe9.txt"}
+	testutil.CreateCommit(t, tmpDir, files, "Add unusual paths", time.Now())
+
+	commits, err := AnalyzeCommits(tmpDir)
+	if err != nil {
+		t.Fatalf("AnalyzeCommits failed: %v", err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d", len(commits))
+	}
+	got := append([]string(nil), commits[0].Files...)
+	sort.Strings(got)
+	want := append([]string(nil), files...)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected files %q, got %q", want, got)
+	}
+}
+
+func TestGetFilesInCommitSkipsSymlinksAndSubmodules(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	// commitTree commits a tree with main.go, a symlink to it and a
+	// submodule in vendor/lib pointing at the given commit
+	code := storeBlob(t, repo, "package main\n")
+	target := storeBlob(t, repo, "main.go")
+	signature := object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}
+	commitTree := func(submodule plumbing.Hash, parents []plumbing.Hash) *object.Commit {
+		vendor := storeObject(t, repo, &object.Tree{Entries: []object.TreeEntry{
+			{Name: "lib", Mode: filemode.Submodule, Hash: submodule},
+		}})
+		root := storeObject(t, repo, &object.Tree{Entries: []object.TreeEntry{
+			{Name: "link", Mode: filemode.Symlink, Hash: target},
+			{Name: "main.go", Mode: filemode.Regular, Hash: code},
+			{Name: "vendor", Mode: filemode.Dir, Hash: vendor},
+		}})
+		hash := storeObject(t, repo, &object.Commit{
+			Author:       signature,
+			Committer:    signature,
+			Message:      "Update",
+			TreeHash:     root,
+			ParentHashes: parents,
+		})
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			t.Fatalf("Failed to get commit: %v", err)
+		}
+		return commit
+	}
+	initial := commitTree(plumbing.NewHash("1111111111111111111111111111111111111111"), nil)
+	bump := commitTree(plumbing.NewHash("2222222222222222222222222222222222222222"), []plumbing.Hash{initial.Hash})
+
+	tests := []struct {
+		commit            *object.Commit
+		includeSubmodules bool
+		expected          []string
+	}{
+		{initial, false, []string{"main.go"}},
+		{initial, true, []string{"main.go", "vendor/lib"}},
+		{bump, false, nil},
+		{bump, true, []string{"vendor/lib"}},
+	}
+	for _, tt := range tests {
+		files, _, _, err := getFilesInCommit(tt.commit, tt.includeSubmodules, MergeUnion, nil)
+		if err != nil {
+			t.Fatalf("getFilesInCommit failed: %v", err)
+		}
+		if strings.Join(files, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("getFilesInCommit(%s, %v) = %v, expected %v", tt.commit.Hash, tt.includeSubmodules, files, tt.expected)
+		}
+	}
+}
+
+func TestIdentifyHotspotsComponents(t *testing.T) {
+	components, err := NewComponents([]ComponentRule{
+		{Glob: "**/*_test.go", Component: "tests"},
+		{Glob: "internal/*", Component: "core"},
+		{Glob: "cmd", Component: "cli"},
+	})
+	if err != nil {
+		t.Fatalf("NewComponents failed: %v", err)
+	}
+
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"internal/git/git.go", "internal/config/config.go"}},
+		{Hash: "hash2", Author: "Test User", Date: time.Now(), Files: []string{"internal/git/git_test.go", "cmd/git-hotspots/main.go"}},
+		{Hash: "hash3", Author: "Test User", Date: time.Now(), Files: []string{"README.md", "internal/git/git.go"}},
+	}
+
+	_, groups := IdentifyHotspotsWithOptions(commits, HotspotOptions{Components: components})
+
+	// Components count each commit once, however many of their files it touched,
+	// and files matching no rule, even in the root directory, are "other"
+	expected := map[string]int{"core": 2, "tests": 1, "cli": 1, OtherComponent: 1}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected components %v, got %v", expected, groups)
+	}
+	for _, group := range groups {
+		if group.Commits != expected[group.Path] {
+			t.Errorf("Expected %d commits for %s, got %d", expected[group.Path], group.Path, group.Commits)
+		}
+	}
+
+	if _, err := NewComponents([]ComponentRule{{Glob: "src/[", Component: "broken"}}); err == nil {
+		t.Errorf("Expected an error for an invalid glob")
+	}
+}
+
+func TestIdentifyHotspotsSlashPaths(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"a/b/c.go"}},
+	}
+
+	// Git paths are slash-separated on every platform, including Windows
+	_, dirs := IdentifyHotspots(commits)
+	if len(dirs) != 1 || dirs[0].Path != "a/b" {
+		t.Errorf("Expected a/b/c.go to be grouped under a/b, got %v", dirs)
+	}
+}
+
+func TestIdentifyHotspotsCaseInsensitivePaths(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.Add(-2 * time.Hour), Files: []string{"Src/File.go"}},
+		{Hash: "hash2", Author: "Test User", Date: now.Add(-time.Hour), Files: []string{"Src/File.go", "src/file.go"}},
+		{Hash: "hash3", Author: "Test User", Date: now, Files: []string{"src/file.go"}},
+	}
+
+	// By default, case variants are distinct hotspots
+	files, dirs := IdentifyHotspots(commits)
+	if len(files) != 2 || len(dirs) != 2 {
+		t.Fatalf("Expected 2 files and 2 directories, got %v and %v", files, dirs)
+	}
+
+	// Case-insensitively, they merge under their latest spelling, counting
+	// the commit touching both variants once
+	files, dirs = IdentifyHotspotsWithOptions(commits, HotspotOptions{CaseInsensitivePaths: true})
+	if len(files) != 1 || files[0].Path != "src/file.go" || files[0].Commits != 3 {
+		t.Errorf("Expected src/file.go with 3 commits, got %v", files)
+	}
+	if len(dirs) != 1 || dirs[0].Path != "src" || dirs[0].Commits != 3 {
+		t.Errorf("Expected src with 3 commits, got %v", dirs)
+	}
+}
+
+func TestIdentifyHotspotsLifetimes(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.Add(-4 * day), Files: []string{"kept.go", "gone.go", "back.go"},
+			Actions: map[string]FileAction{"kept.go": FileAdded, "gone.go": FileAdded, "back.go": FileAdded}},
+		{Hash: "hash2", Author: "Test User", Date: now.Add(-3 * day), Files: []string{"old.go", "back.go"},
+			Actions: map[string]FileAction{"back.go": FileDeleted}},
+		{Hash: "hash3", Author: "Test User", Date: now.Add(-2 * day), Files: []string{"gone.go", "back.go"},
+			Actions: map[string]FileAction{"gone.go": FileDeleted, "back.go": FileAdded}},
+	}
+
+	files, _ := IdentifyHotspots(commits)
+	expected := map[string][2]time.Time{
+		"kept.go": {now.Add(-4 * day), {}},
+		"gone.go": {now.Add(-4 * day), now.Add(-2 * day)},
+		"back.go": {now.Add(-4 * day), {}}, // Added again after its deletion
+		"old.go":  {},                      // Added before the analyzed commits
+	}
+	for _, h := range files {
+		if got := [2]time.Time{h.Created, h.Deleted}; got != expected[h.Path] {
+			t.Errorf("Expected %s to be created and deleted at %v, got %v", h.Path, expected[h.Path], got)
+		}
+	}
+
+	// Files added before the analyzed commits can be dated from their first one
+	files, _ = IdentifyHotspotsWithOptions(commits, HotspotOptions{FirstCommitAsCreation: true})
+	for _, h := range files {
+		lifetime, ok := h.Lifetime(now)
+		if h.Path == "old.go" && (!ok || lifetime != 3*day) {
+			t.Errorf("Expected old.go to live 3 days since its first commit, got %v (%v)", lifetime, ok)
+		}
+		if h.Path == "gone.go" && (!ok || lifetime != 2*day) {
+			t.Errorf("Expected gone.go to live 2 days until its deletion, got %v (%v)", lifetime, ok)
+		}
+	}
+}
+
+func TestAnalyzeCommitsFileActions(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"kept.txt", "gone.txt"}, "Initial commit", time.Now().Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"new.txt"}, "Add new.txt", time.Now().Add(-time.Hour))
+
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Remove gone.txt", Delete: []string{"gone.txt"}})
+
+	commits, err := AnalyzeCommits(tmpDir)
+	if err != nil {
+		t.Fatalf("AnalyzeCommits failed: %v", err)
+	}
+	expected := []map[string]FileAction{
+		{"gone.txt": FileDeleted},
+		{"new.txt": FileAdded},
+		{"kept.txt": FileAdded, "gone.txt": FileAdded},
+	}
+	if len(commits) != len(expected) {
+		t.Fatalf("Expected %d commits, got %d", len(expected), len(commits))
+	}
+	for i, commit := range commits {
+		if !reflect.DeepEqual(commit.Actions, expected[i]) {
+			t.Errorf("Expected commit %q to have actions %v, got %v", commit.Message, expected[i], commit.Actions)
+		}
+	}
+}
+
+func TestIdentifyHotspotsDirSizes(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"big/a.go", "small/a.go", "gone/a.go"}},
+		{Hash: "hash2", Author: "Test User", Date: time.Now(), Files: []string{"big/b.go", "Small/a.go"}},
+		{Hash: "hash3", Author: "Test User", Date: time.Now(), Files: []string{"big/sub/c.go"}},
+	}
+	head := []string{"big/a.go", "big/b.go", "big/c.go", "big/d.go", "big/sub/c.go", "big/sub/d.go", "small/a.go", "root.go"}
+
+	// Directories are keyed like their hotspots, ignoring case if requested,
+	// and files in the root directory aren't counted
+	opts := HotspotOptions{CaseInsensitivePaths: true}
+	sizes := CountDirFiles(head, opts)
+	if want := map[string]int{"big": 4, "big/sub": 2, "small": 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("Expected sizes %v, got %v", want, sizes)
+	}
+
+	// big has 2 commits over 4 files, big/sub 1 over 2, small 2 over 1, and
+	// gone none left
+	opts.DirSizes = sizes
+	_, dirs := IdentifyHotspotsWithOptions(commits, opts)
+	scores := make(map[string]float64)
+	for _, h := range dirs {
+		scores[h.Path] = h.Score
+	}
+	if want := map[string]float64{"big": 0.5, "big/sub": 0.5, "Small": 2, "gone": 0}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("Expected scores %v, got %v", want, scores)
+	}
+}
+
+func TestIdentifyHotspotsOwnerThreshold(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"silo.go", "shared.go"}},
+		{Hash: "hash2", Author: "Test User", Date: time.Now(), Files: []string{"silo.go", "shared.go"}},
+		{Hash: "hash3", Author: "Test User", Date: time.Now(), Files: []string{"silo.go", "shared.go"}},
+		{Hash: "hash4", Author: "Test User", Date: time.Now(), Files: []string{"silo.go"}},
+		{Hash: "hash5", Author: "Another User", Date: time.Now(), Files: []string{"silo.go", "shared.go"}},
+	}
+
+	// The top contributor made 4 of 5 commits to silo.go, and 3 of 4 to shared.go
+	files, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{OwnerThreshold: 0.75})
+	for _, h := range files {
+		if h.SoleOwned != (h.Path == "silo.go") {
+			t.Errorf("Expected only silo.go to be sole-owned over 75%%, got %s: %v", h.Path, h.SoleOwned)
+		}
+	}
+
+	// Nothing is flagged without a threshold
+	files, _ = IdentifyHotspots(commits)
+	for _, h := range files {
+		if h.SoleOwned {
+			t.Errorf("Expected no sole-owned hotspots without a threshold, got %s", h.Path)
+		}
+	}
+}
+
+func TestIdentifyHotspotsRootLabel(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"go.mod", "Makefile", "src/main.go"}},
+		{Hash: "hash2", Author: "Test User", Date: time.Now(), Files: []string{"go.mod"}},
+		{Hash: "hash3", Author: "Test User", Date: time.Now(), Files: []string{"src/a/b.go"}},
+	}
+
+	// Root files have no directory hotspot by default
+	_, dirs := IdentifyHotspots(commits)
+	for _, dir := range dirs {
+		if dir.Path == "." || dir.Path == "" {
+			t.Errorf("Expected no directory hotspot for root files, got %+v", dir)
+		}
+	}
+
+	// With a label they're grouped under it, counting each commit once
+	_, dirs = IdentifyHotspotsWithOptions(commits, HotspotOptions{RootLabel: "<root>"})
+	expected := map[string]int{"<root>": 2, "src": 1, "src/a": 1}
+	if len(dirs) != len(expected) {
+		t.Fatalf("Expected directories %v, got %v", expected, dirs)
+	}
+	for _, dir := range dirs {
+		if dir.Commits != expected[dir.Path] {
+			t.Errorf("Expected %d commits for %s, got %d", expected[dir.Path], dir.Path, dir.Commits)
+		}
+	}
+	if sizes := CountDirFiles([]string{"go.mod", "Makefile", "src/main.go"}, HotspotOptions{RootLabel: "<root>"}); sizes["<root>"] != 2 {
+		t.Errorf("Expected 2 files under <root>, got %v", sizes)
+	}
+}
+
+func TestCountLineChanges(t *testing.T) {
+	tests := []struct {
+		src, dst         string
+		ignoreWhitespace bool
+		expected         LineChanges
+	}{
+		{"", "a\nb\n", false, LineChanges{Added: 2}},
+		{"a\nb\nc\n", "a\nc\n", false, LineChanges{Deleted: 1}},
+		{"a\nb", "a\nB", false, LineChanges{Added: 1, Deleted: 1}},
+		{"if x {\n\treturn\n}\n", "if x {\n    return\n}\n", false, LineChanges{Added: 1, Deleted: 1}},
+		{"if x {\n\treturn\n}\n", "if x {\n    return\n}\n", true, LineChanges{}},
+		{"if x {\n\treturn\n}\n", "if  x {\n\treturn y\n}\n", true, LineChanges{Added: 1, Deleted: 1}},
+	}
+
+	for _, tt := range tests {
+		if got := countLineChanges(tt.src, tt.dst, tt.ignoreWhitespace); got != tt.expected {
+			t.Errorf("countLineChanges(%q, %q, %v) = %+v, expected %+v", tt.src, tt.dst, tt.ignoreWhitespace, got, tt.expected)
+		}
+	}
+}
+
+func TestAnalyzeCommitsCountLines(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	// Add a file, then reformat it and add a line
+	added := commitContent(t, tmpDir, "code.go", "func f() {\n\treturn\n}\n", nil)
+	commitContent(t, tmpDir, "code.go", "func f()  {\n    return\n}\n// done\n", []plumbing.Hash{added})
+
+	for _, tt := range []struct {
+		ignoreWhitespace bool
+		expected         LineChanges
+	}{
+		{false, LineChanges{Added: 6, Deleted: 2}},
+		{true, LineChanges{Added: 4}},
+	} {
+		commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{CountLines: true, IgnoreWhitespace: tt.ignoreWhitespace})
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+		}
+
+		fileHotspots, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{RankBy: RankByChurn})
+		if len(fileHotspots) != 1 {
+			t.Fatalf("Expected only code.go, got %v", fileHotspots)
+		}
+		h := fileHotspots[0]
+		got := LineChanges{Added: h.LinesAdded, Deleted: h.LinesDeleted}
+		if got != tt.expected {
+			t.Errorf("With ignoreWhitespace %v, expected %+v for code.go, got %+v", tt.ignoreWhitespace, tt.expected, got)
+		}
+		if h.Score != float64(got.Added+got.Deleted) {
+			t.Errorf("Expected the churn score to be %d, got %v", got.Added+got.Deleted, h.Score)
+		}
+	}
+}
+
+func TestIsShallow(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	hash := commitContent(t, tmpDir, "file.txt", "content", nil)
+
+	if shallow, err := IsShallow(tmpDir); err != nil || shallow {
+		t.Errorf("Expected a full clone, got shallow %v and error %v", shallow, err)
+	}
+
+	// git clone --depth 1 records the commits whose parents are missing
+	if err := os.WriteFile(filepath.Join(tmpDir, ".git", "shallow"), []byte(hash.String()+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write shallow file: %v", err)
+	}
+	if shallow, err := IsShallow(tmpDir); err != nil || !shallow {
+		t.Errorf("Expected a shallow clone, got shallow %v and error %v", shallow, err)
+	}
+}
+
+func TestFilterExisting(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutil.Commit(t, tmpDir, testutil.Change{Write: map[string]string{"src/main.go": "main", "src/old/gone.go": "gone"}})
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Remove gone.go", Delete: []string{"src/old/gone.go"}})
+
+	hotspots := []Hotspot{{Path: "src/main.go"}, {Path: "src/old/gone.go"}, {Path: "src"}, {Path: "src/old"}}
+	head, err := OpenHeadTree(tmpDir, "")
+	if err != nil {
+		t.Fatalf("OpenHeadTree failed: %v", err)
+	}
+	var paths []string
+	for _, h := range FilterExisting(hotspots, head.Exists) {
+		paths = append(paths, h.Path)
+	}
+	if expected := []string{"src/main.go", "src"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+
+	// Paths are relative to the subpath, which may name a single file
+	head, err = OpenHeadTree(tmpDir, "src")
+	if err != nil {
+		t.Fatalf("OpenHeadTree failed: %v", err)
+	}
+	if !head.Exists("main.go") || head.Exists("old") {
+		t.Errorf("Expected main.go to exist and old not to under src")
+	}
+	head, err = OpenHeadTree(tmpDir, "src/main.go")
+	if err != nil {
+		t.Fatalf("OpenHeadTree failed: %v", err)
+	}
+	if !head.Exists("main.go") {
+		t.Errorf("Expected main.go to exist as the subpath itself")
+	}
+}
+
+func TestHeadTreeFiles(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"src/main.go", "src/util/strings.go", "README.md"}, "Add files", now)
+	if err := os.Symlink("main.go", filepath.Join(tmpDir, "src", "link.go")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	testutil.CreateCommit(t, tmpDir, []string{"src/link.go"}, "Add symlink", now)
+
+	files := func(subpath string, extensions []string) []string {
+		head, err := OpenHeadTree(tmpDir, subpath)
+		if err != nil {
+			t.Fatalf("OpenHeadTree failed: %v", err)
+		}
+		files, err := head.Files(extensions)
+		if err != nil {
+			t.Fatalf("Files failed: %v", err)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	// Symlinks are skipped, and paths are relative to the subpath
+	if got, want := files("", []string{".go"}), []string{"src/main.go", "src/util/strings.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got, want := files("src", nil), []string{"main.go", "util/strings.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v under src, got %v", want, got)
+	}
+	if got, want := files("src/main.go", nil), []string{"main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v for a file subpath, got %v", want, got)
+	}
+}
+
+func TestHeadTreeCountLines(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutil.Commit(t, tmpDir, testutil.Change{Write: map[string]string{
+		"src/main.go":  "package main\n\nfunc main() {}\n",
+		"src/empty.go": "",
+		"logo.png":     "\x89PNG\x00\x01",
+	}})
+
+	head, err := OpenHeadTree(tmpDir, "src")
+	if err != nil {
+		t.Fatalf("OpenHeadTree failed: %v", err)
+	}
+	lines, err := head.CountLines([]string{"main.go", "empty.go"})
+	if err != nil {
+		t.Fatalf("CountLines failed: %v", err)
+	}
+	if want := map[string]int{"main.go": 3, "empty.go": 0}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("Expected %v under src, got %v", want, lines)
+	}
+
+	// Binary files are left out
+	if head, err = OpenHeadTree(tmpDir, ""); err != nil {
+		t.Fatalf("OpenHeadTree failed: %v", err)
+	}
+	if lines, err = head.CountLines([]string{"logo.png", "src/main.go"}); err != nil {
+		t.Fatalf("CountLines failed: %v", err)
+	}
+	if want := map[string]int{"src/main.go": 3}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("Expected %v, got %v", want, lines)
+	}
+}
+
+func TestIdentifyHotspotsFileLines(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now, Files: []string{"pkg/big.go", "pkg/small.go", "pkg/empty.go"}, Lines: map[string]LineChanges{
+			"pkg/big.go": {Added: 100}, "pkg/small.go": {Added: 10}, "pkg/empty.go": {Added: 5, Deleted: 5},
+		}},
+		{Hash: "hash2", Author: "Test User", Date: now, Files: []string{"pkg/big.go", "pkg/gone.go"}, Lines: map[string]LineChanges{
+			"pkg/big.go": {Added: 20, Deleted: 20}, "pkg/gone.go": {Deleted: 50},
+		}},
+	}
+
+	// Churn per line scores the small file highest, and files without lines,
+	// whether empty or gone from HEAD, score 0
+	files, dirs := IdentifyHotspotsWithOptions(commits, HotspotOptions{
+		RankBy:    RankByChurn,
+		FileLines: map[string]int{"pkg/big.go": 140, "pkg/small.go": 5, "pkg/empty.go": 0},
+	})
+	scores := make(map[string]float64)
+	for _, f := range files {
+		scores[f.Path] = f.Score
+	}
+	if want := map[string]float64{"pkg/small.go": 2, "pkg/big.go": 1, "pkg/empty.go": 0, "pkg/gone.go": 0}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("Expected scores %v, got %v", want, scores)
+	}
+
+	// Directories keep their churn
+	if len(dirs) != 1 || dirs[0].Score != 210 {
+		t.Errorf("Expected pkg to score its churn of 210, got %v", dirs[0].Score)
+	}
+}
+
+func TestSortHotspotsTiebreak(t *testing.T) {
+	hotspots := []Hotspot{
+		{Path: "c.go", Score: 2, Commits: 2},
+		{Path: "b.go", Score: 2, Commits: 3},
+		{Path: "a.go", Score: 2, Commits: 2},
+		{Path: "d.go", Score: 5, Commits: 1},
+	}
+	expected := []string{"d.go", "b.go", "a.go", "c.go"}
+
+	// The order on ties must not depend on the input order
+	for i := 0; i < len(hotspots); i++ {
+		shuffled := append(append([]Hotspot{}, hotspots[i:]...), hotspots[:i]...)
+		SortHotspots(shuffled)
+		var paths []string
+		for _, h := range shuffled {
+			paths = append(paths, h.Path)
+		}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("Rotation %d: expected %v, got %v", i, expected, paths)
+		}
+		for j, h := range shuffled {
+			if h.Rank != j+1 {
+				t.Errorf("Rotation %d: expected %s ranked %d, got %d", i, h.Path, j+1, h.Rank)
+			}
+		}
+	}
+}
+
+func TestAnalyzeCommitsWithExtensions(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "web/app.js"}, "Initial commit", now.Add(-48*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"README.md"}, "Document", now.Add(-24*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"tools/gen.PY"}, "Add generator", now.Add(-12*time.Hour))
+
+	extensions, err := LanguageExtensions([]string{"Go", "python"})
+	if err != nil {
+		t.Fatalf("LanguageExtensions failed: %v", err)
+	}
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Extensions: extensions})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+
+	// The documentation commit is skipped, and extensions ignore case
+	var files []string
+	for _, commit := range commits {
+		files = append(files, commit.Files...)
+	}
+	if expected := []string{"tools/gen.PY", "main.go"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected files %v, got %v", expected, files)
+	}
+
+	if _, err := LanguageExtensions([]string{"cobol"}); err == nil || !strings.Contains(err.Error(), "supported: c, cpp") {
+		t.Errorf("Expected an error listing supported languages, got %v", err)
+	}
+}
+
+func TestIdentifyHotspotsContributors(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Bob", Date: now.Add(-72 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash2", Author: "Alice", Date: now.Add(-48 * time.Hour), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash3", Author: "Carol", Date: now.Add(-24 * time.Hour), Files: []string{"dir1/fileA.txt", "dir1/fileB.txt"}},
+		{Hash: "hash4", Author: "Carol", Date: now, Files: []string{"dir1/fileB.txt"}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspots(commits)
+	SortHotspots(fileHotspots)
+
+	// Every author is kept, ties broken by name, and the first is the top contributor
+	expected := []Contributor{{Author: "Alice", Commits: 1}, {Author: "Bob", Commits: 1}, {Author: "Carol", Commits: 1}}
+	if fileHotspots[0].Path != "dir1/fileA.txt" || !reflect.DeepEqual(fileHotspots[0].Contributors, expected) {
+		t.Errorf("Expected dir1/fileA.txt contributors %v, got %s %v", expected, fileHotspots[0].Path, fileHotspots[0].Contributors)
+	}
+	if fileHotspots[0].TopContributor != "Alice" {
+		t.Errorf("Expected top contributor Alice, got %q", fileHotspots[0].TopContributor)
+	}
+	expected = []Contributor{{Author: "Carol", Commits: 2}, {Author: "Alice", Commits: 1}, {Author: "Bob", Commits: 1}}
+	if !reflect.DeepEqual(dirHotspots[0].Contributors, expected) {
+		t.Errorf("Expected dir1 contributors %v, got %v", expected, dirHotspots[0].Contributors)
+	}
+}
+
+func TestIdentifyHotspotsExcludeBursts(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.Add(-30 * 24 * time.Hour), Files: []string{"vendor/lib/a.go", "vendor/lib/b.go", "src/main.go"}},
+		{Hash: "hash2", Author: "Test User", Date: now.Add(-30*24*time.Hour + time.Hour), Files: []string{"vendor/lib/a.go"}},
+		{Hash: "hash3", Author: "Test User", Date: now, Files: []string{"src/main.go"}},
+	}
+
+	// vendor/lib changed twice within an hour and never again
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, HotspotOptions{ExcludeBursts: 24 * time.Hour})
+	if len(dirHotspots) != 1 || dirHotspots[0].Path != "src" {
+		t.Errorf("Expected only src to be kept, got %v", dirHotspots)
+	}
+
+	// Files are left alone
+	if len(fileHotspots) != 3 {
+		t.Errorf("Expected 3 file hotspots, got %d", len(fileHotspots))
+	}
+}
+
+func TestIdentifyHotspotsIdle(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now.Add(-90 * day), Files: []string{"old/a.go", "live/b.go"}},
+		{Hash: "hash2", Author: "Test User", Date: now.Add(-40 * day), Files: []string{"warm/c.go"}},
+		{Hash: "hash3", Author: "Test User", Date: now.Add(-2 * day), Files: []string{"live/b.go"}},
+	}
+	paths := func(hotspots []Hotspot) []string {
+		var paths []string
+		for _, h := range hotspots {
+			paths = append(paths, h.Path)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	tests := []struct {
+		name         string
+		maxIdle      time.Duration
+		activeWithin time.Duration
+		files        []string
+		dirs         []string
+	}{
+		{"max idle", 30 * day, 0, []string{"old/a.go", "warm/c.go"}, []string{"old", "warm"}},
+		{"active within", 0, 7 * day, []string{"live/b.go"}, []string{"live"}},
+		{"both", 30 * day, 60 * day, []string{"warm/c.go"}, []string{"warm"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := HotspotOptions{MaxIdle: tt.maxIdle, ActiveWithin: tt.activeWithin, Now: now}
+			fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, opts)
+			if got := paths(fileHotspots); !reflect.DeepEqual(got, tt.files) {
+				t.Errorf("Expected files %v, got %v", tt.files, got)
+			}
+			if got := paths(dirHotspots); !reflect.DeepEqual(got, tt.dirs) {
+				t.Errorf("Expected dirs %v, got %v", tt.dirs, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeCommitsSample(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		testutil.CreateCommit(t, tmpDir, []string{fmt.Sprintf("file%d.txt", i)}, fmt.Sprintf("Commit %d", i), now.Add(time.Duration(i-20)*time.Hour))
+	}
+
+	hashes := func(opts AnalyzeOptions) []string {
+		commits, err := AnalyzeCommitsWithOptions(tmpDir, opts)
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+		}
+		var hashes []string
+		for _, commit := range commits {
+			hashes = append(hashes, commit.Hash)
+		}
+		return hashes
+	}
+
+	// The same seed picks the same commits, and a sample is a strict subset
+	first := hashes(AnalyzeOptions{Sample: 0.5, Seed: 42})
+	if second := hashes(AnalyzeOptions{Sample: 0.5, Seed: 42}); !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same sample for the same seed, got %v and %v", first, second)
+	}
+	if len(first) == 0 || len(first) == 20 {
+		t.Errorf("Expected a partial sample of 20 commits, got %d", len(first))
+	}
+	if all := hashes(AnalyzeOptions{}); len(all) != 20 {
+		t.Errorf("Expected all 20 commits without sampling, got %d", len(all))
+	}
+}
+
+func TestIdentifyHotspotsExtrapolate(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash2", Author: "Test User", Date: time.Now(), Files: []string{"dir1/fileA.txt"}},
+		{Hash: "hash3", Author: "Another User", Date: time.Now(), Files: []string{"dir1/fileA.txt"}},
+	}
+
+	// Counts from a 10% sample are scaled up tenfold
+	fileHotspots, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{Extrapolate: 0.1})
+	h := fileHotspots[0]
+	if h.Commits != 30 || h.Score != 30 || h.AuthorCommits != 20 {
+		t.Errorf("Expected 30 commits, a score of 30 and 20 author commits, got %d, %v and %d", h.Commits, h.Score, h.AuthorCommits)
+	}
+	if h.Contributors[1].Commits != 10 {
+		t.Errorf("Expected 10 commits for the second contributor, got %d", h.Contributors[1].Commits)
+	}
+}
+
+func TestWatchHead(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := WatchHead(ctx, tmpDir, 10*time.Millisecond)
+
+	// Nothing is sent until HEAD moves
+	select {
+	case <-changes:
+		t.Fatal("Expected no change before committing")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	testutil.CreateCommit(t, tmpDir, []string{"file2.txt"}, "Add file2", time.Now())
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a change after committing")
+	}
+
+	// The channel is closed once the context is done
+	cancel()
+	for range changes {
+	}
+}
+
+func TestIsRemoteURL(t *testing.T) {
+	for s, want := range map[string]bool{
+		"https://github.com/org/repo.git": true,
+		"ssh://git@example.com/repo.git":  true,
+		"git@github.com:org/repo.git":     true,
+		"file:///srv/repo":                true,
+		".":                               false,
+		"../repo":                         false,
+		"/home/user/src/repo":             false,
+		`C:\src\repo`:                     false,
+	} {
+		if got := IsRemoteURL(s); got != want {
+			t.Errorf("IsRemoteURL(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"file1.txt"}, "Initial commit", time.Now().Add(-time.Hour))
+
+	dir := filepath.Join(t.TempDir(), "clone")
+	if err := Clone("file://"+filepath.ToSlash(tmpDir), dir, "", nil); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	commits, err := AnalyzeCommitsWithOptions(dir, AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Files[0] != "file1.txt" {
+		t.Errorf("Expected the cloned commit to touch file1.txt, got %v", commits)
+	}
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// GitIgnore matches the files a repository's git ignore configuration
+// ignores, such as generated files that were committed anyway.
+type GitIgnore struct {
+	matcher gitignore.Matcher
+}
+
+// ReadGitIgnore reads the ignore patterns git uses for the repository
+// containing repoPath: the file named by core.excludesFile, or
+// $XDG_CONFIG_HOME/git/ignore if unset, then .git/info/exclude and the
+// .gitignore files in the working tree, each taking precedence over the ones
+// before. Bare repositories only have the first.
+func ReadGitIgnore(repoPath string) (*GitIgnore, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	patterns, err := readExcludesFile(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil && !errors.Is(err, git.ErrIsBareRepository) {
+		return nil, fmt.Errorf("failed to open working tree: %w", err)
+	}
+	if worktree != nil {
+		worktreePatterns, err := gitignore.ReadPatterns(worktree.Filesystem, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read .gitignore files: %w", err)
+		}
+		patterns = append(patterns, worktreePatterns...)
+	}
+	return &GitIgnore{matcher: gitignore.NewMatcher(patterns)}, nil
+}
+
+// readExcludesFile reads the patterns in the file named by core.excludesFile
+// in the repository, user or system configuration, or in git's default
+// $XDG_CONFIG_HOME/git/ignore. A missing file has no patterns.
+func readExcludesFile(repo *git.Repository) ([]gitignore.Pattern, error) {
+	cfg, err := repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git config: %w", err)
+	}
+	name := cfg.Raw.Section("core").Options.Get("excludesfile")
+	home, _ := os.UserHomeDir()
+	switch {
+	case name == "":
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			if home == "" {
+				return nil, nil
+			}
+			configHome = filepath.Join(home, ".config")
+		}
+		name = filepath.Join(configHome, "git", "ignore")
+	case strings.HasPrefix(name, "~/") && home != "":
+		name = filepath.Join(home, name[2:])
+	}
+
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read core.excludesFile: %w", err)
+	}
+	defer f.Close()
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read core.excludesFile: %w", err)
+	}
+	return patterns, nil
+}
+
+// Ignores reports whether the file at the slash-separated path, relative to
+// the repository root, is ignored. A nil GitIgnore ignores nothing.
+func (g *GitIgnore) Ignores(file string) bool {
+	if g == nil {
+		return false
+	}
+	return g.matcher.Match(strings.Split(file, "/"), false)
+}
+
+// Filter returns the files that aren't ignored, given their paths relative
+// to subpath, as HeadTree.Files lists them. A nil GitIgnore keeps them all.
+func (g *GitIgnore) Filter(files []string, subpath string) []string {
+	if g == nil {
+		return files
+	}
+	subpath = cleanSubpath(subpath)
+	var kept []string
+	for _, file := range files {
+		if !g.Ignores(path.Join(subpath, file)) {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
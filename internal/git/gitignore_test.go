@@ -0,0 +1,115 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestGitIgnore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "api.pb.go", "keep.pb.go", "gen/client.go", "local.txt", "notes.md", "web/app.min.js"}, "Initial commit", time.Now())
+
+	// Ignore files the way git would, even though they're committed: in
+	// .gitignore files, .git/info/exclude and the default excludes file,
+	// with later sources and deeper files taking precedence
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	writeFile(filepath.Join(tmpDir, ".gitignore"), "# Generated\n*.pb.go\n!keep.pb.go\ngen/\n")
+	writeFile(filepath.Join(tmpDir, "web", ".gitignore"), "*.min.js\n")
+	writeFile(filepath.Join(tmpDir, ".git", "info", "exclude"), "local.txt\n")
+	writeFile(filepath.Join(home, ".config", "git", "ignore"), "*.md\n")
+
+	gitIgnore, err := ReadGitIgnore(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadGitIgnore failed: %v", err)
+	}
+	for file, want := range map[string]bool{
+		"main.go":        false,
+		"api.pb.go":      true,
+		"keep.pb.go":     false,
+		"gen/client.go":  true,
+		"local.txt":      true,
+		"notes.md":       true,
+		"web/app.min.js": true,
+		"app.min.js":     false,
+	} {
+		if got := gitIgnore.Ignores(file); got != want {
+			t.Errorf("Ignores(%q) = %v, want %v", file, got, want)
+		}
+	}
+	if got := gitIgnore.Filter([]string{"client.go", "other.go"}, "gen"); got != nil {
+		t.Errorf("Expected every file under gen ignored, got %v", got)
+	}
+	var nilIgnore *GitIgnore
+	if nilIgnore.Ignores("api.pb.go") {
+		t.Errorf("Expected a nil GitIgnore to ignore nothing")
+	}
+
+	// Commits only list the files that aren't ignored
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{RespectGitIgnore: true})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d", len(commits))
+	}
+	if want := []string{"keep.pb.go", "main.go"}; !reflect.DeepEqual(commits[0].Files, want) {
+		t.Errorf("Expected files %v, got %v", want, commits[0].Files)
+	}
+}
+
+func TestGitIgnoreExcludesFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "build.log"}, "Initial commit", time.Now())
+	testutil.CreateCommit(t, tmpDir, []string{"debug.log"}, "Add logs", time.Now())
+
+	// core.excludesFile replaces the default excludes file
+	excludes := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(excludes, []byte("*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write excludes file: %v", err)
+	}
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	cfg.Raw.Section("core").SetOption("excludesfile", excludes)
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// and commits touching only ignored files are skipped
+	commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{RespectGitIgnore: true})
+	if err != nil {
+		t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+	}
+	if len(commits) != 1 || !reflect.DeepEqual(commits[0].Files, []string{"main.go"}) {
+		t.Errorf("Expected only main.go in the initial commit, got %+v", commits)
+	}
+}
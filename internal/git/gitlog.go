@@ -0,0 +1,293 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Backend selects how commits are read from a repository.
+type Backend string
+
+const (
+	// BackendGoGit reads commits in process with go-git.
+	BackendGoGit Backend = "go-git"
+
+	// BackendGit runs the system git binary's git log and parses its output,
+	// for repositories go-git reads slowly or can't read, such as partial
+	// clones or those using pack formats it doesn't support. Its output
+	// doesn't tell symlinks and submodules from files, so changes to them
+	// count whatever AnalyzeOptions.IncludeSubmodules, and it can't count
+	// lines.
+	BackendGit Backend = "git"
+)
+
+// gitLogFormat is the format of the header git log prints for each commit:
+// a record separator, then its hash, parents, author name, email and date,
+// and message, separated by unit separators. With -z, the header ends with
+// a NUL, followed by the status and paths of each change, each ending with a
+// NUL.
+const gitLogFormat = "%x1e%H%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%B"
+
+// loggedCommit is a commit as git log printed it.
+type loggedCommit struct {
+	hash    string
+	parents int
+	author  object.Signature
+	message string
+	files   []string
+	actions map[string]FileAction
+}
+
+// errLimitReached stops reading git log once AnalyzeOptions.LimitCommits
+// commits were read.
+var errLimitReached = errors.New("commit limit reached")
+
+// analyzeCommitsWithGit analyzes commits like AnalyzeCommitsFunc, reading
+// them from the output of git log rather than with go-git.
+func analyzeCommitsWithGit(repoPath string, opts AnalyzeOptions, fn func(commit CommitInfo) error) error {
+	if opts.CountLines {
+		return errors.New("counting lines isn't supported by the git backend")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("the git backend requires git: %w", err)
+	}
+
+	// Start from HEAD, or the requested ref
+	from := "HEAD"
+	if opts.Ref != "" {
+		from = opts.Ref
+	}
+
+	// Make sure the subpath exists before walking the history
+	subpath := cleanSubpath(opts.Path)
+	if subpath != "" {
+		if err := exec.Command("git", "-C", repoPath, "cat-file", "-e", from+":"+subpath).Run(); err != nil {
+			return fmt.Errorf("path %q does not exist in the repository", subpath)
+		}
+	}
+
+	// Merges are listed once per parent, with -m, and without history
+	// simplification, so they're read like go-git's union of their changes,
+	// or only against their first parent if requested. Renames are listed as
+	// a deletion and an addition, as go-git diffs them
+	diffMerges := "-m"
+	if opts.MergeStrategy == MergeFirstParent {
+		diffMerges = "--diff-merges=first-parent"
+	}
+	args := []string{"-C", repoPath, "-c", "log.showRoot=true", "log", "-z", diffMerges, "--full-history",
+		"--name-status", "--no-renames", "--no-color", "--no-ext-diff", "--format=" + gitLogFormat}
+	if len(opts.Hashes) > 0 {
+		args = append(args, "--no-walk=unsorted", "--end-of-options")
+		args = append(args, opts.Hashes...)
+	} else {
+		if !opts.Since.IsZero() {
+			args = append(args, "--since="+opts.Since.Format(time.RFC3339))
+		}
+		if opts.LimitCommits > 0 {
+			// One more, to tell whether older commits were left
+			args = append(args, "--max-count="+strconv.Itoa(opts.LimitCommits+1))
+		}
+		if opts.AllRefs {
+			args = append(args, "--branches")
+			if opts.Remotes {
+				args = append(args, "--remotes")
+			}
+		}
+		args = append(args, "--end-of-options", from)
+	}
+	if subpath != "" {
+		args = append(args, "--", subpath)
+	}
+
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to run git log: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run git log: %w", err)
+	}
+
+	sampled, withinLimit := opts.sampler(), opts.limiter()
+	if len(opts.Hashes) > 0 {
+		withinLimit = func() bool { return true }
+	}
+	err = parseGitLog(stdout, func(logged loggedCommit) error {
+		if !withinLimit() {
+			return errLimitReached
+		}
+		if !sampled() {
+			return nil
+		}
+
+		// Drop root commits, merges and the commits of excluded authors if
+		// requested
+		if opts.SkipRootCommits && logged.parents == 0 {
+			return nil
+		}
+		if opts.MergeStrategy == MergeNone && logged.parents > 1 {
+			return nil
+		}
+		if opts.ExcludeAuthors.Excludes(logged.author.Name, logged.author.Email) {
+			return nil
+		}
+
+		commitInfo, ok := buildCommitInfo(logged.hash, logged.author, logged.message, logged.files, logged.actions, nil, subpath, opts)
+		if ok && !opts.skipLargeCommit(commitInfo) {
+			return fn(commitInfo)
+		}
+		return nil
+	})
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		if errors.Is(err, errLimitReached) {
+			return nil
+		}
+		return fmt.Errorf("failed to iterate through commits: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git log failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// parseGitLog parses the output of git log -z --name-status with
+// gitLogFormat, passing each commit to fn once all of its changes are read.
+// The records git log -m prints for each parent of a merge are combined,
+// taking the union of their files, with the files added or deleted compared
+// with the first parent, listed first. Renames and copies are read as the
+// addition of their new path, and renames as the deletion of the old one
+// too, as go-git diffs them. With -z, paths are printed as they are rather
+// than C-quoted, so those with quotes, newlines or bytes that aren't UTF-8
+// are read back unchanged. It stops at the first error returned by fn.
+func parseGitLog(r io.Reader, fn func(commit loggedCommit) error) error {
+	reader := bufio.NewReader(r)
+	var current *loggedCommit
+	var files *fileSet
+	firstParent := false // Whether the changes read are against the first parent
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		current.files = files.files
+		current.actions = files.actions
+		return fn(*current)
+	}
+
+	for {
+		field, err := readGitLogField(reader)
+		if err == io.EOF {
+			return flush()
+		} else if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(field, "\x1e") {
+			logged, err := parseGitLogHeader(field[1:])
+			if err != nil {
+				return err
+			}
+			firstParent = current == nil || logged.hash != current.hash
+			if firstParent {
+				if err := flush(); err != nil {
+					return err
+				}
+				current, files = &logged, newFileSet()
+			}
+			continue
+		}
+
+		// Each change is a status such as M or R100, on the line after the
+		// header for the first one, then its path, or the old and new paths
+		// of renames and copies
+		if current == nil {
+			return fmt.Errorf("unexpected change %q before the first commit", field)
+		}
+		status := strings.TrimPrefix(field, "\n")
+		if status == "" || !strings.ContainsRune("ACDMRTUX", rune(status[0])) {
+			return fmt.Errorf("unknown status %q in commit %s", status, current.hash)
+		}
+		paths := make([]string, 1)
+		if status[0] == 'R' || status[0] == 'C' {
+			paths = make([]string, 2)
+		}
+		for i := range paths {
+			paths[i], err = readGitLogField(reader)
+			if err == io.EOF {
+				return fmt.Errorf("commit %s ends in the middle of a change", current.hash)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		switch status[0] {
+		case 'A':
+			files.add(paths[0])
+			if firstParent {
+				files.actions[paths[0]] = FileAdded
+			}
+		case 'D':
+			files.add(paths[0])
+			if firstParent {
+				files.actions[paths[0]] = FileDeleted
+			}
+		case 'R':
+			files.add(paths[0])
+			files.add(paths[1])
+			if firstParent {
+				files.actions[paths[0]] = FileDeleted
+				files.actions[paths[1]] = FileAdded
+			}
+		case 'C':
+			files.add(paths[1])
+			if firstParent {
+				files.actions[paths[1]] = FileAdded
+			}
+		default:
+			files.add(paths[0])
+		}
+	}
+}
+
+// readGitLogField reads the next NUL-terminated field of git log -z output,
+// returning io.EOF once there are none left.
+func readGitLogField(r *bufio.Reader) (string, error) {
+	field, err := r.ReadString(0)
+	if err == io.EOF && field == "" {
+		return "", io.EOF
+	} else if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSuffix(field, "\x00"), nil
+}
+
+// parseGitLogHeader parses the header of a commit printed with gitLogFormat,
+// without its leading record separator.
+func parseGitLogHeader(header string) (loggedCommit, error) {
+	fields := strings.SplitN(header, "\x1f", 6)
+	if len(fields) != 6 {
+		return loggedCommit{}, fmt.Errorf("malformed commit header %q", header)
+	}
+	date, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return loggedCommit{}, fmt.Errorf("malformed date of commit %s: %w", fields[0], err)
+	}
+	return loggedCommit{
+		hash:    fields[0],
+		parents: len(strings.Fields(fields[1])),
+		author:  object.Signature{Name: fields[2], Email: fields[3], When: date},
+		message: fields[5],
+	}, nil
+}
@@ -0,0 +1,215 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+)
+
+// gitLogHeader formats the header of a commit like git log with gitLogFormat.
+func gitLogHeader(hash, parents, message string) string {
+	return "\x1e" + hash + "\x1f" + parents + "\x1fTest User\x1ftest@example.com\x1f2026-01-02T15:04:05+02:00\x1f" + message + "\x00"
+}
+
+// gitLogChanges formats the changes of a commit like git log -z
+// --name-status, given as the fields of each, such as "M", "main.go".
+func gitLogChanges(fields ...string) string {
+	return "\n" + strings.Join(fields, "\x00") + "\x00"
+}
+
+func TestParseGitLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		parents int
+		message string
+		files   []string
+		actions map[string]FileAction
+	}{
+		{
+			name:    "root commit",
+			output:  gitLogHeader("abc", "", "Initial commit\n\nWith a body\n") + gitLogChanges("A", "main.go", "A", "dir/util.go"),
+			message: "Initial commit\n\nWith a body\n",
+			files:   []string{"main.go", "dir/util.go"},
+			actions: map[string]FileAction{"main.go": FileAdded, "dir/util.go": FileAdded},
+		},
+		{
+			name:    "modifications, deletions and type changes",
+			output:  gitLogHeader("abc", "p1", "Change\n") + gitLogChanges("M", "main.go", "D", "old.go", "T", "link"),
+			parents: 1,
+			message: "Change\n",
+			files:   []string{"main.go", "old.go", "link"},
+			actions: map[string]FileAction{"old.go": FileDeleted},
+		},
+		{
+			name:    "rename",
+			output:  gitLogHeader("abc", "p1", "Move\n") + gitLogChanges("R100", "old/name.go", "new/name.go", "R087", "a.go", "b.go"),
+			parents: 1,
+			message: "Move\n",
+			files:   []string{"old/name.go", "new/name.go", "a.go", "b.go"},
+			actions: map[string]FileAction{"old/name.go": FileDeleted, "new/name.go": FileAdded, "a.go": FileDeleted, "b.go": FileAdded},
+		},
+		{
+			name:    "copy",
+			output:  gitLogHeader("abc", "p1", "Copy\n") + gitLogChanges("C075", "template.go", "copy.go"),
+			parents: 1,
+			message: "Copy\n",
+			files:   []string{"copy.go"},
+			actions: map[string]FileAction{"copy.go": FileAdded},
+		},
+		{
+			name:    "paths git would quote",
+			output:  gitLogHeader("abc", "p1", "Odd names\n") + gitLogChanges("A", "with space.go", "M", `"quoted".go`, "M", "tab\there.go", "M", "new\nline.go", "M", "caf\xe9.go"),
+			parents: 1,
+			message: "Odd names\n",
+			files:   []string{"with space.go", `"quoted".go`, "tab\there.go", "new\nline.go", "caf\xe9.go"},
+			actions: map[string]FileAction{"with space.go": FileAdded},
+		},
+		{
+			name: "merge listed per parent",
+			output: gitLogHeader("abc", "p1 p2", "Merge branch 'topic'\n") + gitLogChanges("A", "topic.go", "M", "shared.go") +
+				gitLogHeader("abc", "p1 p2", "Merge branch 'topic'\n") + gitLogChanges("M", "shared.go", "D", "main-only.go"),
+			parents: 2,
+			message: "Merge branch 'topic'\n",
+			files:   []string{"topic.go", "shared.go", "main-only.go"},
+			actions: map[string]FileAction{"topic.go": FileAdded}, // Only against the first parent
+		},
+		{
+			name:    "no changes",
+			output:  gitLogHeader("abc", "p1", "Empty\n"),
+			parents: 1,
+			message: "Empty\n",
+			actions: map[string]FileAction{},
+		},
+	}
+	date := time.Date(2026, 1, 2, 15, 4, 5, 0, time.FixedZone("", 2*60*60))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commits []loggedCommit
+			err := parseGitLog(strings.NewReader(tt.output), func(commit loggedCommit) error {
+				commits = append(commits, commit)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("parseGitLog failed: %v", err)
+			}
+			if len(commits) != 1 {
+				t.Fatalf("Expected a single commit, got %+v", commits)
+			}
+			got := commits[0]
+			if got.hash != "abc" || got.parents != tt.parents || got.message != tt.message {
+				t.Errorf("Expected commit abc with %d parents and message %q, got %+v", tt.parents, tt.message, got)
+			}
+			if got.author.Name != "Test User" || got.author.Email != "test@example.com" || !got.author.When.Equal(date) {
+				t.Errorf("Expected a commit by Test User at %v, got %+v", date, got.author)
+			}
+			if !reflect.DeepEqual(got.files, tt.files) {
+				t.Errorf("Expected files %q, got %q", tt.files, got.files)
+			}
+			if !reflect.DeepEqual(got.actions, tt.actions) {
+				t.Errorf("Expected actions %v, got %v", tt.actions, got.actions)
+			}
+		})
+	}
+}
+
+func TestParseGitLogSeveralCommits(t *testing.T) {
+	output := gitLogHeader("second", "first", "Second\n") + gitLogChanges("M", "main.go") +
+		gitLogHeader("empty", "first", "Empty\n") +
+		gitLogHeader("first", "", "First\n") + gitLogChanges("A", "main.go")
+
+	var hashes []string
+	err := parseGitLog(strings.NewReader(output), func(commit loggedCommit) error {
+		hashes = append(hashes, commit.hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parseGitLog failed: %v", err)
+	}
+	if !reflect.DeepEqual(hashes, []string{"second", "empty", "first"}) {
+		t.Errorf("Expected the three commits in order, got %v", hashes)
+	}
+
+	// Errors returned by fn stop the parsing
+	stop := errors.New("stop")
+	calls := 0
+	err = parseGitLog(strings.NewReader(output), func(loggedCommit) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected parsing to stop after the first commit, got %v after %d calls", err, calls)
+	}
+}
+
+func TestParseGitLogMalformed(t *testing.T) {
+	for name, output := range map[string]string{
+		"change before a commit": gitLogChanges("M", "orphan.go"),
+		"missing header fields":  "\x1ehash\x1fonly two fields\x00",
+		"malformed date":         "\x1ehash\x1f\x1fTest User\x1ftest@example.com\x1fyesterday\x1fMessage\x00",
+		"unknown status":         gitLogHeader("abc", "", "Message\n") + gitLogChanges("Z", "main.go"),
+		"truncated rename":       gitLogHeader("abc", "p1", "Message\n") + gitLogChanges("R100", "old.go"),
+	} {
+		if err := parseGitLog(strings.NewReader(output), func(loggedCommit) error { return nil }); err == nil {
+			t.Errorf("%s: expected an error parsing %q", name, output)
+		}
+	}
+}
+
+func TestAnalyzeCommitsWithGitBackend(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go", "README.md"}, "Initial commit", now.Add(-3*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/app.go", "src/app_test.go"}, "Add app", now.Add(-2*time.Hour))
+	testutil.CreateCommit(t, tmpDir, []string{"src/util.go", "docs/guide.md"}, "Add util\n\nCo-authored-by: Pair <pair@example.com>", now.Add(-time.Hour))
+
+	// Delete a file, so both backends must tell deletions apart
+	testutil.Commit(t, tmpDir, testutil.Change{Message: "Remove README.md", Date: now.Add(-30 * time.Minute), Delete: []string{"README.md"}})
+
+	// Both backends read the same commits, whatever the options
+	for _, opts := range []AnalyzeOptions{
+		{},
+		{Path: "src"},
+		{Extensions: []string{".md"}},
+		{SkipRootCommits: true},
+		{Since: now.Add(-90 * time.Minute)},
+	} {
+		expected, err := AnalyzeCommitsWithOptions(tmpDir, opts)
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions failed: %v", err)
+		}
+		opts.Backend = BackendGit
+		commits, err := AnalyzeCommitsWithOptions(tmpDir, opts)
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions with the git backend failed: %v", err)
+		}
+		if len(commits) != len(expected) {
+			t.Fatalf("Expected %d commits with %+v, got %d", len(expected), opts, len(commits))
+		}
+		for i, commit := range commits {
+			want := expected[i]
+			if commit.Hash != want.Hash || commit.Author != want.Author || !commit.Date.Equal(want.Date) ||
+				commit.Message != want.Message || !reflect.DeepEqual(commit.Files, want.Files) ||
+				!reflect.DeepEqual(commit.CoAuthors, want.CoAuthors) || !reflect.DeepEqual(commit.Actions, want.Actions) {
+				t.Errorf("Expected commit %+v with %+v, got %+v", want, opts, commit)
+			}
+		}
+	}
+
+	if _, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Backend: BackendGit, Path: "missing"}); err == nil || !strings.Contains(err.Error(), `path "missing" does not exist`) {
+		t.Errorf("Expected an error for a missing path, got %v", err)
+	}
+	if _, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Backend: BackendGit, CountLines: true}); err == nil {
+		t.Error("Expected an error counting lines with the git backend")
+	}
+}
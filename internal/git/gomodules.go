@@ -0,0 +1,132 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GoModuleComponents returns components grouping files by the Go module they
+// belong to, per the go.mod files in the HEAD of the repository at repoPath:
+// each file goes to the module of the nearest go.mod above it, named by its
+// module path, so multi-module repositories get module-level hotspots.
+// Files outside any module are grouped by directory instead. go.mod files
+// under testdata directories are ignored, as the go command ignores them.
+// Paths are relative to subpath, like those of hotspots analyzed with
+// AnalyzeOptions.Path; a go.mod above the subpath covers all of it.
+func GoModuleComponents(repoPath, subpath string) (*Components, error) {
+	head, err := OpenHeadTree(repoPath, subpath)
+	if err != nil {
+		return nil, err
+	}
+	modules := make(map[string]string)
+
+	// The nearest go.mod above the subpath, if any, covers the whole subpath
+	if head.subpath != "" {
+		for dir := path.Dir(head.subpath); ; dir = path.Dir(dir) {
+			if module, ok, err := head.readGoMod(path.Join(dir, "go.mod")); err != nil {
+				return nil, err
+			} else if ok {
+				modules["."] = module
+				break
+			}
+			if dir == "." {
+				break
+			}
+		}
+	}
+
+	files, err := head.Files(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if path.Base(file) != "go.mod" || inTestdata(file) {
+			continue
+		}
+		name := file
+		if head.subpath != "" {
+			name = head.subpath + "/" + file
+		}
+		module, _, err := head.readGoMod(name)
+		if err != nil {
+			return nil, err
+		}
+		modules[path.Dir(file)] = module
+	}
+	return &Components{modules: modules}, nil
+}
+
+// moduleFor returns the module path of the nearest module above file, or its
+// directory if it's in none, reporting false for files in the root directory.
+func (c *Components) moduleFor(file string) (string, bool) {
+	dir := path.Dir(file)
+	for d := dir; ; d = path.Dir(d) {
+		if module, ok := c.modules[d]; ok {
+			return module, true
+		}
+		if d == "." {
+			break
+		}
+	}
+	return dir, dir != "."
+}
+
+// readGoMod returns the module path declared by the go.mod file at the
+// slash-separated name, relative to the repository root. It reports false if
+// there's no such file. A go.mod without a module directive is named after
+// its directory.
+func (t *HeadTree) readGoMod(name string) (string, bool, error) {
+	file, err := t.tree.File(name)
+	if errors.Is(err, object.ErrFileNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("failed to find %s in HEAD: %w", name, err)
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s in HEAD: %w", name, err)
+	}
+	if module := parseModulePath([]byte(contents)); module != "" {
+		return module, true, nil
+	}
+	return path.Dir(name), true, nil
+}
+
+// parseModulePath returns the path in the module directive of a go.mod file,
+// or "" if it has none.
+func parseModulePath(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.Index(line, "//"); comment >= 0 {
+			line = line[:comment]
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted
+		}
+		return fields[1]
+	}
+	return ""
+}
+
+// inTestdata reports whether the slash-separated file is under a testdata
+// directory.
+func inTestdata(file string) bool {
+	for _, segment := range strings.Split(path.Dir(file), "/") {
+		if segment == "testdata" {
+			return true
+		}
+	}
+	return false
+}
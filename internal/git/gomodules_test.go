@@ -0,0 +1,75 @@
+package git
+
+import (
+	"os"
+	"testing"
+
+	"git-hotspots/internal/testutil"
+)
+
+func TestGoModuleComponents(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"svc/go.mod":                  "module example.com/svc // the service\n\ngo 1.23\n",
+		"svc/api/api.go":              "package api\n",
+		"svc/tools/go.mod":            "module \"example.com/svc/tools\"\n",
+		"svc/tools/gen/gen.go":        "package gen\n",
+		"svc/testdata/mod/go.mod":     "module example.com/fixture\n",
+		"svc/testdata/mod/fixture.go": "package fixture\n",
+		"scripts/build.sh":            "#!/bin/sh\n",
+		"README.md":                   "# Test\n",
+	}
+	for file, content := range files {
+		commitContent(t, tmpDir, file, content, nil)
+	}
+
+	// Files go to the nearest module, or their directory outside any module
+	components, err := GoModuleComponents(tmpDir, "")
+	if err != nil {
+		t.Fatalf("GoModuleComponents failed: %v", err)
+	}
+	tests := []struct {
+		file      string
+		component string
+		ok        bool
+	}{
+		{"svc/api/api.go", "example.com/svc", true},
+		{"svc/go.mod", "example.com/svc", true},
+		{"svc/tools/gen/gen.go", "example.com/svc/tools", true},
+		{"svc/testdata/mod/fixture.go", "example.com/svc", true},
+		{"scripts/build.sh", "scripts", true},
+		{"README.md", ".", false},
+	}
+	for _, tt := range tests {
+		if component, ok := components.componentFor(tt.file); component != tt.component || ok != tt.ok {
+			t.Errorf("componentFor(%q) = %q, %v, expected %q, %v", tt.file, component, ok, tt.component, tt.ok)
+		}
+	}
+
+	// A go.mod above the subpath covers all of it
+	components, err = GoModuleComponents(tmpDir, "svc/api")
+	if err != nil {
+		t.Fatalf("GoModuleComponents failed: %v", err)
+	}
+	if component, ok := components.componentFor("api.go"); component != "example.com/svc" || !ok {
+		t.Errorf("Expected api.go in example.com/svc under the subpath, got %q, %v", component, ok)
+	}
+}
+
+func TestParseModulePath(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"module example.com/a\n\ngo 1.23\n", "example.com/a"},
+		{"// Deprecated: use b\nmodule \"example.com/a\" // quoted\n", "example.com/a"},
+		{"go 1.23\n", ""},
+	}
+	for _, tt := range tests {
+		if got := parseModulePath([]byte(tt.data)); got != tt.expected {
+			t.Errorf("parseModulePath(%q) = %q, expected %q", tt.data, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package git
+
+import (
+	"sort"
+	"time"
+)
+
+// HeatmapBucket is the length of the calendar periods a heatmap counts
+// commits in.
+type HeatmapBucket string
+
+const (
+	// BucketDay counts commits per calendar day.
+	BucketDay HeatmapBucket = "day"
+
+	// BucketWeek counts commits per week, starting on Mondays.
+	BucketWeek HeatmapBucket = "week"
+
+	// BucketMonth counts commits per calendar month.
+	BucketMonth HeatmapBucket = "month"
+)
+
+// Heatmap holds the number of commits touching each of a list of files in
+// each period of the analysis window.
+type Heatmap struct {
+	Bucket  HeatmapBucket
+	Periods []time.Time  // Start of each period, oldest first
+	Rows    []HeatmapRow // In the order of the hotspots they were computed from
+}
+
+// HeatmapRow holds the commits touching a file in each period of a heatmap.
+type HeatmapRow struct {
+	Path   string
+	Counts []int // Commits in each of Heatmap.Periods
+}
+
+// ComputeHeatmap counts the commits touching each hotspot in each calendar
+// period of the given bucket length between since and until, in the location
+// of until. Periods are aligned to the calendar, so the first and last ones
+// may extend past the window. If since is zero, as when analyzing the full
+// history, the window starts at the earliest commit of the hotspots.
+func ComputeHeatmap(hotspots []Hotspot, since, until time.Time, bucket HeatmapBucket) Heatmap {
+	heatmap := Heatmap{Bucket: bucket}
+	loc := until.Location()
+	since = windowStart(hotspots, since, until)
+
+	for start := bucketStart(since.In(loc), bucket); !start.After(until); start = nextBucket(start, bucket) {
+		heatmap.Periods = append(heatmap.Periods, start)
+	}
+
+	for _, h := range hotspots {
+		row := HeatmapRow{Path: h.Path, Counts: make([]int, len(heatmap.Periods))}
+		for _, date := range h.CommitDates {
+			if date.Before(since) || date.After(until) {
+				continue
+			}
+			if i := heatmap.period(date.In(loc)); i >= 0 {
+				row.Counts[i]++
+			}
+		}
+		heatmap.Rows = append(heatmap.Rows, row)
+	}
+	return heatmap
+}
+
+// windowStart returns since, or if it's zero, as when analyzing listed
+// commits or the full history, the earliest commit of the hotspots, or until
+// if they have none.
+func windowStart(hotspots []Hotspot, since, until time.Time) time.Time {
+	if !since.IsZero() {
+		return since
+	}
+	since = until
+	for _, h := range hotspots {
+		for _, date := range h.CommitDates {
+			if date.Before(since) {
+				since = date
+			}
+		}
+	}
+	return since
+}
+
+// period returns the index of the period containing date, or -1 if none does.
+func (h Heatmap) period(date time.Time) int {
+	return sort.Search(len(h.Periods), func(i int) bool { return h.Periods[i].After(date) }) - 1
+}
+
+// bucketStart returns the start of the period of the given bucket length
+// containing t.
+func bucketStart(t time.Time, bucket HeatmapBucket) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch bucket {
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7) // Back to Monday
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// nextBucket returns the start of the period after the one starting at start.
+func nextBucket(start time.Time, bucket HeatmapBucket) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
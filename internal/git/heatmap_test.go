@@ -0,0 +1,58 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComputeHeatmap(t *testing.T) {
+	// Wednesday 2026-01-07 to Wednesday 2026-01-21 spans three weeks from Monday 2026-01-05
+	since := time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 1, 21, 12, 0, 0, 0, time.UTC)
+	hotspots := []Hotspot{
+		{Path: "busy.go", CommitDates: []time.Time{
+			since.AddDate(0, 0, 1), since.AddDate(0, 0, 2), until.Add(-time.Hour),
+			since.AddDate(0, 0, -3), // Before the window
+		}},
+		{Path: "quiet.go", CommitDates: []time.Time{since.AddDate(0, 0, 6)}},
+	}
+
+	heatmap := ComputeHeatmap(hotspots, since, until, BucketWeek)
+	expectedPeriods := []time.Time{
+		time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(heatmap.Periods, expectedPeriods) {
+		t.Fatalf("Expected weeks %v, got %v", expectedPeriods, heatmap.Periods)
+	}
+	expected := []HeatmapRow{
+		{Path: "busy.go", Counts: []int{2, 0, 1}},
+		{Path: "quiet.go", Counts: []int{0, 1, 0}},
+	}
+	if !reflect.DeepEqual(heatmap.Rows, expected) {
+		t.Errorf("Expected rows %v, got %v", expected, heatmap.Rows)
+	}
+
+	// Months start on the first, and a zero since starts at the earliest commit
+	heatmap = ComputeHeatmap(hotspots, time.Time{}, until, BucketMonth)
+	if len(heatmap.Periods) != 1 || !heatmap.Periods[0].Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected January only, got %v", heatmap.Periods)
+	}
+	if counts := heatmap.Rows[0].Counts; !reflect.DeepEqual(counts, []int{4}) {
+		t.Errorf("Expected all 4 commits of busy.go in January, got %v", counts)
+	}
+}
+
+func TestComputeHeatmapDays(t *testing.T) {
+	until := time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -2)
+	heatmap := ComputeHeatmap([]Hotspot{{Path: "a.go", CommitDates: []time.Time{until}}}, since, until, BucketDay)
+	if len(heatmap.Periods) != 3 {
+		t.Fatalf("Expected 3 days, got %v", heatmap.Periods)
+	}
+	if counts := heatmap.Rows[0].Counts; !reflect.DeepEqual(counts, []int{0, 0, 1}) {
+		t.Errorf("Expected the commit on the last day, got %v", counts)
+	}
+}
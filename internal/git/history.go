@@ -0,0 +1,161 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// FileChange is a commit that changed a file, as part of its history.
+type FileChange struct {
+	CommitRef
+	Path  string // Path of the file as of this commit
+	Lines LineChanges
+}
+
+// FileHistory returns the commits that changed file, newest first, following
+// it backward across renames like git log --follow. file is relative to the
+// repository root and must exist in HEAD. Only opts.Since, opts.IgnoreWhitespace,
+// opts.Aliases and opts.CacheSize apply. Merge commits are skipped, since they
+// change no lines of their own.
+func FileHistory(repoPath, file string, opts AnalyzeOptions) ([]FileChange, error) {
+	repo, err := openRepositoryWithCache(repoPath, opts.CacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+
+	current := cleanSubpath(file)
+	if err := checkPathInHead(repo, ref.Hash(), current); err != nil {
+		return nil, err
+	}
+
+	logOptions := &git.LogOptions{
+		From:  ref.Hash(),
+		Order: git.LogOrderCommitterTime,
+	}
+	if !opts.Since.IsZero() {
+		logOptions.Since = &opts.Since
+	}
+	commitIter, err := repo.Log(logOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit iterator: %w", err)
+	}
+
+	var history []FileChange
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if c.NumParents() > 1 {
+			return nil
+		}
+		previous, lines, ok, err := fileChangeInCommit(c, current, opts.IgnoreWhitespace)
+		if err != nil {
+			return fmt.Errorf("failed to diff commit %s: %w", c.Hash.String(), err)
+		}
+		if !ok {
+			return nil
+		}
+
+		info := CommitInfo{
+			Hash:    c.Hash.String(),
+			Author:  opts.Aliases.Canonical(c.Author.Name, c.Author.Email),
+			Date:    c.Author.When,
+			Message: c.Message,
+		}
+		history = append(history, FileChange{
+			CommitRef: newCommitRef(info),
+			Path:      current,
+			Lines:     lines,
+		})
+
+		// Older commits know the file by its name before a rename
+		if previous != "" {
+			current = previous
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate through commits: %w", err)
+	}
+	return history, nil
+}
+
+// fileChangeInCommit reports whether commit changed the file at path,
+// compared with its first parent, and the lines it changed. If the file was
+// renamed, previous is its path in the parent; if it was modified, it's path
+// itself; if it was added, it's empty.
+func fileChangeInCommit(commit *object.Commit, path string, ignoreWhitespace bool) (previous string, lines LineChanges, ok bool, err error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", LineChanges{}, false, err
+	}
+	entry, err := tree.FindEntry(path)
+	if err != nil {
+		return "", LineChanges{}, false, nil
+	}
+
+	parentTree := &object.Tree{}
+	if commit.NumParents() == 1 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return "", LineChanges{}, false, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return "", LineChanges{}, false, err
+		}
+	}
+
+	// A file that was already there was modified, unless it's unchanged
+	if parentEntry, err := parentTree.FindEntry(path); err == nil {
+		if parentEntry.Hash == entry.Hash && parentEntry.Mode == entry.Mode {
+			return "", LineChanges{}, false, nil
+		}
+		from, err := parentTree.TreeEntryFile(parentEntry)
+		if err != nil {
+			return "", LineChanges{}, false, err
+		}
+		to, err := tree.TreeEntryFile(entry)
+		if err != nil {
+			return "", LineChanges{}, false, err
+		}
+		lines, err := fileLineChanges(from, to, ignoreWhitespace)
+		return path, lines, true, err
+	}
+
+	// Otherwise it was added, or renamed from another path. Detecting
+	// renames diffs the whole trees, so it's only done for new files.
+	changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return "", LineChanges{}, false, err
+	}
+	for _, change := range changes {
+		if change.To.Name != path {
+			continue
+		}
+		from, to, err := change.Files()
+		if err != nil {
+			return "", LineChanges{}, false, err
+		}
+		lines, err := fileLineChanges(from, to, ignoreWhitespace)
+		return change.From.Name, lines, true, err
+	}
+	return "", LineChanges{}, false, nil
+}
+
+// fileLineChanges counts the lines added and deleted to turn from into to,
+// either of which may be nil. Binary files have no lines to count.
+func fileLineChanges(from, to *object.File, ignoreWhitespace bool) (LineChanges, error) {
+	src, srcOK, err := textContents(from)
+	if err != nil {
+		return LineChanges{}, err
+	}
+	dst, dstOK, err := textContents(to)
+	if err != nil || !srcOK || !dstOK {
+		return LineChanges{}, err
+	}
+	return countLineChanges(src, dst, ignoreWhitespace), nil
+}
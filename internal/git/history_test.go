@@ -0,0 +1,71 @@
+package git
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+)
+
+func TestFileHistoryFollowsRenames(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	commit := func(message string, age time.Duration, files map[string]string) {
+		testutil.Commit(t, tmpDir, testutil.Change{Message: message, Date: now.Add(-age), Write: files})
+	}
+
+	lines := []string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+	content := strings.Join(lines, "\n") + "\n"
+	commit("Add old.go", 5*time.Hour, map[string]string{"old.go": content, "other.txt": "other"})
+
+	lines[0] = "ONE"
+	content = strings.Join(lines, "\n") + "\n"
+	commit("Edit old.go", 4*time.Hour, map[string]string{"old.go": content})
+
+	// Rename the file, changing a line on the way
+	lines[1] = "TWO"
+	content = strings.Join(lines, "\n") + "\n"
+	testutil.Commit(t, tmpDir, testutil.Change{
+		Message: "Rename old.go to new.go",
+		Date:    now.Add(-3 * time.Hour),
+		Rename:  map[string]string{"old.go": "new.go"},
+		Write:   map[string]string{"new.go": content},
+	})
+
+	commit("Edit other.txt", 2*time.Hour, map[string]string{"other.txt": "changed"})
+	commit("Extend new.go", time.Hour, map[string]string{"new.go": content + "eleven\ntwelve\n"})
+
+	history, err := FileHistory(tmpDir, "new.go", AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("FileHistory failed: %v", err)
+	}
+
+	type change struct {
+		Subject string
+		Path    string
+		Lines   LineChanges
+	}
+	var got []change
+	for _, c := range history {
+		got = append(got, change{c.Subject, c.Path, c.Lines})
+	}
+	expected := []change{
+		{"Extend new.go", "new.go", LineChanges{Added: 2}},
+		{"Rename old.go to new.go", "new.go", LineChanges{Added: 1, Deleted: 1}},
+		{"Edit old.go", "old.go", LineChanges{Added: 1, Deleted: 1}},
+		{"Add old.go", "old.go", LineChanges{Added: 10}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected history %+v, got %+v", expected, got)
+	}
+
+	// Files that aren't in HEAD are rejected
+	if _, err := FileHistory(tmpDir, "old.go", AnalyzeOptions{}); err == nil {
+		t.Error("Expected an error for a file that no longer exists")
+	}
+}
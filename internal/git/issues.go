@@ -0,0 +1,45 @@
+package git
+
+// CommitIssue describes why a commit could only be partially analyzed.
+type CommitIssue int
+
+const (
+	// UnreadableParent means a parent of the commit, its tree or the diff
+	// against it couldn't be read, such as a parent missing from a shallow
+	// clone, so the changes against that parent were missed.
+	UnreadableParent CommitIssue = iota
+
+	// WholeTree means no changes were found against any parent, such as for
+	// an empty commit, so every file in the commit's tree was counted instead.
+	WholeTree
+
+	// NoFiles means the commit changed no file that's counted, such as one
+	// that only bumps a submodule.
+	NoFiles
+)
+
+// CommitIssues counts the commits with each CommitIssue, to surface data
+// quality problems that would otherwise go unnoticed.
+type CommitIssues struct {
+	UnreadableParents int
+	WholeTree         int
+	NoFiles           int
+}
+
+// Add counts a commit with the given issue.
+func (c *CommitIssues) Add(issue CommitIssue) {
+	switch issue {
+	case UnreadableParent:
+		c.UnreadableParents++
+	case WholeTree:
+		c.WholeTree++
+	case NoFiles:
+		c.NoFiles++
+	}
+}
+
+// Total returns the number of issues counted. A commit with several issues
+// counts once for each.
+func (c CommitIssues) Total() int {
+	return c.UnreadableParents + c.WholeTree + c.NoFiles
+}
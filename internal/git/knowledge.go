@@ -0,0 +1,102 @@
+package git
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// KnowledgeNode is a directory in the knowledge map, annotated with the
+// author who made the most commits anywhere underneath it.
+type KnowledgeNode struct {
+	Name           string
+	Path           string
+	Commits        int
+	TopContributor string
+	AuthorCommits  int
+	Ownership      float64 // Percentage of Commits made by TopContributor
+	Children       []*KnowledgeNode
+
+	authors  map[string]int
+	children map[string]*KnowledgeNode // Children by name
+}
+
+// BuildKnowledgeMap builds a directory tree rooted at "." in which every
+// directory counts the distinct commits touching any file below it.
+func BuildKnowledgeMap(commits []CommitInfo) *KnowledgeNode {
+	root := newKnowledgeNode(".", ".")
+
+	for _, commit := range commits {
+		// Attribute the commit once to the root and every ancestor directory
+		// of the files it touched
+		touched := map[*KnowledgeNode]bool{root: true}
+		for _, file := range commit.Files {
+			dir := path.Dir(file)
+			if dir == "." {
+				continue
+			}
+
+			node := root
+			for _, part := range strings.Split(dir, "/") {
+				node = node.child(part)
+				touched[node] = true
+			}
+		}
+
+		for node := range touched {
+			node.add(commit.Author)
+		}
+	}
+
+	root.finalize()
+	return root
+}
+
+func newKnowledgeNode(name, path string) *KnowledgeNode {
+	return &KnowledgeNode{
+		Name:     name,
+		Path:     path,
+		authors:  make(map[string]int),
+		children: make(map[string]*KnowledgeNode),
+	}
+}
+
+// child returns the named child directory, creating it if needed.
+func (n *KnowledgeNode) child(name string) *KnowledgeNode {
+	if c, ok := n.children[name]; ok {
+		return c
+	}
+
+	path := name
+	if n.Path != "." {
+		path = n.Path + "/" + name
+	}
+	c := newKnowledgeNode(name, path)
+	n.children[name] = c
+	n.Children = append(n.Children, c)
+	return c
+}
+
+func (n *KnowledgeNode) add(author string) {
+	n.Commits++
+	n.authors[author]++
+}
+
+// finalize computes the dominant author for the node and its descendants
+// and orders children by commit count.
+func (n *KnowledgeNode) finalize() {
+	n.TopContributor, n.AuthorCommits = dominantAuthor(n.authors)
+	if n.Commits > 0 {
+		n.Ownership = float64(n.AuthorCommits) / float64(n.Commits) * 100
+	}
+
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].Commits != n.Children[j].Commits {
+			return n.Children[i].Commits > n.Children[j].Commits
+		}
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, c := range n.Children {
+		c.finalize()
+	}
+}
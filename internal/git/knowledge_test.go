@@ -0,0 +1,61 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildKnowledgeMap(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: time.Now(), Files: []string{"README.md", "src/app/main.go"}},
+		{Hash: "hash2", Author: "Test User", Date: time.Now(), Files: []string{"src/app/main.go"}},
+		{Hash: "hash3", Author: "Another User", Date: time.Now(), Files: []string{"src/lib/util.go"}},
+	}
+
+	root := BuildKnowledgeMap(commits)
+
+	// The root counts every commit once
+	if root.Commits != 3 {
+		t.Errorf("Expected root to have 3 commits, got %d", root.Commits)
+	}
+	if root.TopContributor != "Test User" || root.AuthorCommits != 2 {
+		t.Errorf("Expected root owned by 'Test User' with 2 commits, got '%s' with %d", root.TopContributor, root.AuthorCommits)
+	}
+
+	// Root-level files don't create child directories
+	if len(root.Children) != 1 || root.Children[0].Path != "src" {
+		t.Fatalf("Expected a single 'src' child, got %v", root.Children)
+	}
+
+	// src aggregates both of its subdirectories
+	src := root.Children[0]
+	if src.Commits != 3 {
+		t.Errorf("Expected src to have 3 commits, got %d", src.Commits)
+	}
+	if len(src.Children) != 2 {
+		t.Fatalf("Expected src to have 2 children, got %d", len(src.Children))
+	}
+
+	// Children are ordered by commit count
+	app, lib := src.Children[0], src.Children[1]
+	if app.Path != "src/app" || app.TopContributor != "Test User" || app.Ownership != 100 {
+		t.Errorf("Expected src/app owned by 'Test User' at 100%%, got %s '%s' at %.1f%%", app.Path, app.TopContributor, app.Ownership)
+	}
+	if lib.Path != "src/lib" || lib.TopContributor != "Another User" {
+		t.Errorf("Expected src/lib owned by 'Another User', got %s '%s'", lib.Path, lib.TopContributor)
+	}
+}
+
+func TestBuildKnowledgeMapTies(t *testing.T) {
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Zoe", Date: time.Now(), Files: []string{"src/main.go"}},
+		{Hash: "hash2", Author: "Alice", Date: time.Now(), Files: []string{"src/main.go"}},
+	}
+
+	// Tied authors are split by name, whatever order maps are iterated in
+	for i := 0; i < 20; i++ {
+		if root := BuildKnowledgeMap(commits); root.TopContributor != "Alice" || root.Children[0].TopContributor != "Alice" {
+			t.Fatalf("Expected Alice to own the tree, got %s and %s", root.TopContributor, root.Children[0].TopContributor)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// languageExtensions maps lower-case language names to the file extensions
+// of their source files, like a small subset of GitHub Linguist.
+var languageExtensions = map[string][]string{
+	"c":          {".c", ".h"},
+	"cpp":        {".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx"},
+	"csharp":     {".cs"},
+	"css":        {".css", ".scss", ".sass", ".less"},
+	"go":         {".go"},
+	"html":       {".html", ".htm"},
+	"java":       {".java"},
+	"javascript": {".js", ".jsx", ".mjs", ".cjs"},
+	"kotlin":     {".kt", ".kts"},
+	"markdown":   {".md", ".markdown"},
+	"php":        {".php"},
+	"python":     {".py", ".pyi"},
+	"ruby":       {".rb", ".rake"},
+	"rust":       {".rs"},
+	"scala":      {".scala", ".sc"},
+	"shell":      {".sh", ".bash", ".zsh"},
+	"sql":        {".sql"},
+	"swift":      {".swift"},
+	"typescript": {".ts", ".tsx", ".mts", ".cts"},
+	"yaml":       {".yml", ".yaml"},
+}
+
+// Languages returns the names of the languages LanguageExtensions knows, sorted.
+func Languages() []string {
+	names := make([]string, 0, len(languageExtensions))
+	for name := range languageExtensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LanguageExtensions returns the file extensions of the given languages, such
+// as ".go" for "go", for AnalyzeOptions.Extensions. Names are case-insensitive.
+// It returns an error listing the supported languages if a name is unknown.
+func LanguageExtensions(languages []string) ([]string, error) {
+	var extensions []string
+	for _, language := range languages {
+		exts, ok := languageExtensions[strings.ToLower(strings.TrimSpace(language))]
+		if !ok {
+			return nil, fmt.Errorf("unknown language %q (supported: %s)", language, strings.Join(Languages(), ", "))
+		}
+		extensions = append(extensions, exts...)
+	}
+	return extensions, nil
+}
+
+// hasExtension reports whether file ends in one of extensions, ignoring case.
+// Every file matches if extensions is empty.
+func hasExtension(file string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(file))
+	for _, e := range extensions {
+		if ext == strings.ToLower(e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"errors"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// reindexFunc returns a function that makes repo's object store reload its
+// list of packs, or nil if the store doesn't cache one. go-git's filesystem
+// storage reads the pack indexes once, so objects a git gc or repack running
+// alongside the analysis moves from loose files into a new pack look missing
+// until it does.
+func reindexFunc(repo *git.Repository) func() {
+	if store, ok := repo.Storer.(interface{ Reindex() }); ok {
+		return store.Reindex
+	}
+	return nil
+}
+
+// parentTree returns the tree of the i-th parent of commit. If the parent or
+// its tree can't be found, the object store is reindexed with reindex, if
+// set, and they're read once more before giving up.
+func parentTree(commit *object.Commit, i int, reindex func()) (*object.Tree, error) {
+	tree, err := readParentTree(commit, i)
+	if errors.Is(err, plumbing.ErrObjectNotFound) && reindex != nil {
+		reindex()
+		tree, err = readParentTree(commit, i)
+	}
+	return tree, err
+}
+
+// readParentTree returns the tree of the i-th parent of commit.
+func readParentTree(commit *object.Commit, i int) (*object.Tree, error) {
+	parent, err := commit.Parent(i)
+	if err != nil {
+		return nil, err
+	}
+	return parent.Tree()
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestParentTreeReindexes(t *testing.T) {
+	// The parent is only in the complete store, as if a repack had moved it
+	// into a pack the partial store's index doesn't list yet
+	complete, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	partial, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	signature := object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}
+	tree := storeObject(t, complete, &object.Tree{})
+	storeObject(t, partial, &object.Tree{})
+	parent := storeObject(t, complete, &object.Commit{Author: signature, Committer: signature, TreeHash: tree})
+	child := &object.Commit{Author: signature, Committer: signature, TreeHash: tree, ParentHashes: []plumbing.Hash{parent}}
+	commit, err := object.GetCommit(partial.Storer, storeObject(t, partial, child))
+	if err != nil {
+		t.Fatalf("Failed to get commit: %v", err)
+	}
+
+	// Without a way to reindex, the parent is missing
+	if _, err := parentTree(commit, 0, nil); !errors.Is(err, plumbing.ErrObjectNotFound) {
+		t.Fatalf("Expected the parent to be missing, got %v", err)
+	}
+
+	// Reindexing finds it
+	reindexed := 0
+	reindex := func() {
+		reindexed++
+		obj, err := complete.Storer.EncodedObject(plumbing.CommitObject, parent)
+		if err != nil {
+			t.Fatalf("Failed to read parent: %v", err)
+		}
+		if _, err := partial.Storer.SetEncodedObject(obj); err != nil {
+			t.Fatalf("Failed to store parent: %v", err)
+		}
+	}
+	if got, err := parentTree(commit, 0, reindex); err != nil || got.Hash != tree || reindexed != 1 {
+		t.Errorf("Expected tree %s after 1 reindex, got %v, %v after %d", tree, got, err, reindexed)
+	}
+
+	// Once found, the parent is read without reindexing again
+	if _, err := parentTree(commit, 0, reindex); err != nil || reindexed != 1 {
+		t.Errorf("Expected no further reindex, got %v after %d", err, reindexed)
+	}
+}
+
+func TestReindexFunc(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	// go-git's filesystem storage caches its list of packs, so it can be reindexed
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if reindexFunc(repo) == nil {
+		t.Errorf("Expected the filesystem storage to support reindexing")
+	}
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"sort"
+	"time"
+)
+
+// OwnershipChange is a file whose dominant author in the first half of the
+// analysis window differs from the one in the second half.
+type OwnershipChange struct {
+	Path            string
+	PreviousOwner   string
+	PreviousCommits int // Commits by PreviousOwner in the first half
+	NewOwner        string
+	NewCommits      int // Commits by NewOwner in the second half
+	Commits         int
+}
+
+// DetectOwnershipChanges compares, for each hotspot, the author with the most
+// commits before the midpoint of [since, until) against the one with the most
+// commits after it. Hotspots without commits in both halves are skipped, as are
+// those whose previous owner is still tied for the most commits afterwards.
+// If since is zero, the window starts at the earliest commit of the hotspots.
+// Changes are ordered by commit count, most active first.
+func DetectOwnershipChanges(hotspots []Hotspot, since, until time.Time) []OwnershipChange {
+	since = windowStart(hotspots, since, until)
+	midpoint := since.Add(until.Sub(since) / 2)
+
+	var changes []OwnershipChange
+	for _, h := range hotspots {
+		before := make(map[string]int)
+		after := make(map[string]int)
+		for i, date := range h.CommitDates {
+			if i >= len(h.CommitAuthors) || date.Before(since) || !date.Before(until) {
+				continue
+			}
+			if date.Before(midpoint) {
+				before[h.CommitAuthors[i]]++
+			} else {
+				after[h.CommitAuthors[i]]++
+			}
+		}
+
+		previousOwner, previousCommits := dominantAuthor(before)
+		newOwner, newCommits := dominantAuthor(after)
+		if previousOwner == "" || newOwner == "" || after[previousOwner] == newCommits {
+			continue
+		}
+
+		changes = append(changes, OwnershipChange{
+			Path:            h.Path,
+			PreviousOwner:   previousOwner,
+			PreviousCommits: previousCommits,
+			NewOwner:        newOwner,
+			NewCommits:      newCommits,
+			Commits:         h.Commits,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Commits != changes[j].Commits {
+			return changes[i].Commits > changes[j].Commits
+		}
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// dominantAuthor returns the author with the most commits, breaking ties by
+// name so the result doesn't depend on map iteration order.
+func dominantAuthor(authors map[string]int) (string, int) {
+	top, topCommits := "", 0
+	for author, count := range authors {
+		if count > topCommits || (count == topCommits && author < top) {
+			top, topCommits = author, count
+		}
+	}
+	return top, topCommits
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectOwnershipChanges(t *testing.T) {
+	until := time.Now()
+	since := until.AddDate(0, 0, -100)
+	early := since.AddDate(0, 0, 10)
+	late := until.AddDate(0, 0, -10)
+
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: early, Files: []string{"handoff.go", "stable.go"}},
+		{Hash: "hash2", Author: "Test User", Date: early.Add(time.Hour), Files: []string{"handoff.go"}},
+		{Hash: "hash3", Author: "Another User", Date: late, Files: []string{"handoff.go", "stable.go", "new.go"}},
+		{Hash: "hash4", Author: "Test User", Date: late.Add(time.Hour), Files: []string{"stable.go"}},
+	}
+	fileHotspots, _ := IdentifyHotspotsWithOptions(commits, HotspotOptions{KeepCommits: true})
+
+	// Authors are retained alongside each commit date
+	for _, h := range fileHotspots {
+		if len(h.CommitAuthors) != len(h.CommitDates) {
+			t.Errorf("Expected %d commit authors for %s, got %d", len(h.CommitDates), h.Path, len(h.CommitAuthors))
+		}
+	}
+
+	changes := DetectOwnershipChanges(fileHotspots, since, until)
+
+	// stable.go keeps its owner and new.go has no history before the midpoint
+	if len(changes) != 1 {
+		t.Fatalf("Expected 1 ownership change, got %d: %v", len(changes), changes)
+	}
+	change := changes[0]
+	if change.Path != "handoff.go" || change.Commits != 3 {
+		t.Errorf("Expected handoff.go with 3 commits, got %s with %d", change.Path, change.Commits)
+	}
+	if change.PreviousOwner != "Test User" || change.PreviousCommits != 2 {
+		t.Errorf("Expected previous owner 'Test User' with 2 commits, got '%s' with %d", change.PreviousOwner, change.PreviousCommits)
+	}
+	if change.NewOwner != "Another User" || change.NewCommits != 1 {
+		t.Errorf("Expected new owner 'Another User' with 1 commit, got '%s' with %d", change.NewOwner, change.NewCommits)
+	}
+
+	// Without a start, the window spans the commits, however old
+	old := until.AddDate(-3, 0, 0)
+	for i := range fileHotspots {
+		for j := range fileHotspots[i].CommitDates {
+			if fileHotspots[i].CommitDates[j].Before(since.AddDate(0, 0, 50)) {
+				fileHotspots[i].CommitDates[j] = old
+			}
+		}
+	}
+	if changes := DetectOwnershipChanges(fileHotspots, time.Time{}, until); len(changes) != 1 || changes[0].Path != "handoff.go" {
+		t.Errorf("Expected handoff.go to change owner since its first commit, got %v", changes)
+	}
+}
@@ -0,0 +1,264 @@
+package git
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// BranchRefs returns the names of the refs the history is walked from with
+// AnalyzeOptions.AllRefs: HEAD, every local branch and, if remotes is set,
+// every remote-tracking branch. Symbolic refs such as refs/remotes/origin/HEAD
+// are left out, since the branches they point to are walked anyway.
+func BranchRefs(path string, remotes bool) ([]string, error) {
+	repo, err := openRepository(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+	refs, err := branchRefs(repo, remotes)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.Name().String()
+	}
+	return names, nil
+}
+
+// branchRefs returns HEAD, the local branches and, if remotes is set, the
+// remote-tracking branches of repo. The branch HEAD points to is only listed
+// once, under its own name.
+func branchRefs(repo *git.Repository, remotes bool) ([]*plumbing.Reference, error) {
+	var refs []*plumbing.Reference
+	head, err := repo.Head()
+	if err == nil {
+		refs = append(refs, head)
+	} else if err != plumbing.ErrReferenceNotFound {
+		return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+
+	iter, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || (head != nil && ref.Name() == head.Name()) {
+			return nil
+		}
+		if ref.Name().IsBranch() || (remotes && ref.Name().IsRemote()) {
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	return refs, nil
+}
+
+// ErrInvalidRef is returned for a ref name that isn't a plain branch or tag
+// name, a full ref name or a full commit hash, such as revision syntax.
+var ErrInvalidRef = errors.New("invalid ref")
+
+// ErrUnknownRef is returned for a valid ref name naming no commit.
+var ErrUnknownRef = errors.New("unknown ref")
+
+// ValidateRef checks that name can be given as AnalyzeOptions.Ref: a branch
+// or tag name such as main or v1.2, a full ref name such as refs/heads/main,
+// or a full commit hash. Revision syntax such as main~2, HEAD^ or @{-1} isn't
+// accepted, so untrusted names can't select arbitrary commits.
+func ValidateRef(name string) error {
+	if name == "" || name == string(plumbing.HEAD) || plumbing.IsHash(name) {
+		return nil
+	}
+	full := plumbing.ReferenceName(name)
+	if !strings.HasPrefix(name, "refs/") {
+		full = plumbing.NewBranchReferenceName(name)
+	}
+	if err := full.Validate(); err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidRef, name)
+	}
+	return nil
+}
+
+// ResolveRef returns the hash of the commit name points to in the repository
+// at path, per ValidateRef, or of HEAD if name is empty.
+func ResolveRef(path, name string) (string, error) {
+	repo, err := openRepository(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open git repository: %w", err)
+	}
+	hash, err := resolveRef(repo, name)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// resolveRef returns the hash of the commit name points to in repo, or of
+// HEAD if name is empty. Like git, it looks a plain name up as a tag, then a
+// branch, then a remote-tracking branch. Annotated tags are peeled to the
+// commit they tag.
+func resolveRef(repo *git.Repository, name string) (plumbing.Hash, error) {
+	if name == "" || name == string(plumbing.HEAD) {
+		head, err := repo.Head()
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to get HEAD reference: %w", err)
+		}
+		return head.Hash(), nil
+	}
+	if err := ValidateRef(name); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	hash := plumbing.NewHash(name)
+	if !plumbing.IsHash(name) {
+		candidates := []plumbing.ReferenceName{plumbing.ReferenceName(name)}
+		if !strings.HasPrefix(name, "refs/") {
+			candidates = []plumbing.ReferenceName{
+				plumbing.NewTagReferenceName(name),
+				plumbing.NewBranchReferenceName(name),
+				plumbing.ReferenceName("refs/remotes/" + name),
+			}
+		}
+		found := false
+		for _, candidate := range candidates {
+			ref, err := repo.Reference(candidate, true)
+			if err == plumbing.ErrReferenceNotFound {
+				continue
+			} else if err != nil {
+				return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: %w", candidate, err)
+			}
+			hash, found = ref.Hash(), true
+			break
+		}
+		if !found {
+			return plumbing.ZeroHash, fmt.Errorf("%w %q", ErrUnknownRef, name)
+		}
+	}
+
+	// Peel annotated tags, and make sure a hash names a commit
+	if tag, err := repo.TagObject(hash); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("%w %q: %v", ErrUnknownRef, name, err)
+		}
+		return commit.Hash, nil
+	}
+	if _, err := repo.CommitObject(hash); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("%w %q: %v", ErrUnknownRef, name, err)
+	}
+	return hash, nil
+}
+
+// logAllRefs returns an iterator over the commits reachable from any of refs,
+// each visited once however many refs reach it, like git log --branches.
+// Commits from every ref are interleaved newest first by committer time, so
+// the first ones are the most recent across all of them. Commits are
+// filtered like git.LogOptions filters them.
+func logAllRefs(repo *git.Repository, refs []*plumbing.Reference, logOptions *git.LogOptions) (object.CommitIter, error) {
+	var iter object.CommitIter
+	refsIter := &refsCommitIter{seen: make(map[plumbing.Hash]bool)}
+	for _, ref := range refs {
+		if refsIter.seen[ref.Hash()] {
+			continue
+		}
+		start, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit of %s: %w", ref.Name(), err)
+		}
+		refsIter.push(start)
+	}
+	iter = refsIter
+	if logOptions.PathFilter != nil {
+		iter = object.NewCommitPathIterFromIter(logOptions.PathFilter, iter, true)
+	}
+	if logOptions.Since != nil {
+		iter = object.NewCommitLimitIterFromIter(iter, object.LogLimitOptions{Since: logOptions.Since})
+	}
+	return iter, nil
+}
+
+// refsCommitIter walks the history from several commits at once, returning
+// the newest commit by committer time among those not yet returned whose
+// children were, and each commit once. Parents that can't be read, such as
+// those missing from a shallow clone, end the walk along them without
+// failing it; the commits they're parents of are reported for them when
+// their files are read.
+type refsCommitIter struct {
+	queue commitQueue
+	seen  map[plumbing.Hash]bool // Commits queued so far
+}
+
+// push queues c to be returned unless it already was.
+func (it *refsCommitIter) push(c *object.Commit) {
+	if it.seen[c.Hash] {
+		return
+	}
+	it.seen[c.Hash] = true
+	heap.Push(&it.queue, c)
+}
+
+func (it *refsCommitIter) Next() (*object.Commit, error) {
+	if it.queue.Len() == 0 {
+		return nil, io.EOF
+	}
+	c := heap.Pop(&it.queue).(*object.Commit)
+	for i := 0; i < c.NumParents(); i++ {
+		if parent, err := c.Parent(i); err == nil {
+			it.push(parent)
+		}
+	}
+	return c, nil
+}
+
+func (it *refsCommitIter) ForEach(cb func(*object.Commit) error) error {
+	return forEachCommit(it, cb)
+}
+
+func (it *refsCommitIter) Close() {
+	it.queue = nil
+}
+
+// commitQueue is a heap of commits, newest by committer time first.
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int           { return len(q) }
+func (q commitQueue) Less(i, j int) bool { return q[i].Committer.When.After(q[j].Committer.When) }
+func (q commitQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)        { *q = append(*q, x.(*object.Commit)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// forEachCommit calls cb for each commit of it until it's exhausted or cb
+// returns an error, treating storer.ErrStop as a request to stop.
+func forEachCommit(it object.CommitIter, cb func(*object.Commit) error) error {
+	defer it.Close()
+	for {
+		c, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := cb(c); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
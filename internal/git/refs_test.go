@@ -0,0 +1,105 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestValidateRef(t *testing.T) {
+	for _, name := range []string{"", "HEAD", "main", "feature/login", "v1.2.0", "refs/heads/main", "refs/remotes/origin/main", "0123456789abcdef0123456789abcdef01234567"} {
+		if err := ValidateRef(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	// Revision syntax and malformed names are rejected
+	for _, name := range []string{"main~2", "HEAD^", "@{-1}", "main..feature", "-main", "a b", "main:file.go", "refs/heads/", "../../etc/passwd", "main.lock"} {
+		if err := ValidateRef(name); !errors.Is(err, ErrInvalidRef) {
+			t.Errorf("Expected %q to be invalid, got %v", name, err)
+		}
+	}
+}
+
+func TestAnalyzeCommitsRef(t *testing.T) {
+	tmpDir := testutil.NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	// v1 tags the first commit, feature adds a commit HEAD doesn't have
+	now := time.Now()
+	testutil.CreateCommit(t, tmpDir, []string{"main.go"}, "Initial commit", now.Add(-3*time.Hour))
+	first, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	signature := &object.Signature{Name: "Test User", Email: "test@example.com", When: now}
+	if _, err := repo.CreateTag("v1", first.Hash(), &git.CreateTagOptions{Tagger: signature, Message: "v1"}); err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+	testutil.CreateCommit(t, tmpDir, []string{"util.go"}, "Add util", now.Add(-2*time.Hour))
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+		t.Fatalf("Failed to check out feature: %v", err)
+	}
+	testutil.CreateCommit(t, tmpDir, []string{"feature.go"}, "Add feature", now.Add(-time.Hour))
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: head.Name()}); err != nil {
+		t.Fatalf("Failed to check out %s: %v", head.Name(), err)
+	}
+
+	files := func(ref string) []string {
+		commits, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Ref: ref})
+		if err != nil {
+			t.Fatalf("AnalyzeCommitsWithOptions(%q) failed: %v", ref, err)
+		}
+		var files []string
+		for _, commit := range commits {
+			files = append(files, commit.Files...)
+		}
+		return files
+	}
+	tests := []struct {
+		ref   string
+		files []string
+	}{
+		{"", []string{"util.go", "main.go"}},
+		{"feature", []string{"feature.go", "util.go", "main.go"}},
+		{"refs/heads/feature", []string{"feature.go", "util.go", "main.go"}},
+		{"v1", []string{"main.go"}}, // Annotated tags are peeled
+		{first.Hash().String(), []string{"main.go"}},
+	}
+	for _, tt := range tests {
+		if got := files(tt.ref); !reflect.DeepEqual(got, tt.files) {
+			t.Errorf("Expected %v from %q, got %v", tt.files, tt.ref, got)
+		}
+	}
+
+	// Names and hashes naming no commit are unknown
+	for _, ref := range []string{"missing", "0123456789abcdef0123456789abcdef01234567"} {
+		if _, err := AnalyzeCommitsWithOptions(tmpDir, AnalyzeOptions{Ref: ref}); !errors.Is(err, ErrUnknownRef) {
+			t.Errorf("Expected %q to be unknown, got %v", ref, err)
+		}
+	}
+	if hash, err := ResolveRef(tmpDir, "feature~1"); !errors.Is(err, ErrInvalidRef) {
+		t.Errorf("Expected revision syntax to be rejected, got %q, %v", hash, err)
+	}
+}
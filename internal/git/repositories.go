@@ -0,0 +1,104 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
+// RepositoryCommits holds the commits analyzed for one of several repositories.
+type RepositoryCommits struct {
+	// Name identifies the repository in reports, usually the base name of its root.
+	Name    string
+	Root    string
+	Commits []CommitInfo
+	Issues  CommitIssues // Issues of the commits that couldn't be fully read
+}
+
+// RepositoryError records why a repository couldn't be analyzed.
+type RepositoryError struct {
+	Path string
+	Err  error
+}
+
+func (e *RepositoryError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *RepositoryError) Unwrap() error {
+	return e.Err
+}
+
+// AnalyzeRepositories analyzes each of the given repositories with the same options.
+// A repository that can't be analyzed doesn't stop the others; its failure is
+// returned as a *RepositoryError instead.
+func AnalyzeRepositories(paths []string, opts AnalyzeOptions) ([]RepositoryCommits, []error) {
+	var repos []RepositoryCommits
+	var errs []error
+	names := make(map[string]bool)
+
+	for _, repoPath := range paths {
+		root, err := RepositoryRoot(repoPath)
+		if err != nil {
+			errs = append(errs, &RepositoryError{Path: repoPath, Err: err})
+			continue
+		}
+
+		// Count each repository's issues, still passing them on
+		var issues CommitIssues
+		repoOpts := opts
+		repoOpts.OnCommitIssue = func(commit CommitInfo, issue CommitIssue) {
+			issues.Add(issue)
+			if opts.OnCommitIssue != nil {
+				opts.OnCommitIssue(commit, issue)
+			}
+		}
+		commits, err := AnalyzeCommitsWithOptions(root, repoOpts)
+		if err != nil {
+			errs = append(errs, &RepositoryError{Path: repoPath, Err: err})
+			continue
+		}
+
+		// Fall back to the full root when two repositories share a base name
+		name := filepath.Base(root)
+		if names[name] {
+			name = filepath.ToSlash(root)
+		}
+		names[name] = true
+
+		repos = append(repos, RepositoryCommits{Name: name, Root: root, Commits: commits, Issues: issues})
+	}
+
+	return repos, errs
+}
+
+// MergeRepositories combines the commits of several repositories into one list,
+// qualifying every file path with the name of its repository.
+func MergeRepositories(repos []RepositoryCommits) []CommitInfo {
+	var merged []CommitInfo
+	for _, repo := range repos {
+		for _, commit := range repo.Commits {
+			files := make([]string, len(commit.Files))
+			for i, file := range commit.Files {
+				files[i] = path.Join(repo.Name, file)
+			}
+			commit.Files = files
+			if commit.Lines != nil {
+				lines := make(map[string]LineChanges, len(commit.Lines))
+				for file, changes := range commit.Lines {
+					lines[path.Join(repo.Name, file)] = changes
+				}
+				commit.Lines = lines
+			}
+			if commit.Actions != nil {
+				actions := make(map[string]FileAction, len(commit.Actions))
+				for file, action := range commit.Actions {
+					actions[path.Join(repo.Name, file)] = action
+				}
+				commit.Actions = actions
+			}
+			merged = append(merged, commit)
+		}
+	}
+	return merged
+}
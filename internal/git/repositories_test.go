@@ -0,0 +1,82 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/testutil"
+)
+
+func TestAnalyzeRepositories(t *testing.T) {
+	repoPath := testutil.NewRepo(t)
+	defer os.RemoveAll(repoPath)
+	testutil.CreateCommit(t, repoPath, []string{"main.go"}, "Initial commit", time.Now())
+
+	notARepo := t.TempDir()
+
+	repos, errs := AnalyzeRepositories([]string{repoPath, notARepo}, AnalyzeOptions{})
+
+	// The valid repository is analyzed despite the failure
+	if len(repos) != 1 {
+		t.Fatalf("Expected 1 analyzed repository, got %d", len(repos))
+	}
+	if repos[0].Name != filepath.Base(repoPath) || len(repos[0].Commits) != 1 {
+		t.Errorf("Expected %s with 1 commit, got %s with %d", filepath.Base(repoPath), repos[0].Name, len(repos[0].Commits))
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %d", len(errs))
+	}
+	var repoErr *RepositoryError
+	if !errors.As(errs[0], &repoErr) || repoErr.Path != notARepo {
+		t.Errorf("Expected a RepositoryError for %s, got %v", notARepo, errs[0])
+	}
+}
+
+func TestMergeRepositories(t *testing.T) {
+	repos := []RepositoryCommits{
+		{Name: "api", Commits: []CommitInfo{{
+			Hash:    "hash1",
+			Files:   []string{"main.go", "src/server.go"},
+			Lines:   map[string]LineChanges{"main.go": {Added: 5, Deleted: 1}},
+			Actions: map[string]FileAction{"src/server.go": FileDeleted},
+		}}},
+		{Name: "web", Commits: []CommitInfo{{Hash: "hash2", Files: []string{"main.go"}}}},
+	}
+
+	merged := MergeRepositories(repos)
+	if len(merged) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(merged))
+	}
+
+	// Files with the same path in different repositories stay distinct
+	expected := [][]string{{"api/main.go", "api/src/server.go"}, {"web/main.go"}}
+	for i, commit := range merged {
+		if len(commit.Files) != len(expected[i]) {
+			t.Fatalf("Expected files %v, got %v", expected[i], commit.Files)
+		}
+		for j, file := range commit.Files {
+			if file != expected[i][j] {
+				t.Errorf("Expected file %s, got %s", expected[i][j], file)
+			}
+		}
+	}
+
+	// Lines changed and actions are keyed by the qualified paths
+	if want := map[string]LineChanges{"api/main.go": {Added: 5, Deleted: 1}}; !reflect.DeepEqual(merged[0].Lines, want) {
+		t.Errorf("Expected lines %v, got %v", want, merged[0].Lines)
+	}
+
+	if want := map[string]FileAction{"api/src/server.go": FileDeleted}; !reflect.DeepEqual(merged[0].Actions, want) {
+		t.Errorf("Expected actions %v, got %v", want, merged[0].Actions)
+	}
+
+	// The input commits aren't modified
+	if repos[0].Commits[0].Files[0] != "main.go" {
+		t.Errorf("Expected original files to be unchanged, got %v", repos[0].Commits[0].Files)
+	}
+}
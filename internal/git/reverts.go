@@ -0,0 +1,14 @@
+package git
+
+import "regexp"
+
+// revertPattern matches the messages git revert writes: a subject like
+// `Revert "Add feature"` and a body line like "This reverts commit abc1234.".
+var revertPattern = regexp.MustCompile(`(?m)^(?:Revert "|This reverts commit [0-9a-f]{7,40}\b)`)
+
+// IsRevert reports whether message is that of a commit reverting an earlier
+// one. Only the messages git revert writes are recognized, so reverts made by
+// hand, squashed into other commits or with reworded messages are missed.
+func IsRevert(message string) bool {
+	return revertPattern.MatchString(message)
+}
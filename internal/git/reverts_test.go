@@ -0,0 +1,42 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsRevert(t *testing.T) {
+	for message, want := range map[string]bool{
+		"Revert \"Add caching\"\n\nThis reverts commit 0123456789abcdef0123456789abcdef01234567.": true,
+		"Undo caching\n\nThis reverts commit 0123abc.":                                            true,
+		"Revert \"Revert \\\"Add caching\\\"\"":                                                   true,
+		"Add a revert button":                                                                     false,
+		"Reverting is hard":                                                                       false,
+	} {
+		if got := IsRevert(message); got != want {
+			t.Errorf("IsRevert(%q) = %v, want %v", message, got, want)
+		}
+	}
+}
+
+func TestRankByReverts(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "1", Author: "Test User", Date: now, Message: "Add caching", Files: []string{"pkg/a.go", "b.go"}},
+		{Hash: "2", Author: "Test User", Date: now, Message: "Revert \"Add caching\"", Files: []string{"pkg/a.go"}},
+		{Hash: "3", Author: "Test User", Date: now, Message: "Tweak b", Files: []string{"b.go"}},
+		{Hash: "4", Author: "Test User", Date: now, Message: "Tweak b again", Files: []string{"b.go"}},
+	}
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, HotspotOptions{RankBy: RankByReverts})
+	SortHotspots(fileHotspots)
+
+	if fileHotspots[0].Path != "pkg/a.go" || fileHotspots[0].Reverts != 1 || fileHotspots[0].Score != 1 {
+		t.Errorf("Expected pkg/a.go with 1 revert first, got %+v", fileHotspots[0])
+	}
+	if fileHotspots[1].Path != "b.go" || fileHotspots[1].Reverts != 0 || fileHotspots[1].Score != 0 {
+		t.Errorf("Expected b.go with no reverts second, got %+v", fileHotspots[1])
+	}
+	if len(dirHotspots) != 1 || dirHotspots[0].Reverts != 1 {
+		t.Errorf("Expected pkg with 1 revert, got %+v", dirHotspots)
+	}
+}
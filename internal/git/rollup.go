@@ -0,0 +1,121 @@
+package git
+
+import (
+	"slices"
+	"sort"
+)
+
+// RollUpDirs returns the directory hotspots with those nested deeper than
+// depth levels folded into their ancestor at that depth, to show deeply
+// nested repositories at a readable depth without changing how they were
+// analyzed. The counts and scores of folded directories are summed, so a
+// commit touching several of them counts once for each, and their
+// contributors are merged, breaking ties by name. Sole ownership is marked
+// again per opts.OwnerThreshold. Hotspots are returned unsorted, and dirs
+// is left as it is. A depth of zero returns dirs.
+func RollUpDirs(dirs []Hotspot, depth int, opts HotspotOptions) []Hotspot {
+	if depth <= 0 {
+		return dirs
+	}
+
+	var result []Hotspot
+	index := make(map[string]int)
+	folded := make(map[int]bool)
+	emails := make(map[string]string)
+	for _, h := range dirs {
+		if h.TopContributorEmail != "" {
+			emails[h.TopContributor] = h.TopContributorEmail
+		}
+		ancestor := truncateDir(h.Path, depth)
+		i, ok := index[ancestor]
+		if !ok {
+			index[ancestor] = len(result)
+			h.Path = ancestor
+			h.Rank = 0
+			result = append(result, h)
+			continue
+		}
+		result[i] = foldHotspot(result[i], h)
+		folded[i] = true
+	}
+
+	// Rank the merged contributors of folded directories
+	for i := range folded {
+		h := &result[i]
+		sort.Slice(h.Contributors, func(i, j int) bool {
+			if h.Contributors[i].Commits != h.Contributors[j].Commits {
+				return h.Contributors[i].Commits > h.Contributors[j].Commits
+			}
+			return h.Contributors[i].Author < h.Contributors[j].Author
+		})
+		h.TopContributor, h.AuthorCommits = "", 0
+		if len(h.Contributors) > 0 {
+			h.TopContributor, h.AuthorCommits = h.Contributors[0].Author, h.Contributors[0].Commits
+		}
+		h.TopContributorEmail = emails[h.TopContributor]
+		h.Concentration = concentration(h.Contributors)
+		h.SoleOwned = false
+		if opts.OwnerThreshold > 0 {
+			markSoleOwned(result[i:i+1], opts.OwnerThreshold)
+		}
+	}
+	return result
+}
+
+// foldHotspot returns into with the counts, dates, commits and contributors
+// of h added, in new slices so those of into aren't modified.
+func foldHotspot(into, h Hotspot) Hotspot {
+	into.Commits += h.Commits
+	into.Score += h.Score
+	into.LinesAdded += h.LinesAdded
+	into.LinesDeleted += h.LinesDeleted
+	into.Defects += h.Defects
+	into.Reverts += h.Reverts
+	into.Deletions += h.Deletions
+	into.TestFiles += h.TestFiles
+	if into.FirstSeen.IsZero() || (!h.FirstSeen.IsZero() && h.FirstSeen.Before(into.FirstSeen)) {
+		into.FirstSeen = h.FirstSeen
+	}
+	if h.LastModified.After(into.LastModified) {
+		into.LastModified = h.LastModified
+	}
+	into.CommitDates = slices.Concat(into.CommitDates, h.CommitDates)
+	into.CommitAuthors = slices.Concat(into.CommitAuthors, h.CommitAuthors)
+	into.History = slices.Concat(into.History, h.History)
+
+	if len(h.Activity) == len(into.Activity) {
+		activity := make([]int, len(into.Activity))
+		for i := range activity {
+			activity[i] = into.Activity[i] + h.Activity[i]
+		}
+		into.Activity = activity
+	}
+
+	commits := make(map[string]int)
+	var contributors []Contributor
+	for _, c := range slices.Concat(into.Contributors, h.Contributors) {
+		if _, ok := commits[c.Author]; !ok {
+			contributors = append(contributors, Contributor{Author: c.Author})
+		}
+		commits[c.Author] += c.Commits
+	}
+	for i, c := range contributors {
+		contributors[i].Commits = commits[c.Author]
+	}
+	into.Contributors = contributors
+	return into
+}
+
+// truncateDir returns the ancestor of the slash-separated dir at depth, or
+// dir itself if it isn't nested that deep.
+func truncateDir(dir string, depth int) string {
+	for i := 0; i < len(dir); i++ {
+		if dir[i] != '/' {
+			continue
+		}
+		if depth--; depth == 0 {
+			return dir[:i]
+		}
+	}
+	return dir
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRollUpDirs(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Alice", Date: now.Add(-3 * time.Hour), Files: []string{"a/b/c/x.go", "a/b/d/y.go"}},
+		{Hash: "hash2", Author: "Bob", Date: now.Add(-2 * time.Hour), Files: []string{"a/b/c/d/z.go"}},
+		{Hash: "hash3", Author: "Alice", Date: now.Add(-time.Hour), Files: []string{"a/w.go", "a/b/v.go"}},
+	}
+	opts := HotspotOptions{OwnerThreshold: 0.6}
+	_, dirs := IdentifyHotspotsWithOptions(commits, opts)
+	analyzed := append([]Hotspot(nil), dirs...)
+
+	// Deeper directories are folded into their ancestor at the depth,
+	// summing their counts, so hash1 counts for both a/b/c and a/b/d, and
+	// shallower ones are kept as they are
+	rolled := RollUpDirs(dirs, 2, opts)
+	byPath := make(map[string]Hotspot)
+	for _, h := range rolled {
+		byPath[h.Path] = h
+	}
+	if len(byPath) != 2 {
+		t.Fatalf("Expected directories a and a/b, got %+v", rolled)
+	}
+	ab := byPath["a/b"]
+	if ab.Commits != 4 || !ab.FirstSeen.Equal(now.Add(-3*time.Hour)) || !ab.LastModified.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected 4 commits to a/b from 3 to 1 hours ago, got %+v", ab)
+	}
+	if want := []Contributor{{Author: "Alice", Commits: 3}, {Author: "Bob", Commits: 1}}; !reflect.DeepEqual(ab.Contributors, want) {
+		t.Errorf("Expected contributors %v, got %v", want, ab.Contributors)
+	}
+	if ab.TopContributor != "Alice" || ab.AuthorCommits != 3 || !ab.SoleOwned {
+		t.Errorf("Expected Alice to own a/b with 3 commits, got %+v", ab)
+	}
+	if a := byPath["a"]; a.Commits != 1 || a.TopContributor != "Alice" {
+		t.Errorf("Expected a to keep its commit, got %+v", a)
+	}
+
+	// The analyzed hotspots aren't changed, and depth 0 keeps them
+	if !reflect.DeepEqual(dirs, analyzed) {
+		t.Errorf("Expected the analyzed directories to be unchanged, got %+v", dirs)
+	}
+	if got := RollUpDirs(dirs, 0, opts); !reflect.DeepEqual(got, dirs) {
+		t.Errorf("Expected depth 0 to keep every directory, got %+v", got)
+	}
+}
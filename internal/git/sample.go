@@ -0,0 +1,62 @@
+package git
+
+import (
+	"math"
+	"math/rand"
+)
+
+// sampler returns a function reporting whether to analyze the next commit,
+// keeping a random opts.Sample of them. The same seed picks the same commits
+// of the same history. Every commit is kept if Sample is zero or at least 1.
+func (opts AnalyzeOptions) sampler() func() bool {
+	if opts.Sample <= 0 || opts.Sample >= 1 {
+		return func() bool { return true }
+	}
+	r := rand.New(rand.NewSource(opts.Seed))
+	return func() bool {
+		return r.Float64() < opts.Sample
+	}
+}
+
+// limiter returns a function reporting whether the walk may go on to the next
+// commit, stopping it after the opts.LimitCommits most recent ones and
+// calling opts.OnLimitReached if older commits were left. Every commit is
+// walked if LimitCommits is zero.
+func (opts AnalyzeOptions) limiter() func() bool {
+	if opts.LimitCommits <= 0 {
+		return func() bool { return true }
+	}
+	walked := 0
+	return func() bool {
+		if walked++; walked <= opts.LimitCommits {
+			return true
+		}
+		if opts.OnLimitReached != nil {
+			opts.OnLimitReached()
+		}
+		return false
+	}
+}
+
+// extrapolate scales the counts of hotspots up by 1/sample, the fraction of
+// commits they were counted from, to estimate them for every commit.
+func extrapolate(hotspots []Hotspot, sample float64) {
+	scale := func(n int) int {
+		return int(math.Round(float64(n) / sample))
+	}
+	for i := range hotspots {
+		h := &hotspots[i]
+		h.Commits = scale(h.Commits)
+		h.Score /= sample
+		h.AuthorCommits = scale(h.AuthorCommits)
+		h.LinesAdded = scale(h.LinesAdded)
+		h.LinesDeleted = scale(h.LinesDeleted)
+		h.Defects = scale(h.Defects)
+		h.Reverts = scale(h.Reverts)
+		h.Deletions = scale(h.Deletions)
+		h.TestFiles = scale(h.TestFiles)
+		for j := range h.Contributors {
+			h.Contributors[j].Commits = scale(h.Contributors[j].Commits)
+		}
+	}
+}
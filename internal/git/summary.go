@@ -0,0 +1,60 @@
+package git
+
+import "time"
+
+// Summary describes the commits hotspots were identified from.
+type Summary struct {
+	Commits     int       // Number of commits analyzed
+	Authors     int       // Number of distinct commit authors
+	Files       int       // Number of distinct files touched
+	FirstCommit time.Time // Date of the earliest commit, zero without commits
+	LastCommit  time.Time // Date of the latest commit, zero without commits
+}
+
+// Summarize returns the summary of commits.
+func Summarize(commits []CommitInfo) Summary {
+	var s Summarizer
+	for _, commit := range commits {
+		s.Add(commit)
+	}
+	return s.Summary()
+}
+
+// Summarizer builds a Summary from commits added one at a time, so totals
+// can be computed while streaming commits. The zero value is ready to use.
+type Summarizer struct {
+	summary Summary
+	authors map[string]bool
+	files   map[string]bool
+}
+
+// Add adds commit to the summary.
+func (s *Summarizer) Add(commit CommitInfo) {
+	if s.authors == nil {
+		s.authors = make(map[string]bool)
+		s.files = make(map[string]bool)
+	}
+	if !s.authors[commit.Author] {
+		s.authors[commit.Author] = true
+		s.summary.Authors++
+	}
+	for _, file := range commit.Files {
+		if !s.files[file] {
+			s.files[file] = true
+			s.summary.Files++
+		}
+	}
+
+	s.summary.Commits++
+	if s.summary.Commits == 1 || commit.Date.Before(s.summary.FirstCommit) {
+		s.summary.FirstCommit = commit.Date
+	}
+	if commit.Date.After(s.summary.LastCommit) {
+		s.summary.LastCommit = commit.Date
+	}
+}
+
+// Summary returns the summary of the commits added so far.
+func (s *Summarizer) Summary() Summary {
+	return s.summary
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// conventionalCommitPattern matches the type of a Conventional Commits
+// subject such as "fix(parser)!: handle empty input", capturing "fix".
+var conventionalCommitPattern = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?!?:`)
+
+// TagWeights weights commits by the tag of their message, such as "fix" or
+// "feat", when ranking by RankByWeighted.
+type TagWeights struct {
+	// Weights maps lower-case tags to weights. Commits with other tags, or
+	// none, weigh 1.
+	Weights map[string]float64
+
+	// Pattern extracts the tag from the subject of a commit message as its
+	// first capture group. If nil, Conventional Commits prefixes are used.
+	Pattern *regexp.Regexp
+}
+
+// ParseTagWeights parses weights given as comma-separated tag=weight pairs,
+// e.g. "fix=3,feat=1".
+func ParseTagWeights(s string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		tag, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(tag) == "" {
+			return nil, fmt.Errorf("invalid weight %q (expected tag=weight)", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for tag %q", value, tag)
+		}
+		weights[strings.ToLower(strings.TrimSpace(tag))] = weight
+	}
+	return weights, nil
+}
+
+// CommitTag returns the lower-case tag found by pattern in the subject of a
+// commit message, or its Conventional Commits prefix if pattern is nil. It
+// returns an empty string if the subject has no tag.
+func CommitTag(message string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		pattern = conventionalCommitPattern
+	}
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	match := pattern.FindStringSubmatch(subject)
+	if len(match) < 2 {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
+
+// weight returns the weight of a commit with the given message.
+func (w *TagWeights) weight(message string) float64 {
+	if w == nil {
+		return 1
+	}
+	if weight, ok := w.Weights[CommitTag(message, w.Pattern)]; ok {
+		return weight
+	}
+	return 1
+}
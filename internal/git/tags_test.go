@@ -0,0 +1,70 @@
+package git
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCommitTag(t *testing.T) {
+	tests := []struct {
+		message string
+		pattern *regexp.Regexp
+		want    string
+	}{
+		{"fix: handle empty input", nil, "fix"},
+		{"Feat(parser)!: new syntax\n\nBody", nil, "feat"},
+		{"Update README", nil, ""},
+		{"fix handle empty input", nil, ""},
+		{"[BUG] crash on start", regexp.MustCompile(`^\[(\w+)\]`), "bug"},
+		{"crash on start", regexp.MustCompile(`^\[(\w+)\]`), ""},
+	}
+	for _, tt := range tests {
+		if got := CommitTag(tt.message, tt.pattern); got != tt.want {
+			t.Errorf("CommitTag(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestParseTagWeights(t *testing.T) {
+	weights, err := ParseTagWeights("fix=3, Feat=0.5")
+	if err != nil {
+		t.Fatalf("ParseTagWeights failed: %v", err)
+	}
+	if expected := map[string]float64{"fix": 3, "feat": 0.5}; !reflect.DeepEqual(weights, expected) {
+		t.Errorf("Expected %v, got %v", expected, weights)
+	}
+
+	for _, invalid := range []string{"fix", "fix=x", "=2", "fix=-1"} {
+		if _, err := ParseTagWeights(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestIdentifyHotspotsWeighted(t *testing.T) {
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "1", Author: "Test User", Date: now, Message: "fix: crash", Files: []string{"src/bug.go"}},
+		{Hash: "2", Author: "Test User", Date: now, Message: "feat: new thing", Files: []string{"src/feature.go", "src/bug.go"}},
+		{Hash: "3", Author: "Test User", Date: now, Message: "feat: more", Files: []string{"src/feature.go"}},
+		{Hash: "4", Author: "Test User", Date: now, Message: "Tidy up", Files: []string{"src/feature.go"}},
+	}
+	opts := HotspotOptions{
+		RankBy:     RankByWeighted,
+		TagWeights: &TagWeights{Weights: map[string]float64{"fix": 3, "feat": 0.5}},
+	}
+
+	fileHotspots, dirHotspots := IdentifyHotspotsWithOptions(commits, opts)
+	scores := make(map[string]float64)
+	for _, h := range append(fileHotspots, dirHotspots...) {
+		scores[h.Path] = h.Score
+	}
+
+	// Untagged commits weigh 1
+	expected := map[string]float64{"src/bug.go": 3.5, "src/feature.go": 2, "src": 5}
+	if !reflect.DeepEqual(scores, expected) {
+		t.Errorf("Expected scores %v, got %v", expected, scores)
+	}
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// languageTestPatterns maps the languages of languageExtensions to globs
+// matching their test files by the conventions of their usual tools, in the
+// syntax of ComponentRule.Glob. Languages without a common convention have
+// none.
+var languageTestPatterns = map[string][]string{
+	"c":          {"**/test_*.c", "**/*_test.c"},
+	"cpp":        {"**/*_test.cc", "**/*_test.cpp", "**/*_unittest.cc", "**/*_unittest.cpp"},
+	"csharp":     {"**/*Test.cs", "**/*Tests.cs"},
+	"go":         {"**/*_test.go", "**/testdata"},
+	"java":       {"**/src/test", "**/*Test.java", "**/*Tests.java"},
+	"javascript": {"**/__tests__", "**/*.test.js", "**/*.spec.js", "**/*.test.jsx", "**/*.spec.jsx"},
+	"kotlin":     {"**/src/test", "**/*Test.kt"},
+	"php":        {"**/*Test.php"},
+	"python":     {"**/test_*.py", "**/*_test.py", "**/conftest.py"},
+	"ruby":       {"**/spec", "**/*_spec.rb", "**/*_test.rb"},
+	"rust":       {"**/tests/**/*.rs"},
+	"scala":      {"**/src/test", "**/*Spec.scala", "**/*Test.scala"},
+	"swift":      {"**/*Tests.swift"},
+	"typescript": {"**/__tests__", "**/*.test.ts", "**/*.spec.ts", "**/*.test.tsx", "**/*.spec.tsx"},
+}
+
+// TestPatterns matches test files, such as foo_test.go, so they can be left
+// out of hotspots and production code churn stands out.
+type TestPatterns struct {
+	globs [][]string // Slash-separated segments of each glob
+}
+
+// NewTestPatterns returns the patterns matching the test files of the given
+// languages, or of every language if none are given, and the extra globs, in
+// the syntax of ComponentRule.Glob. Language names are case-insensitive.
+func NewTestPatterns(languages []string, extra []string) (*TestPatterns, error) {
+	if len(languages) == 0 {
+		languages = Languages()
+	}
+	var globs []string
+	for _, language := range languages {
+		name := strings.ToLower(strings.TrimSpace(language))
+		if _, ok := languageExtensions[name]; !ok {
+			return nil, fmt.Errorf("unknown language %q (supported: %s)", language, strings.Join(Languages(), ", "))
+		}
+		globs = append(globs, languageTestPatterns[name]...)
+	}
+	globs = append(globs, extra...)
+
+	patterns := &TestPatterns{}
+	for _, glob := range globs {
+		segments := strings.Split(glob, "/")
+		for _, segment := range segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid test pattern %q: %w", glob, err)
+			}
+		}
+		patterns.globs = append(patterns.globs, segments)
+	}
+	return patterns, nil
+}
+
+// Matches reports whether the file at the slash-separated path is a test
+// file. A nil TestPatterns matches nothing.
+func (p *TestPatterns) Matches(file string) bool {
+	if p == nil {
+		return false
+	}
+	segments := strings.Split(file, "/")
+	for _, glob := range p.globs {
+		if matchGlob(glob, segments) {
+			return true
+		}
+	}
+	return false
+}
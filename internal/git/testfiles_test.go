@@ -0,0 +1,96 @@
+package git
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTestPatterns(t *testing.T) {
+	patterns, err := NewTestPatterns(nil, []string{"**/fixtures", "e2e/**"})
+	if err != nil {
+		t.Fatalf("NewTestPatterns failed: %v", err)
+	}
+	for file, want := range map[string]bool{
+		"main.go":                          false,
+		"main_test.go":                     true,
+		"internal/git/git_test.go":         true,
+		"internal/git/testdata/repo.txt":   true,
+		"app/test_views.py":                true,
+		"app/views.py":                     false,
+		"web/src/__tests__/app.tsx":        true,
+		"web/src/app.spec.ts":              true,
+		"src/test/java/FooTest.java":       true,
+		"src/main/java/Foo.java":           false,
+		"lib/models/user_spec.rb":          true,
+		"crate/tests/integration/smoke.rs": true,
+		"crate/src/lib.rs":                 false,
+		"api/fixtures/user.json":           true,
+		"e2e/login.js":                     true,
+		"docs/testing.md":                  false,
+	} {
+		if got := patterns.Matches(file); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", file, got, want)
+		}
+	}
+
+	// Only the patterns of the given languages apply
+	goOnly, err := NewTestPatterns([]string{"Go"}, nil)
+	if err != nil {
+		t.Fatalf("NewTestPatterns failed: %v", err)
+	}
+	if !goOnly.Matches("pkg/a_test.go") || goOnly.Matches("app/test_views.py") {
+		t.Errorf("Expected only Go test files to match")
+	}
+	var nilPatterns *TestPatterns
+	if nilPatterns.Matches("main_test.go") {
+		t.Errorf("Expected nil TestPatterns to match nothing")
+	}
+
+	if _, err := NewTestPatterns([]string{"cobol"}, nil); err == nil || !strings.Contains(err.Error(), `unknown language "cobol"`) {
+		t.Errorf("Expected an error for an unknown language, got %v", err)
+	}
+	if _, err := NewTestPatterns(nil, []string{"[tests"}); err == nil || !strings.Contains(err.Error(), `invalid test pattern "[tests"`) {
+		t.Errorf("Expected an error for an invalid glob, got %v", err)
+	}
+}
+
+func TestIdentifyHotspotsExcludeTests(t *testing.T) {
+	patterns, err := NewTestPatterns([]string{"go"}, nil)
+	if err != nil {
+		t.Fatalf("NewTestPatterns failed: %v", err)
+	}
+	now := time.Now()
+	commits := []CommitInfo{
+		{Hash: "hash1", Author: "Test User", Date: now, Files: []string{"pkg/a.go", "pkg/a_test.go"}},
+		{Hash: "hash2", Author: "Test User", Date: now, Files: []string{"pkg/a_test.go", "pkg/b_test.go"}},
+		{Hash: "hash3", Author: "Test User", Date: now, Files: []string{"pkg/a.go", "cmd/main.go"}},
+	}
+
+	// Commits only changing tests are skipped, and the test files changed
+	// are counted per directory instead
+	files, dirs := IdentifyHotspotsWithOptions(commits, HotspotOptions{ExcludeTests: patterns, NormalizeByCommitSize: true})
+	if len(files) != 2 {
+		t.Errorf("Expected pkg/a.go and cmd/main.go, got %v", files)
+	}
+	for _, f := range files {
+		if f.Path == "pkg/a.go" && (f.Commits != 2 || f.Score != 1.5) {
+			t.Errorf("Expected pkg/a.go to have 2 commits scoring 1.5 without its tests, got %d and %v", f.Commits, f.Score)
+		}
+	}
+	expected := map[string][2]int{"pkg": {2, 3}, "cmd": {1, 0}}
+	if len(dirs) != len(expected) {
+		t.Fatalf("Expected directories %v, got %v", expected, dirs)
+	}
+	for _, dir := range dirs {
+		if got := [2]int{dir.Commits, dir.TestFiles}; got != expected[dir.Path] {
+			t.Errorf("Expected %s to have commits and test files %v, got %v", dir.Path, expected[dir.Path], got)
+		}
+	}
+
+	// Untouched test files aren't added either
+	files, _ = AddUnchanged(nil, nil, []string{"pkg/c.go", "pkg/c_test.go"}, HotspotOptions{ExcludeTests: patterns})
+	if len(files) != 1 || files[0].Path != "pkg/c.go" {
+		t.Errorf("Expected only pkg/c.go added, got %v", files)
+	}
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"sort"
+	"strings"
+)
+
+// HotspotTreeNode is a directory or file in the hotspot tree. A file holds
+// the counts of its hotspot, and a directory the sums of those of every file
+// underneath it, so a commit touching two of its files counts twice.
+type HotspotTreeNode struct {
+	Name         string
+	Path         string
+	File         bool
+	Commits      int
+	LinesAdded   int
+	LinesDeleted int
+	Score        float64
+	Children     []*HotspotTreeNode // Ordered by score, hottest first
+
+	children map[treeKey]*HotspotTreeNode // Children by name and kind
+}
+
+// treeKey identifies a child of a hotspot tree node. A file and a directory
+// may share a name, such as a file replaced by a directory over the window.
+type treeKey struct {
+	name string
+	file bool
+}
+
+// BuildHotspotTree nests file hotspots under their directories in a tree
+// rooted at ".", ranking directories and files together as one hierarchy.
+func BuildHotspotTree(files []Hotspot) *HotspotTreeNode {
+	root := &HotspotTreeNode{Name: ".", Path: "."}
+	for _, h := range files {
+		node := root
+		node.addCounts(h)
+		parts := strings.Split(h.Path, "/")
+		for _, part := range parts[:len(parts)-1] {
+			node = node.child(part, false)
+			node.addCounts(h)
+		}
+		node.child(parts[len(parts)-1], true).addCounts(h)
+	}
+	root.sortChildren()
+	return root
+}
+
+// child returns the named child, creating it as a file or directory if
+// needed.
+func (n *HotspotTreeNode) child(name string, file bool) *HotspotTreeNode {
+	key := treeKey{name: name, file: file}
+	if c, ok := n.children[key]; ok {
+		return c
+	}
+
+	path := name
+	if n.Path != "." {
+		path = n.Path + "/" + name
+	}
+	c := &HotspotTreeNode{Name: name, Path: path, File: file}
+	if n.children == nil {
+		n.children = make(map[treeKey]*HotspotTreeNode)
+	}
+	n.children[key] = c
+	n.Children = append(n.Children, c)
+	return c
+}
+
+func (n *HotspotTreeNode) addCounts(h Hotspot) {
+	n.Commits += h.Commits
+	n.LinesAdded += h.LinesAdded
+	n.LinesDeleted += h.LinesDeleted
+	n.Score += h.Score
+}
+
+// sortChildren orders the children of the node and its descendants by score,
+// then commits, then name.
+func (n *HotspotTreeNode) sortChildren() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Name < b.Name
+	})
+	for _, c := range n.Children {
+		c.sortChildren()
+	}
+}
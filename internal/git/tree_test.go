@@ -0,0 +1,38 @@
+package git
+
+import "testing"
+
+func TestBuildHotspotTree(t *testing.T) {
+	files := []Hotspot{
+		{Path: "README.md", Commits: 1, Score: 1},
+		{Path: "src/app/main.go", Commits: 4, Score: 4, LinesAdded: 10},
+		{Path: "src/app/util.go", Commits: 2, Score: 2, LinesDeleted: 3},
+		{Path: "src/lib/lib.go", Commits: 5, Score: 5},
+	}
+
+	root := BuildHotspotTree(files)
+
+	// Counts add up from files to every directory above them
+	if root.Commits != 12 || root.Score != 12 || root.LinesAdded != 10 || root.LinesDeleted != 3 {
+		t.Errorf("Expected root to sum all files, got %+v", root)
+	}
+	if len(root.Children) != 2 {
+		t.Fatalf("Expected src and README.md under the root, got %d children", len(root.Children))
+	}
+
+	// Directories and files are ranked together by score
+	src, readme := root.Children[0], root.Children[1]
+	if src.Path != "src" || src.File || src.Commits != 11 {
+		t.Errorf("Expected src directory with 11 commits first, got %+v", src)
+	}
+	if readme.Path != "README.md" || !readme.File || len(readme.Children) != 0 {
+		t.Errorf("Expected README.md file last, got %+v", readme)
+	}
+	app, lib := src.Children[0], src.Children[1]
+	if app.Path != "src/app" || app.Score != 6 || lib.Path != "src/lib" || lib.Score != 5 {
+		t.Errorf("Expected src/app scoring 6 before src/lib scoring 5, got %s %v and %s %v", app.Path, app.Score, lib.Path, lib.Score)
+	}
+	if len(app.Children) != 2 || app.Children[0].Path != "src/app/main.go" || !app.Children[0].File {
+		t.Errorf("Expected src/app/main.go first under src/app, got %+v", app.Children)
+	}
+}
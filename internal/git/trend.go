@@ -0,0 +1,69 @@
+package git
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultTrendSplit is the default split point for trends, as a fraction of the window.
+const DefaultTrendSplit = 0.5
+
+// Trend compares how often a file was changed before and after a split point
+// in the analysis window.
+type Trend struct {
+	Path    string
+	Earlier int // Commits before the split point
+	Later   int // Commits from the split point on
+
+	// Change is the percentage change in commits per day from the earlier part
+	// of the window to the later one. It's +Inf if there were no earlier commits.
+	Change float64
+}
+
+// ComputeTrends splits [since, until) at the given fraction of its length and
+// compares each hotspot's commit rate in the two parts, which may differ in
+// length. If since is zero, the window starts at the earliest commit of the
+// hotspots. Trends are ordered from cooling down the most to heating up the
+// most.
+func ComputeTrends(hotspots []Hotspot, since, until time.Time, split float64) []Trend {
+	since = windowStart(hotspots, since, until)
+	splitPoint := since.Add(time.Duration(float64(until.Sub(since)) * split))
+	earlierDays := splitPoint.Sub(since).Hours() / 24
+	laterDays := until.Sub(splitPoint).Hours() / 24
+
+	var trends []Trend
+	for _, h := range hotspots {
+		trend := Trend{Path: h.Path}
+		for _, date := range h.CommitDates {
+			if date.Before(since) || !date.Before(until) {
+				continue
+			}
+			if date.Before(splitPoint) {
+				trend.Earlier++
+			} else {
+				trend.Later++
+			}
+		}
+		if trend.Earlier == 0 && trend.Later == 0 {
+			continue
+		}
+
+		if trend.Earlier == 0 {
+			trend.Change = math.Inf(1)
+		} else {
+			earlierRate := float64(trend.Earlier) / earlierDays
+			laterRate := float64(trend.Later) / laterDays
+			trend.Change = (laterRate - earlierRate) / earlierRate * 100
+		}
+		trends = append(trends, trend)
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Change != trends[j].Change {
+			return trends[i].Change < trends[j].Change
+		}
+		return trends[i].Path < trends[j].Path
+	})
+	return trends
+}
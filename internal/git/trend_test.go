@@ -0,0 +1,75 @@
+package git
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComputeTrends(t *testing.T) {
+	until := time.Now()
+	since := until.AddDate(0, 0, -100)
+	early := since.AddDate(0, 0, 10)
+	late := until.AddDate(0, 0, -10)
+
+	hotspots := []Hotspot{
+		{Path: "cooling.go", CommitDates: []time.Time{early, early, early, early, late}},
+		{Path: "heating.go", CommitDates: []time.Time{early, late, late}},
+		{Path: "new.go", CommitDates: []time.Time{late}},
+		{Path: "old.go", CommitDates: []time.Time{since.AddDate(0, 0, -1)}},
+	}
+
+	trends := ComputeTrends(hotspots, since, until, 0.5)
+
+	// Files without commits in the window are skipped, and the rest are
+	// ordered from cooling down to heating up
+	if len(trends) != 3 {
+		t.Fatalf("Expected 3 trends, got %d: %v", len(trends), trends)
+	}
+	expected := []struct {
+		path           string
+		earlier, later int
+		change         float64
+	}{
+		{"cooling.go", 4, 1, -75},
+		{"heating.go", 1, 2, 100},
+		{"new.go", 0, 1, math.Inf(1)},
+	}
+	for i, e := range expected {
+		trend := trends[i]
+		if trend.Path != e.path || trend.Earlier != e.earlier || trend.Later != e.later {
+			t.Errorf("Expected %s with %d/%d commits, got %s with %d/%d", e.path, e.earlier, e.later, trend.Path, trend.Earlier, trend.Later)
+		}
+		if math.Abs(trend.Change-e.change) > 0.01 && !(math.IsInf(e.change, 1) && math.IsInf(trend.Change, 1)) {
+			t.Errorf("Expected %s to change by %.2f%%, got %.2f%%", e.path, e.change, trend.Change)
+		}
+	}
+}
+
+func TestComputeTrendsSplit(t *testing.T) {
+	until := time.Now()
+	since := until.AddDate(0, 0, -100)
+
+	// One commit in the first 20 days and four in the last 80 is the same rate
+	hotspots := []Hotspot{
+		{Path: "steady.go", CommitDates: []time.Time{
+			since.AddDate(0, 0, 10),
+			since.AddDate(0, 0, 30), since.AddDate(0, 0, 50), since.AddDate(0, 0, 70), since.AddDate(0, 0, 90),
+		}},
+	}
+
+	trends := ComputeTrends(hotspots, since, until, 0.2)
+	if len(trends) != 1 || trends[0].Earlier != 1 || trends[0].Later != 4 {
+		t.Fatalf("Expected 1 earlier and 4 later commits, got %v", trends)
+	}
+	if math.Abs(trends[0].Change) > 0.01 {
+		t.Errorf("Expected no change in commit rate, got %.2f%%", trends[0].Change)
+	}
+
+	// Without a start, the window starts at the first commit, 90 days
+	// before until, so it's split 18 days later
+	trends = ComputeTrends(hotspots, time.Time{}, until, 0.2)
+	if len(trends) != 1 || trends[0].Earlier != 1 || trends[0].Later != 4 {
+		t.Fatalf("Expected 1 earlier and 4 later commits from the first commit, got %v", trends)
+	}
+}
@@ -0,0 +1,49 @@
+package git
+
+import (
+	"context"
+	"time"
+)
+
+// WatchHead polls the commit HEAD of the repository at path points to every
+// interval, and sends on the returned channel once HEAD has moved and then
+// stayed put for another interval, so a burst of commits or a rebase only
+// triggers one send. The channel is closed once ctx is done.
+func WatchHead(ctx context.Context, path string, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{})
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		_, last, _ := HeadRef(path)
+		moved := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// HEAD may not resolve halfway through a rebase; wait for it to settle
+			_, hash, err := HeadRef(path)
+			if err != nil {
+				hash = ""
+			}
+			if hash != last {
+				last, moved = hash, true
+				continue
+			}
+			if !moved || hash == "" {
+				continue
+			}
+			moved = false
+			select {
+			case changes <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
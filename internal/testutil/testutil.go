@@ -0,0 +1,145 @@
+// Package testutil builds synthetic git repositories for tests, with commits
+// by given authors at given dates that add, modify, rename and delete files.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Default identity of the author and committer of commits.
+const (
+	DefaultAuthor = "Test User"
+	DefaultEmail  = "test@example.com"
+)
+
+// NewRepo creates an empty git repository in a new temporary directory and
+// returns its path. The caller removes it when done.
+func NewRepo(t testing.TB) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "git-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("Failed to init git repo: %v", err)
+	}
+	return dir
+}
+
+// CreateCommit commits files, creating any missing directories, with the
+// given message at the given time, by DefaultAuthor. Every file is written
+// with the same content, so committing a file again that wasn't changed
+// since fails; use Commit to change its content.
+func CreateCommit(t testing.TB, repoPath string, files []string, message string, when time.Time) plumbing.Hash {
+	t.Helper()
+	write := make(map[string]string, len(files))
+	for _, file := range files {
+		write[file] = "test content"
+	}
+	return Commit(t, repoPath, Change{Message: message, Date: when, Write: write})
+}
+
+// Change describes a commit for Commit. Zero fields take defaults.
+type Change struct {
+	// Author and Email identify the author and committer, DefaultAuthor
+	// and DefaultEmail if empty.
+	Author string
+	Email  string
+
+	// Date is the author and commit date, now if zero.
+	Date time.Time
+
+	// Message is the commit message, "Update" if empty.
+	Message string
+
+	// Write maps the slash-separated paths of files to add or modify to
+	// their new content.
+	Write map[string]string
+
+	// Rename maps the paths of files to move to their new paths. Files are
+	// moved before those in Write are written, so they can be renamed and
+	// modified in one commit.
+	Rename map[string]string
+
+	// Delete lists the paths of files to delete.
+	Delete []string
+
+	// Parents, if set, are the parents of the commit instead of HEAD, such
+	// as both branches of a merge.
+	Parents []plumbing.Hash
+}
+
+// Commit makes the commit change describes in the repository at repoPath and
+// returns its hash.
+func Commit(t testing.TB, repoPath string, change Change) plumbing.Hash {
+	t.Helper()
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	for from, to := range change.Rename {
+		mkdirFor(t, repoPath, to)
+		if _, err := wt.Move(from, to); err != nil {
+			t.Fatalf("Failed to move %s to %s: %v", from, to, err)
+		}
+	}
+	for file, content := range change.Write {
+		mkdirFor(t, repoPath, file)
+		if err := os.WriteFile(filepath.Join(repoPath, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", file, err)
+		}
+		if _, err := wt.Add(file); err != nil {
+			t.Fatalf("Failed to add file %s: %v", file, err)
+		}
+	}
+	for _, file := range change.Delete {
+		if _, err := wt.Remove(file); err != nil {
+			t.Fatalf("Failed to delete file %s: %v", file, err)
+		}
+	}
+
+	signature := &object.Signature{Name: change.Author, Email: change.Email, When: change.Date}
+	if signature.Name == "" {
+		signature.Name = DefaultAuthor
+	}
+	if signature.Email == "" {
+		signature.Email = DefaultEmail
+	}
+	if signature.When.IsZero() {
+		signature.When = time.Now()
+	}
+	message := change.Message
+	if message == "" {
+		message = "Update"
+	}
+	hash, err := wt.Commit(message, &git.CommitOptions{
+		Author:    signature,
+		Committer: signature,
+		Parents:   change.Parents,
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	return hash
+}
+
+// mkdirFor creates the directory of file in the repository if missing.
+func mkdirFor(t testing.TB, repoPath, file string) {
+	t.Helper()
+	dir := filepath.Dir(filepath.Join(repoPath, file))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create dir %s: %v", dir, err)
+	}
+}
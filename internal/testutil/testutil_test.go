@@ -0,0 +1,90 @@
+package testutil
+
+import (
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestCommit(t *testing.T) {
+	tmpDir := NewRepo(t)
+	defer os.RemoveAll(tmpDir)
+
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	base := CreateCommit(t, tmpDir, []string{"src/main.go", "old.go", "gone.go"}, "Initial commit", when)
+	hash := Commit(t, tmpDir, Change{
+		Author: "Alice",
+		Email:  "alice@example.com",
+		Rename: map[string]string{"old.go": "pkg/new.go"},
+		Write:  map[string]string{"src/main.go": "changed"},
+		Delete: []string{"gone.go"},
+	})
+
+	repo, err := git.PlainOpen(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatalf("Failed to read commit: %v", err)
+	}
+	if commit.Author.Name != "Alice" || commit.Author.Email != "alice@example.com" || commit.Message != "Update" {
+		t.Errorf("Expected Alice's commit with the default message, got %+v and %q", commit.Author, commit.Message)
+	}
+	if len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != base {
+		t.Errorf("Expected the initial commit as parent, got %v", commit.ParentHashes)
+	}
+	if got := treeFiles(t, commit); !equal(got, []string{"pkg/new.go", "src/main.go"}) {
+		t.Errorf("Expected pkg/new.go and src/main.go, got %v", got)
+	}
+
+	first, err := repo.CommitObject(base)
+	if err != nil {
+		t.Fatalf("Failed to read commit: %v", err)
+	}
+	if first.Author.Name != DefaultAuthor || !first.Author.When.Equal(when) {
+		t.Errorf("Expected %s at %v, got %+v", DefaultAuthor, when, first.Author)
+	}
+
+	// Parents override HEAD, so a commit can merge two branches
+	side := Commit(t, tmpDir, Change{Write: map[string]string{"side.go": "side"}, Parents: []plumbing.Hash{base}})
+	merge := Commit(t, tmpDir, Change{Message: "Merge", Parents: []plumbing.Hash{hash, side}})
+	if commit, err = repo.CommitObject(merge); err != nil {
+		t.Fatalf("Failed to read commit: %v", err)
+	}
+	if commit.NumParents() != 2 {
+		t.Errorf("Expected a merge commit, got %d parents", commit.NumParents())
+	}
+}
+
+func treeFiles(t *testing.T, commit *object.Commit) []string {
+	t.Helper()
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("Failed to read tree: %v", err)
+	}
+	var files []string
+	tree.Files().ForEach(func(f *object.File) error {
+		files = append(files, f.Name)
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
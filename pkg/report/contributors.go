@@ -0,0 +1,52 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonContributorStat is the JSON representation of an author in the contributor ranking.
+type jsonContributorStat struct {
+	Author  string `json:"author"`
+	Commits int    `json:"commits"`
+	Files   int    `json:"files"`
+}
+
+// WriteContributors writes the top contributors to w as a plain-text table,
+// with the number of distinct files each touched.
+func WriteContributors(w io.Writer, contributors []git.ContributorStat, topCount int) error {
+	if _, err := fmt.Fprintf(w, "%-7s  %-7s  %s\n", "Commits", "Files", "Author"); err != nil {
+		return err
+	}
+	for i, c := range contributors {
+		if i >= topCount {
+			break
+		}
+		if _, err := fmt.Fprintf(w, "%7d  %7d  %s\n", c.Commits, c.Files, c.Author); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteContributorsJSON writes the top contributors to w as a JSON array.
+func WriteContributorsJSON(w io.Writer, contributors []git.ContributorStat, topCount int) error {
+	result := []jsonContributorStat{}
+	for i, c := range contributors {
+		if i >= topCount {
+			break
+		}
+		result = append(result, jsonContributorStat{
+			Author:  c.Author,
+			Commits: c.Commits,
+			Files:   c.Files,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonCounts is the JSON representation of the totals written by --count-only.
+type jsonCounts struct {
+	Commits int `json:"commits"`
+	Files   int `json:"files"`
+	Authors int `json:"authors"`
+}
+
+// WriteCounts writes the totals of summary to w as key=value lines, for
+// scripts that only need aggregate numbers.
+func WriteCounts(w io.Writer, summary git.Summary) error {
+	_, err := fmt.Fprintf(w, "commits=%d\nfiles=%d\nauthors=%d\n", summary.Commits, summary.Files, summary.Authors)
+	return err
+}
+
+// WriteCountsJSON writes the totals of summary to w as a JSON object.
+func WriteCountsJSON(w io.Writer, summary git.Summary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonCounts{
+		Commits: summary.Commits,
+		Files:   summary.Files,
+		Authors: summary.Authors,
+	})
+}
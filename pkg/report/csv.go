@@ -0,0 +1,56 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"git-hotspots/internal/git"
+)
+
+// csvHeader is the header row of CSV output.
+var csvHeader = []string{"kind", "path", "commits", "score", "linesAdded", "linesDeleted", "authors", "topContributor", "authorCommits", "soleOwned", "firstSeen", "lastModified"}
+
+// WriteCSV writes the top file and directory hotspots to w as CSV, one row
+// per hotspot with a kind of "file" or "directory", for spreadsheets. Dates
+// are RFC 3339 unless opts.DateFormat says otherwise.
+func WriteCSV(w io.Writer, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts Options) error {
+	opts.Sort(fileHotspots)
+	opts.Sort(dirHotspots)
+
+	now := time.Now()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, group := range []struct {
+		kind     string
+		hotspots []git.Hotspot
+	}{{"file", fileHotspots}, {"directory", dirHotspots}} {
+		for i, h := range group.hotspots {
+			if i >= opts.TopCount {
+				break
+			}
+			err := cw.Write([]string{
+				group.kind,
+				QuotePath(h.Path),
+				strconv.Itoa(h.Commits),
+				strconv.FormatFloat(h.Score, 'f', -1, 64),
+				strconv.Itoa(h.LinesAdded),
+				strconv.Itoa(h.LinesDeleted),
+				strconv.Itoa(len(h.Contributors)),
+				h.TopContributor,
+				strconv.Itoa(h.AuthorCommits),
+				strconv.FormatBool(h.SoleOwned),
+				opts.DateFormat.Format(h.FirstSeen, now),
+				opts.DateFormat.Format(h.LastModified, now),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
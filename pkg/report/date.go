@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateFormat controls how dates are rendered. Besides the named formats below,
+// any Go time layout such as "2006-01-02" is accepted. The zero value uses the
+// default of each output: RFC 3339 for machine formats, relative for the UI.
+type DateFormat string
+
+// Named date formats.
+const (
+	DateRFC3339  DateFormat = "rfc3339"
+	DateUnix     DateFormat = "unix"
+	DateRelative DateFormat = "relative"
+)
+
+// dateLayoutReference is formatted and parsed back to validate custom layouts.
+var dateLayoutReference = time.Date(2001, time.February, 3, 16, 7, 8, 0, time.UTC)
+
+// ParseDateFormat validates a date format given on the command line.
+func ParseDateFormat(s string) (DateFormat, error) {
+	switch format := DateFormat(s); format {
+	case "", DateRFC3339, DateUnix, DateRelative:
+		return format, nil
+	}
+
+	formatted := dateLayoutReference.Format(s)
+	if formatted == s {
+		return "", fmt.Errorf("date format %q contains no date or time elements", s)
+	}
+	if _, err := time.Parse(s, formatted); err != nil {
+		return "", fmt.Errorf("invalid date format %q: %w", s, err)
+	}
+	return DateFormat(s), nil
+}
+
+// Or returns f, or fallback if f is the zero value.
+func (f DateFormat) Or(fallback DateFormat) DateFormat {
+	if f == "" {
+		return fallback
+	}
+	return f
+}
+
+// Format renders t in the date format, relative to now where needed.
+// The zero value renders as RFC 3339. A zero t, the date of a hotspot
+// without commits, renders as "never".
+func (f DateFormat) Format(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	switch f {
+	case "", DateRFC3339:
+		return t.Format(time.RFC3339)
+	case DateUnix:
+		return fmt.Sprint(t.Unix())
+	case DateRelative:
+		return RelativeAge(t, now)
+	default:
+		return t.Format(string(f))
+	}
+}
+
+// jsonValue returns t in the date format for JSON output, with Unix
+// timestamps as numbers rather than strings, and null for a zero t.
+func (f DateFormat) jsonValue(t, now time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	if f == DateUnix {
+		return t.Unix()
+	}
+	return f.Format(t, now)
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonDefect is the JSON representation of a file ranked by defect commits.
+type jsonDefect struct {
+	Path           string `json:"path"`
+	Defects        int    `json:"defects"`
+	Commits        int    `json:"commits"`
+	TopContributor string `json:"topContributor"`
+	AuthorCommits  int    `json:"authorCommits"`
+}
+
+// WriteDefects writes the top files by defect commits to w as a plain-text
+// table, with their total commits for context.
+func WriteDefects(w io.Writer, hotspots []git.Hotspot, topCount int) error {
+	if _, err := fmt.Fprintf(w, "%-7s  %-7s  %-25s  %s\n", "Defects", "Commits", "Top Contributor (Commits)", "Path"); err != nil {
+		return err
+	}
+	for i, h := range hotspots {
+		if i >= topCount {
+			break
+		}
+		contributor := fmt.Sprintf("%s (%d)", h.TopContributor, h.AuthorCommits)
+		if _, err := fmt.Fprintf(w, "%7d  %7d  %-25s  %s\n", h.Defects, h.Commits, contributor, QuotePath(h.Path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDefectsJSON writes the top files by defect commits to w as a JSON array.
+func WriteDefectsJSON(w io.Writer, hotspots []git.Hotspot, topCount int) error {
+	result := []jsonDefect{}
+	for i, h := range hotspots {
+		if i >= topCount {
+			break
+		}
+		result = append(result, jsonDefect{
+			Path:           QuotePath(h.Path),
+			Defects:        h.Defects,
+			Commits:        h.Commits,
+			TopContributor: h.TopContributor,
+			AuthorCommits:  h.AuthorCommits,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
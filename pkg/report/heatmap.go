@@ -0,0 +1,122 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonHeatmap is the JSON representation of a heatmap. Counts holds a row
+// for each of Paths, with a cell for each of Periods.
+type jsonHeatmap struct {
+	Bucket  git.HeatmapBucket `json:"bucket"`
+	Periods []string          `json:"periods"`
+	Paths   []string          `json:"paths"`
+	Counts  [][]int           `json:"counts"`
+}
+
+// HeatLevels is the number of levels HeatLevel divides counts into.
+const HeatLevels = 3
+
+// HeatLevel returns how hot count is compared to max, the largest count in
+// the heatmap: 0 for no commits, then 1 up to HeatLevels. Any commit is at
+// least level 1, so activity is always visible.
+func HeatLevel(count, max int) int {
+	if count <= 0 || max <= 0 {
+		return 0
+	}
+	return (count*HeatLevels + max - 1) / max
+}
+
+// HeatmapMax returns the largest count in heatmap.
+func HeatmapMax(heatmap git.Heatmap) int {
+	max := 0
+	for _, row := range heatmap.Rows {
+		for _, c := range row.Counts {
+			if c > max {
+				max = c
+			}
+		}
+	}
+	return max
+}
+
+// HeatmapTitle describes the columns of heatmap, e.g.
+// "Commits per week from 2026-01-05 to 2026-03-30".
+func HeatmapTitle(heatmap git.Heatmap) string {
+	if len(heatmap.Periods) == 0 {
+		return fmt.Sprintf("Commits per %s", heatmap.Bucket)
+	}
+	first, last := heatmap.Periods[0], heatmap.Periods[len(heatmap.Periods)-1]
+	return fmt.Sprintf("Commits per %s from %s to %s", heatmap.Bucket,
+		PeriodLabel(first, heatmap.Bucket), PeriodLabel(last, heatmap.Bucket))
+}
+
+// PeriodLabel returns the date a heatmap period starts on, as a month for
+// monthly buckets.
+func PeriodLabel(start time.Time, bucket git.HeatmapBucket) string {
+	if bucket == git.BucketMonth {
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+// HeatmapCell formats a heatmap count right-aligned to width, showing
+// periods without commits as a dot so busy ones stand out.
+func HeatmapCell(count, width int) string {
+	if count == 0 {
+		return fmt.Sprintf("%*s", width, "·")
+	}
+	return fmt.Sprintf("%*d", width, count)
+}
+
+// HeatmapCellWidth returns the width of the cells of a heatmap whose
+// largest count is max, including a space separating them.
+func HeatmapCellWidth(max int) int {
+	return len(fmt.Sprint(max)) + 1
+}
+
+// WriteHeatmap writes heatmap to w as a plain-text grid with a row per file,
+// oldest periods first.
+func WriteHeatmap(w io.Writer, heatmap git.Heatmap) error {
+	if _, err := fmt.Fprintln(w, HeatmapTitle(heatmap)); err != nil {
+		return err
+	}
+	width := HeatmapCellWidth(HeatmapMax(heatmap))
+	for _, row := range heatmap.Rows {
+		var line strings.Builder
+		for _, c := range row.Counts {
+			line.WriteString(HeatmapCell(c, width))
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s\n", line.String(), QuotePath(row.Path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHeatmapJSON writes heatmap to w as a JSON object holding its counts
+// as a nested array.
+func WriteHeatmapJSON(w io.Writer, heatmap git.Heatmap) error {
+	result := jsonHeatmap{
+		Bucket:  heatmap.Bucket,
+		Periods: []string{},
+		Paths:   []string{},
+		Counts:  [][]int{},
+	}
+	for _, start := range heatmap.Periods {
+		result.Periods = append(result.Periods, PeriodLabel(start, heatmap.Bucket))
+	}
+	for _, row := range heatmap.Rows {
+		result.Paths = append(result.Paths, QuotePath(row.Path))
+		result.Counts = append(result.Counts, row.Counts)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
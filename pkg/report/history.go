@@ -0,0 +1,75 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonFileChange is the JSON representation of a commit in a file's history.
+type jsonFileChange struct {
+	Hash         string `json:"hash"`
+	Date         any    `json:"date"` // Formatted by Options.DateFormat
+	Author       string `json:"author"`
+	Subject      string `json:"subject"`
+	Path         string `json:"path"`
+	LinesAdded   int    `json:"linesAdded"`
+	LinesDeleted int    `json:"linesDeleted"`
+}
+
+// jsonFileHistory is the top-level JSON document for a file's history.
+type jsonFileHistory struct {
+	File    string           `json:"file"`
+	Commits []jsonFileChange `json:"commits"`
+}
+
+// WriteFileHistory writes the commits that changed file to w as a plain-text
+// table, newest first. The path is shown for each commit, so renames stand out.
+// Dates are relative unless opts.DateFormat says otherwise.
+func WriteFileHistory(w io.Writer, file string, history []git.FileChange, opts Options) error {
+	if _, err := fmt.Fprintf(w, "History of %s (%d commits)\n\n", file, len(history)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%-7s  %-14s  %-20s  %7s  %7s  %-30s  %s\n", "Commit", "Date", "Author", "Added", "Deleted", "Path", "Subject"); err != nil {
+		return err
+	}
+
+	dateFormat := opts.DateFormat.Or(DateRelative)
+	now := time.Now()
+	for _, change := range history {
+		hash := change.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		if _, err := fmt.Fprintf(w, "%-7s  %-14s  %-20s  %7d  %7d  %-30s  %s\n",
+			hash, dateFormat.Format(change.Date, now), change.Author,
+			change.Lines.Added, change.Lines.Deleted, QuotePath(change.Path), change.Subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFileHistoryJSON writes the commits that changed file to w as JSON, newest first.
+func WriteFileHistoryJSON(w io.Writer, file string, history []git.FileChange, opts Options) error {
+	now := time.Now()
+	result := jsonFileHistory{File: file, Commits: []jsonFileChange{}}
+	for _, change := range history {
+		result.Commits = append(result.Commits, jsonFileChange{
+			Hash:         change.Hash,
+			Date:         opts.DateFormat.Or(DateRFC3339).jsonValue(change.Date, now),
+			Author:       change.Author,
+			Subject:      change.Subject,
+			Path:         QuotePath(change.Path),
+			LinesAdded:   change.Lines.Added,
+			LinesDeleted: change.Lines.Deleted,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"git-hotspots/internal/git"
+)
+
+// htmlTemplate renders the tables of WriteHTML as a standalone page.
+var htmlTemplate = template.Must(template.New("hotspots").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+td.metric { text-align: right; }
+tr.sole-owned td { color: #b00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- range .Tables}}
+<h2>{{.Title}}</h2>
+<table>
+<thead><tr><th>{{.MetricHeader}}</th><th>Top Contributor (Commits)</th><th>First Seen</th><th>Last Modified</th><th>{{.PathHeader}}</th></tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr{{if .SoleOwned}} class="sole-owned"{{end}}><td class="metric">{{.Metric}}</td><td>{{.Contributor}}</td><td>{{.FirstSeen}}</td><td>{{.LastModified}}</td><td>{{if .URL}}<a href="{{.URL}}">{{.Path}}</a>{{else}}{{.Path}}{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the top file and directory hotspots to w as a standalone
+// HTML page with the tables of WriteMarkdown, sole-owned hotspots in red, for
+// publishing reports.
+func WriteHTML(w io.Writer, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts Options) error {
+	title := "Git Hotspots"
+	if opts.Reverse {
+		title = "Git Coldspots"
+	}
+	return htmlTemplate.Execute(w, struct {
+		Title  string
+		Tables []documentTable
+	}{title, documentTables(fileHotspots, dirHotspots, opts, time.Now())})
+}
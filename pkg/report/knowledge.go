@@ -0,0 +1,72 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonKnowledgeNode is the JSON representation of a knowledge map node.
+type jsonKnowledgeNode struct {
+	Path           string              `json:"path"`
+	Commits        int                 `json:"commits"`
+	TopContributor string              `json:"topContributor"`
+	AuthorCommits  int                 `json:"authorCommits"`
+	Ownership      float64             `json:"ownership"`
+	Children       []jsonKnowledgeNode `json:"children,omitempty"`
+}
+
+// KnowledgeLabel returns the display label for a knowledge map node,
+// e.g. "src (Jane Smith 75.0%, 8 commits)".
+func KnowledgeLabel(node *git.KnowledgeNode) string {
+	return fmt.Sprintf("%s (%s %.1f%%, %d commits)",
+		QuotePath(node.Name), node.TopContributor, node.Ownership, node.Commits)
+}
+
+// WriteKnowledgeTree writes the knowledge map to w as an indented tree.
+func WriteKnowledgeTree(w io.Writer, root *git.KnowledgeNode) error {
+	if _, err := fmt.Fprintln(w, KnowledgeLabel(root)); err != nil {
+		return err
+	}
+	return writeKnowledgeChildren(w, root, "")
+}
+
+func writeKnowledgeChildren(w io.Writer, node *git.KnowledgeNode, prefix string) error {
+	for i, child := range node.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		if _, err := fmt.Fprintln(w, prefix+branch+KnowledgeLabel(child)); err != nil {
+			return err
+		}
+		if err := writeKnowledgeChildren(w, child, prefix+indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteKnowledgeJSON writes the knowledge map to w as nested JSON.
+func WriteKnowledgeJSON(w io.Writer, root *git.KnowledgeNode) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toJSONKnowledgeNode(root))
+}
+
+func toJSONKnowledgeNode(node *git.KnowledgeNode) jsonKnowledgeNode {
+	result := jsonKnowledgeNode{
+		Path:           QuotePath(node.Path),
+		Commits:        node.Commits,
+		TopContributor: node.TopContributor,
+		AuthorCommits:  node.AuthorCommits,
+		Ownership:      node.Ownership,
+	}
+	for _, child := range node.Children {
+		result.Children = append(result.Children, toJSONKnowledgeNode(child))
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"git-hotspots/internal/git"
+)
+
+// markdownEscaper escapes the characters that would end a table cell or
+// format text in Markdown, such as the underscores of __init__.py.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "|", `\|`)
+
+// WriteMarkdown writes the top file and directory hotspots to w as Markdown
+// tables under headings, e.g. for wikis and pull request comments, with the
+// columns of WriteTable but the activity sparkline. Either table is left out
+// if opts.NoFiles or opts.NoDirs is set. Dates are relative unless
+// opts.DateFormat says otherwise.
+func WriteMarkdown(w io.Writer, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts Options) error {
+	bw := bufio.NewWriter(w)
+	for i, table := range documentTables(fileHotspots, dirHotspots, opts, time.Now()) {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "## %s\n\n| %s | Top Contributor (Commits) | First Seen | Last Modified | %s |\n| ---: | --- | --- | --- | --- |\n",
+			table.Title, table.MetricHeader, table.PathHeader)
+		for _, row := range table.Rows {
+			fmt.Fprintf(bw, "| %d | %s | %s | %s | %s |\n",
+				row.Metric, markdownEscaper.Replace(row.Contributor), row.FirstSeen, row.LastModified, markdownPath(row))
+		}
+	}
+	return bw.Flush()
+}
+
+// markdownPath returns the escaped path of row, linked to its URL if it has
+// one.
+func markdownPath(row documentRow) string {
+	path := markdownEscaper.Replace(row.Path)
+	if row.URL == "" {
+		return path
+	}
+	return fmt.Sprintf("[%s](%s)", path, row.URL)
+}
+
+// documentTable is a table of hotspots for document formats such as
+// Markdown and HTML.
+type documentTable struct {
+	Title        string
+	MetricHeader string
+	PathHeader   string
+	Rows         []documentRow
+}
+
+// documentRow is a hotspot in a documentTable, formatted as in WriteTable.
+type documentRow struct {
+	Metric       int
+	Contributor  string // Top contributor and their commits, marked with a ! if sole-owned
+	FirstSeen    string
+	LastModified string
+	Path         string
+	URL          string // Link to the file, if opts.RepoURL is set
+	SoleOwned    bool
+}
+
+// documentTables returns the tables of the top file and directory hotspots
+// for document formats, sorted and left out as by WriteTable, with dates
+// relative to now unless opts.DateFormat says otherwise.
+func documentTables(fileHotspots, dirHotspots []git.Hotspot, opts Options, now time.Time) []documentTable {
+	filesTitle, dirsTitle := opts.Titles()
+	var tables []documentTable
+	if !opts.NoFiles {
+		tables = append(tables, newDocumentTable(filesTitle, "File Path", fileHotspots, opts.RepoURL, opts, now))
+	}
+	if !opts.NoDirs {
+		tables = append(tables, newDocumentTable(dirsTitle, "Directory Path", dirHotspots, "", opts, now))
+	}
+	return tables
+}
+
+// newDocumentTable returns a table of the top hotspots, linking their paths
+// under repoURL unless it's empty.
+func newDocumentTable(title, pathHeader string, hotspots []git.Hotspot, repoURL string, opts Options, now time.Time) documentTable {
+	opts.Sort(hotspots)
+	if len(hotspots) > opts.TopCount {
+		hotspots = hotspots[:opts.TopCount]
+	}
+
+	metricHeader, _, value := metricColumn(opts.Metric)
+	dateFormat := opts.DateFormat.Or(DateRelative)
+	table := documentTable{Title: title, MetricHeader: metricHeader, PathHeader: pathHeader}
+	for _, h := range hotspots {
+		contributor := fmt.Sprintf("%s (%d)", h.TopContributor, h.AuthorCommits)
+		if h.SoleOwned {
+			contributor += "!"
+		}
+		var link string
+		if repoURL != "" {
+			link = FileURL(repoURL, h.Path)
+		}
+		table.Rows = append(table.Rows, documentRow{
+			Metric:       value(h),
+			Contributor:  contributor,
+			FirstSeen:    dateFormat.Format(h.FirstSeen, now),
+			LastModified: dateFormat.Format(h.LastModified, now),
+			Path:         QuotePath(h.Path),
+			URL:          link,
+			SoleOwned:    h.SoleOwned,
+		})
+	}
+	return table
+}
+
+// FileURL returns the URL of path under repoURL, ignoring trailing slashes
+// of repoURL and escaping each segment of path.
+func FileURL(repoURL, path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimRight(repoURL, "/") + "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonOwnershipChange is the JSON representation of an ownership change.
+type jsonOwnershipChange struct {
+	Path            string `json:"path"`
+	Commits         int    `json:"commits"`
+	PreviousOwner   string `json:"previousOwner"`
+	PreviousCommits int    `json:"previousCommits"`
+	NewOwner        string `json:"newOwner"`
+	NewCommits      int    `json:"newCommits"`
+}
+
+// OwnershipChangeLabel returns the display label for an ownership change,
+// e.g. "Jane Smith (5) -> John Doe (3)".
+func OwnershipChangeLabel(change git.OwnershipChange) string {
+	return fmt.Sprintf("%s (%d) -> %s (%d)",
+		change.PreviousOwner, change.PreviousCommits, change.NewOwner, change.NewCommits)
+}
+
+// WriteOwnershipChanges writes the top ownership changes to w as a plain-text table.
+func WriteOwnershipChanges(w io.Writer, changes []git.OwnershipChange, topCount int) error {
+	if _, err := fmt.Fprintf(w, "%-7s  %-45s  %s\n", "Commits", "Ownership Change", "Path"); err != nil {
+		return err
+	}
+	for i, change := range changes {
+		if i >= topCount {
+			break
+		}
+		if _, err := fmt.Fprintf(w, "%7d  %-45s  %s\n", change.Commits, OwnershipChangeLabel(change), QuotePath(change.Path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteOwnershipChangesJSON writes the top ownership changes to w as a JSON array.
+func WriteOwnershipChangesJSON(w io.Writer, changes []git.OwnershipChange, topCount int) error {
+	result := []jsonOwnershipChange{}
+	for i, change := range changes {
+		if i >= topCount {
+			break
+		}
+		result = append(result, jsonOwnershipChange{
+			Path:            QuotePath(change.Path),
+			Commits:         change.Commits,
+			PreviousOwner:   change.PreviousOwner,
+			PreviousCommits: change.PreviousCommits,
+			NewOwner:        change.NewOwner,
+			NewCommits:      change.NewCommits,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
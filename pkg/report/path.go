@@ -0,0 +1,83 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuotePath returns path escaped for output, like git's core.quotePath but
+// keeping valid non-ASCII characters and without the surrounding quotes.
+// Paths are kept as the raw bytes of the tree entries, which needn't be UTF-8
+// and may contain control characters that garble terminals and JSON. Bytes
+// that aren't valid UTF-8 become octal escapes such as \351, control
+// characters become \t, \n or octal escapes, and backslashes are doubled.
+// Other paths, including those with spaces, are returned unchanged.
+func QuotePath(path string) string {
+	if utf8.ValidString(path) && strings.IndexFunc(path, unicode.IsControl) < 0 {
+		return path
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); {
+		r, size := utf8.DecodeRuneInString(path[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\%03o`, path[i])
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case unicode.IsControl(r):
+			for _, c := range []byte(path[i : i+size]) {
+				fmt.Fprintf(&b, `\%03o`, c)
+			}
+		default:
+			b.WriteString(path[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// ShortenPath shortens path to at most width characters, eliding whole
+// directories in its middle so its leading directory and file name are kept,
+// as in src/…/handlers/user.go. If even src/…/user.go doesn't fit, the leading
+// directory is dropped too, and failing that the middle of the path is cut
+// regardless of slashes. Paths that already fit are returned unchanged.
+func ShortenPath(path string, width int) string {
+	if utf8.RuneCountInString(path) <= width {
+		return path
+	}
+	if width < 1 {
+		return ""
+	}
+
+	// Keep as many of the last components after the leading one as fit
+	components := strings.Split(path, "/")
+	if len(components) > 2 {
+		name := components[len(components)-1]
+		for _, head := range []string{components[0] + "/…/", "…/"} {
+			if utf8.RuneCountInString(head+name) > width {
+				continue
+			}
+			tail := name
+			for i := len(components) - 2; i > 0; i-- {
+				longer := components[i] + "/" + tail
+				if utf8.RuneCountInString(head+longer) > width {
+					break
+				}
+				tail = longer
+			}
+			return head + tail
+		}
+	}
+
+	runes := []rune(path)
+	head := (width - 1) / 2
+	tail := width - 1 - head
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
@@ -0,0 +1,44 @@
+package report
+
+import "testing"
+
+func TestQuotePath(t *testing.T) {
+	for path, want := range map[string]string{
+		"src/main.go":           "src/main.go",
+		"docs/release notes.md": "docs/release notes.md",
+		"docs/naïve.md":         "docs/naïve.md",
+		"caf\xe9.txt":           `caf\351.txt`,
+		"a\tb\\c.txt":           `a\tb\\c.txt`,
+		"bell\a.txt":            `bell\007.txt`,
+	} {
+		if got := QuotePath(path); got != want {
+			t.Errorf("QuotePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestShortenPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		width int
+		want  string
+	}{
+		{"src/main.go", 20, "src/main.go"},
+		{"src/main.go", 11, "src/main.go"},
+		{"src/api/v2/handlers/user.go", 24, "src/…/handlers/user.go"},
+		{"src/api/v2/handlers/user.go", 20, "src/…/user.go"},
+		{"src/api/v2/handlers/user.go", 12, "…/user.go"},
+		{"internal/git/accumulator.go", 20, "…/git/accumulator.go"},
+		{"internal/git/accumulator.go", 15, "interna…ator.go"},
+		{"docs/naïve/notes.md", 10, "…/notes.md"},
+		{"docs/naïve/notes.md", 8, "doc…s.md"},
+		{"docs/naïve/notes/a.md", 19, "docs/…/notes/a.md"},
+		{"src/main.go", 1, "…"},
+		{"src/main.go", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := ShortenPath(tt.path, tt.width); got != tt.want {
+			t.Errorf("ShortenPath(%q, %d) = %q, want %q", tt.path, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"git-hotspots/internal/git"
+)
+
+// prometheusMetrics are the gauges written for each hotspot in the
+// Prometheus text format.
+var prometheusMetrics = []struct {
+	name  string
+	help  string
+	value func(h git.Hotspot) float64
+}{
+	{"git_hotspot_commits", "Commits touching the file or directory in the analysis window.", func(h git.Hotspot) float64 { return float64(h.Commits) }},
+	{"git_hotspot_score", "Score the file or directory is ranked by.", func(h git.Hotspot) float64 { return h.Score }},
+	{"git_hotspot_authors", "Distinct authors of the commits touching the file or directory.", func(h git.Hotspot) float64 { return float64(len(h.Contributors)) }},
+}
+
+// labelEscaper escapes label values per the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes gauges of the top file and directory hotspots to w in
+// the Prometheus text exposition format, labeled with their path and a kind
+// of "file" or "directory". Only the top opts.TopCount of each are written,
+// to keep the number of series down.
+func WritePrometheus(w io.Writer, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts Options) error {
+	return WriteRepositoriesPrometheus(w, []RepositoryHotspots{{Files: fileHotspots, Directories: dirHotspots}}, opts)
+}
+
+// WriteRepositoriesPrometheus writes gauges of the top file and directory
+// hotspots of each repository to w like WritePrometheus, labeling them with
+// their repository as well.
+func WriteRepositoriesPrometheus(w io.Writer, repos []RepositoryHotspots, opts Options) error {
+	for _, repo := range repos {
+		opts.Sort(repo.Files)
+		opts.Sort(repo.Directories)
+	}
+
+	// Samples of a metric must follow its HELP and TYPE lines together
+	bw := bufio.NewWriter(w)
+	for _, metric := range prometheusMetrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s gauge\n", metric.name, metric.help, metric.name)
+		for _, repo := range repos {
+			for _, group := range []struct {
+				kind     string
+				hotspots []git.Hotspot
+			}{{"file", repo.Files}, {"directory", repo.Directories}} {
+				for i, h := range group.hotspots {
+					if i >= opts.TopCount {
+						break
+					}
+					labels := fmt.Sprintf(`path="%s",kind="%s"`, labelEscaper.Replace(h.Path), group.kind)
+					if repo.Name != "" {
+						labels += fmt.Sprintf(`,repository="%s"`, labelEscaper.Replace(repo.Name))
+					}
+					fmt.Fprintf(bw, "%s{%s} %s\n", metric.name, labels, strconv.FormatFloat(metric.value(h), 'g', -1, 64))
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
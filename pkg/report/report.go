@@ -0,0 +1,445 @@
+package report
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonHotspot is the JSON representation of a single hotspot.
+type jsonHotspot struct {
+	Rank           int               `json:"rank"` // 1-based position in the sorted list
+	Path           string            `json:"path"`
+	Commits        int               `json:"commits"`
+	Score          float64           `json:"score"`
+	TopContributor string            `json:"topContributor"`
+	AuthorCommits  int               `json:"authorCommits"`
+	FirstSeen      any               `json:"firstSeen"`    // Formatted by Options.DateFormat
+	LastModified   any               `json:"lastModified"` // Formatted by Options.DateFormat
+	Activity       []int             `json:"activity"`
+	LinesAdded     int               `json:"linesAdded,omitempty"`   // Only if lines were counted
+	LinesDeleted   int               `json:"linesDeleted,omitempty"` // Only if lines were counted
+	Reverts        int               `json:"reverts,omitempty"`      // Only if any commits were reverts
+	Deletions      int               `json:"deletions,omitempty"`    // Only if any files were deleted
+	TestFiles      int               `json:"testFiles,omitempty"`    // Only directories with tests left out
+	Contributors   []jsonContributor `json:"contributors"`           // Commits by each author, most first
+	SoleOwned      bool              `json:"soleOwned,omitempty"`    // Only with an owner threshold
+	Concentration  float64           `json:"concentration"`          // Gini coefficient of contributors' commits
+	Created        any               `json:"created,omitempty"`      // Only files whose creation was analyzed
+	Deleted        any               `json:"deleted,omitempty"`      // Only files deleted since
+	LifetimeDays   *int              `json:"lifetimeDays,omitempty"` // From Created to Deleted or now
+
+	TopContributorEmailHash string `json:"topContributorEmailHash,omitempty"`
+}
+
+// jsonContributor is the JSON representation of an author's commits to a hotspot.
+type jsonContributor struct {
+	Author  string `json:"author"`
+	Commits int    `json:"commits"`
+}
+
+// SchemaVersion is the version of the shape of hotspot JSON and JSON Lines
+// output, written as "schemaVersion". It's bumped whenever a field is removed,
+// renamed or changes meaning; fields may be added without bumping it.
+const SchemaVersion = 1
+
+// jsonReport is the top-level JSON document for a hotspot report.
+type jsonReport struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	Summary       *jsonSummary  `json:"summary,omitempty"`
+	Files         []jsonHotspot `json:"files"`
+	Directories   []jsonHotspot `json:"directories"`
+}
+
+// Summary describes what a report was built from, so JSON reports are
+// self-describing and can be compared across runs.
+type Summary struct {
+	git.Summary
+	Repositories []string         // Paths of the analyzed repositories
+	Version      string           // Version of git-hotspots that wrote the report
+	Since        time.Time        // Start of the analysis window, zero for the full history
+	Sample       float64          // Fraction of commits analyzed, zero for all of them
+	Extrapolated bool             // Whether counts were scaled up from the sample
+	LimitCommits int              // Most recent commits walked, zero for all of them
+	Truncated    bool             // Whether LimitCommits left older commits out
+	Issues       git.CommitIssues // Commits that couldn't be fully read
+}
+
+// jsonSummary is the JSON representation of a report summary.
+type jsonSummary struct {
+	Version      string      `json:"version"`
+	Repositories []string    `json:"repositories"`
+	Commits      int         `json:"commits"`
+	Authors      int         `json:"authors"`
+	Since        any         `json:"since,omitempty"`       // Formatted by Options.DateFormat
+	FirstCommit  any         `json:"firstCommit,omitempty"` // Formatted by Options.DateFormat
+	LastCommit   any         `json:"lastCommit,omitempty"`  // Formatted by Options.DateFormat
+	Sample       float64     `json:"sample,omitempty"`      // Only if results are an estimate
+	Extrapolated bool        `json:"extrapolated,omitempty"`
+	LimitCommits int         `json:"limitCommits,omitempty"`
+	Truncated    bool        `json:"truncated,omitempty"` // Only if older commits were left out
+	Issues       *jsonIssues `json:"issues,omitempty"`    // Only if some commits couldn't be fully read
+}
+
+// jsonIssues is the JSON representation of git.CommitIssues.
+type jsonIssues struct {
+	UnreadableParents int `json:"unreadableParents"`
+	WholeTree         int `json:"wholeTree"`
+	NoFiles           int `json:"noFiles"`
+}
+
+// RepositoryHotspots holds the hotspots of one of several analyzed repositories.
+type RepositoryHotspots struct {
+	Name        string
+	Files       []git.Hotspot
+	Directories []git.Hotspot
+	Summary     *Summary // Included in JSON output if set
+}
+
+// jsonRepositoryReport is the JSON document for the hotspots of one repository.
+type jsonRepositoryReport struct {
+	Repository string `json:"repository"`
+	jsonReport
+}
+
+// jsonLine is the JSON Lines representation of a single hotspot, tagged
+// with its kind and, for several repositories, the repository it's from.
+type jsonLine struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Kind          string `json:"kind"` // "file" or "directory"
+	Repository    string `json:"repository,omitempty"`
+	jsonHotspot
+}
+
+// Options controls what is included in a report.
+type Options struct {
+	// TopCount is the number of top files and directories to include.
+	TopCount int
+
+	// WithGravatar includes the gravatar hash of each top contributor's
+	// email. It's off by default to avoid leaking identifying data.
+	WithGravatar bool
+
+	// DateFormat controls how dates are written. The default is RFC 3339.
+	DateFormat DateFormat
+
+	// NoColor disables colors in output that has them, such as the UI.
+	NoColor bool
+
+	// NoFiles and NoDirs leave the file or directory hotspots out of tables
+	// and the UI, when only the other kind was identified.
+	NoFiles bool
+	NoDirs  bool
+
+	// Summary, if set, is included in JSON output.
+	Summary *Summary
+
+	// Since is the start of the analysis window, which activity sparklines
+	// span. The zero value uses the default window, per git.DefaultSince.
+	Since time.Time
+
+	// Metric selects what tables show and are ranked by. The zero value
+	// shows commits.
+	Metric Metric
+
+	// Reverse lists the least changed hotspots first, to find coldspots
+	// such as dead or finished code.
+	Reverse bool
+
+	// Compact fits the UI's hotspot tables to the width of their panes for
+	// small terminals, leaving out the top contributor column and shortening
+	// paths.
+	Compact bool
+
+	// RepoURL, if set, links the file paths of Markdown and HTML reports to
+	// RepoURL/path, e.g. https://github.com/org/repo/blob/main.
+	RepoURL string
+}
+
+// WindowStart returns the start of the window activity sparklines span at
+// now: opts.Since, or the default window if it's zero.
+func (opts Options) WindowStart(now time.Time) time.Time {
+	if opts.Since.IsZero() {
+		return git.DefaultSince(now)
+	}
+	return opts.Since
+}
+
+// Sort sorts hotspots in the order reports list them: by opts.Metric, most
+// first, or least first if opts.Reverse is set.
+func (opts Options) Sort(hotspots []git.Hotspot) {
+	if opts.Reverse {
+		SortColdspots(hotspots, opts.Metric)
+		return
+	}
+	SortHotspots(hotspots, opts.Metric)
+}
+
+// Titles returns the titles of the file and directory hotspot tables.
+func (opts Options) Titles() (files, dirs string) {
+	if opts.Reverse {
+		return "Coldest Files", "Coldest Directories"
+	}
+	return "Top Hotspot Files", "Top Hotspot Directories"
+}
+
+// WriteJSON writes the top file and directory hotspots to w as JSON.
+func WriteJSON(w io.Writer, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts Options) error {
+	opts.Sort(fileHotspots)
+	opts.Sort(dirHotspots)
+
+	now := time.Now()
+	report := jsonReport{
+		SchemaVersion: SchemaVersion,
+		Summary:       toJSONSummary(opts.Summary, opts, now),
+		Files:         toJSONHotspots(fileHotspots, opts, now),
+		Directories:   toJSONHotspots(dirHotspots, opts, now),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteRepositoriesJSON writes the top file and directory hotspots of each repository to w
+// as a JSON array.
+func WriteRepositoriesJSON(w io.Writer, repos []RepositoryHotspots, opts Options) error {
+	now := time.Now()
+	reports := []jsonRepositoryReport{}
+	for _, repo := range repos {
+		opts.Sort(repo.Files)
+		opts.Sort(repo.Directories)
+		reports = append(reports, jsonRepositoryReport{
+			Repository: repo.Name,
+			jsonReport: jsonReport{
+				SchemaVersion: SchemaVersion,
+				Summary:       toJSONSummary(repo.Summary, opts, now),
+				Files:         toJSONHotspots(repo.Files, opts, now),
+				Directories:   toJSONHotspots(repo.Directories, opts, now),
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}
+
+// WriteJSONLines writes the top file and directory hotspots to w as JSON
+// Lines, one object per hotspot tagged with a "kind" of "file" or "directory".
+// Each line is written as soon as it's encoded, so large reports stream.
+func WriteJSONLines(w io.Writer, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts Options) error {
+	return writeJSONLines(json.NewEncoder(w), "", fileHotspots, dirHotspots, opts, time.Now())
+}
+
+// WriteRepositoriesJSONLines writes the top file and directory hotspots of
+// each repository to w as JSON Lines, tagging each line with its repository.
+func WriteRepositoriesJSONLines(w io.Writer, repos []RepositoryHotspots, opts Options) error {
+	encoder := json.NewEncoder(w)
+	now := time.Now()
+	for _, repo := range repos {
+		if err := writeJSONLines(encoder, repo.Name, repo.Files, repo.Directories, opts, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSONLines(encoder *json.Encoder, repository string, fileHotspots, dirHotspots []git.Hotspot, opts Options, now time.Time) error {
+	opts.Sort(fileHotspots)
+	opts.Sort(dirHotspots)
+
+	for _, group := range []struct {
+		kind     string
+		hotspots []git.Hotspot
+	}{{"file", fileHotspots}, {"directory", dirHotspots}} {
+		for i, h := range group.hotspots {
+			if i >= opts.TopCount {
+				break
+			}
+			line := jsonLine{SchemaVersion: SchemaVersion, Kind: group.kind, Repository: repository, jsonHotspot: toJSONHotspot(h, opts, now)}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// toJSONSummary converts summary for JSON output, leaving out unset dates.
+// It returns nil if summary is nil.
+func toJSONSummary(summary *Summary, opts Options, now time.Time) *jsonSummary {
+	if summary == nil {
+		return nil
+	}
+	date := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
+		}
+		return opts.DateFormat.Or(DateRFC3339).jsonValue(t, now)
+	}
+	var issues *jsonIssues
+	if summary.Issues.Total() > 0 {
+		issues = &jsonIssues{
+			UnreadableParents: summary.Issues.UnreadableParents,
+			WholeTree:         summary.Issues.WholeTree,
+			NoFiles:           summary.Issues.NoFiles,
+		}
+	}
+	return &jsonSummary{
+		Version:      summary.Version,
+		Repositories: summary.Repositories,
+		Commits:      summary.Commits,
+		Authors:      summary.Authors,
+		Since:        date(summary.Since),
+		FirstCommit:  date(summary.FirstCommit),
+		LastCommit:   date(summary.LastCommit),
+		Sample:       summary.Sample,
+		Extrapolated: summary.Extrapolated,
+		LimitCommits: summary.LimitCommits,
+		Truncated:    summary.Truncated,
+		Issues:       issues,
+	}
+}
+
+func toJSONHotspots(hotspots []git.Hotspot, opts Options, now time.Time) []jsonHotspot {
+	result := []jsonHotspot{}
+	for i, h := range hotspots {
+		if i >= opts.TopCount {
+			break
+		}
+		result = append(result, toJSONHotspot(h, opts, now))
+	}
+	return result
+}
+
+func toJSONHotspot(h git.Hotspot, opts Options, now time.Time) jsonHotspot {
+	hotspot := jsonHotspot{
+		Rank:           h.Rank,
+		Path:           QuotePath(h.Path),
+		Commits:        h.Commits,
+		Score:          h.Score,
+		TopContributor: h.TopContributor,
+		AuthorCommits:  h.AuthorCommits,
+		FirstSeen:      opts.DateFormat.Or(DateRFC3339).jsonValue(h.FirstSeen, now),
+		LastModified:   opts.DateFormat.Or(DateRFC3339).jsonValue(h.LastModified, now),
+		Activity:       Activity(h, opts.WindowStart(now), now),
+		LinesAdded:     h.LinesAdded,
+		LinesDeleted:   h.LinesDeleted,
+		Reverts:        h.Reverts,
+		Deletions:      h.Deletions,
+		TestFiles:      h.TestFiles,
+		Contributors:   []jsonContributor{},
+		SoleOwned:      h.SoleOwned,
+		Concentration:  h.Concentration,
+	}
+	for _, c := range h.Contributors {
+		hotspot.Contributors = append(hotspot.Contributors, jsonContributor{Author: c.Author, Commits: c.Commits})
+	}
+	if lifetime, ok := h.Lifetime(now); ok {
+		days := int(lifetime / (24 * time.Hour))
+		hotspot.Created = opts.DateFormat.Or(DateRFC3339).jsonValue(h.Created, now)
+		hotspot.Deleted = opts.DateFormat.Or(DateRFC3339).jsonValue(h.Deleted, now)
+		hotspot.LifetimeDays = &days
+	}
+	if opts.WithGravatar {
+		hotspot.TopContributorEmailHash = GravatarHash(h.TopContributorEmail)
+	}
+	return hotspot
+}
+
+// GravatarHash returns the gravatar hash of an email: the hex MD5 of the
+// trimmed, lowercased address.
+func GravatarHash(email string) string {
+	sum := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// DescribeLifetime describes how long the file of h has existed, e.g.
+// "42 days (created 2026-01-02, deleted 2026-02-13)", with its dates in
+// format. It returns "" if the file's creation is unknown.
+func DescribeLifetime(h git.Hotspot, format DateFormat, now time.Time) string {
+	lifetime, ok := h.Lifetime(now)
+	if !ok {
+		return ""
+	}
+	days := int(lifetime / (24 * time.Hour))
+	unit := "days"
+	if days == 1 {
+		unit = "day"
+	}
+	description := fmt.Sprintf("%d %s (created %s", days, unit, format.Format(h.Created, now))
+	if !h.Deleted.IsZero() {
+		description += ", deleted " + format.Format(h.Deleted, now)
+	}
+	return description + ")"
+}
+
+// RelativeAge describes how long before now t was, e.g. "3 days ago".
+func RelativeAge(t time.Time, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return pluralize(int(age/time.Minute), "minute")
+	case age < 24*time.Hour:
+		return pluralize(int(age/time.Hour), "hour")
+	case age < 30*24*time.Hour:
+		return pluralize(int(age/(24*time.Hour)), "day")
+	case age < 365*24*time.Hour:
+		return pluralize(int(age/(30*24*time.Hour)), "month")
+	default:
+		return pluralize(int(age/(365*24*time.Hour)), "year")
+	}
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s ago", unit)
+	}
+	return fmt.Sprintf("%d %ss ago", n, unit)
+}
+
+// ActivityBuckets is the number of time buckets used for activity sparklines.
+const ActivityBuckets = 12
+
+// sparkLevels are the glyphs used by Sparkline, from lowest to highest.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders bucket counts as a unicode sparkline scaled to the largest count.
+func Sparkline(counts []int) string {
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+
+	line := make([]rune, len(counts))
+	for i, c := range counts {
+		level := 0
+		if max > 0 {
+			// Round up so that any activity is visible above the baseline
+			level = (c*(len(sparkLevels)-1) + max - 1) / max
+		}
+		line[i] = sparkLevels[level]
+	}
+	return string(line)
+}
+
+// Activity buckets a hotspot's commit dates across the analysis window from since to now.
+// Hotspots without commit dates have the activity counted during the analysis instead,
+// per git.HotspotOptions.ActivitySince.
+func Activity(hotspot git.Hotspot, since, now time.Time) []int {
+	if hotspot.CommitDates == nil && hotspot.Activity != nil {
+		return hotspot.Activity
+	}
+	return git.BucketCommitDates(hotspot.CommitDates, since, now, ActivityBuckets)
+}
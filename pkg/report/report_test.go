@@ -0,0 +1,962 @@
+package report
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"git-hotspots/internal/git"
+)
+
+func TestRelativeAge(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		age      time.Duration
+		expected string
+	}{
+		{10 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{5 * time.Hour, "5 hours ago"},
+		{3 * 24 * time.Hour, "3 days ago"},
+		{60 * 24 * time.Hour, "2 months ago"},
+		{400 * 24 * time.Hour, "1 year ago"},
+	}
+
+	for _, tt := range tests {
+		if got := RelativeAge(now.Add(-tt.age), now); got != tt.expected {
+			t.Errorf("RelativeAge(%v) = %q, expected %q", tt.age, got, tt.expected)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		counts   []int
+		expected string
+	}{
+		{[]int{0, 0, 0}, "▁▁▁"},
+		{[]int{0, 1, 7, 14}, "▁▂▅█"},
+		{[]int{1, 10}, "▂█"},
+	}
+
+	for _, tt := range tests {
+		if got := Sparkline(tt.counts); got != tt.expected {
+			t.Errorf("Sparkline(%v) = %q, expected %q", tt.counts, got, tt.expected)
+		}
+	}
+}
+
+func TestGravatarHash(t *testing.T) {
+	// The address is trimmed and lowercased before hashing
+	expected := "0bc83cb571cd1c50ba6f3e8a78ef1346"
+	for _, email := range []string{"myemailaddress@example.com", " MyEmailAddress@example.com "} {
+		if got := GravatarHash(email); got != expected {
+			t.Errorf("GravatarHash(%q) = %q, expected %q", email, got, expected)
+		}
+	}
+}
+
+func TestWriteJSONWithGravatar(t *testing.T) {
+	hotspots := []git.Hotspot{
+		{Path: "main.go", Commits: 2, Score: 2, TopContributor: "Test User", TopContributorEmail: "test@example.com"},
+	}
+
+	// Emails aren't exposed unless requested
+	var out bytes.Buffer
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if strings.Contains(out.String(), "topContributorEmailHash") {
+		t.Errorf("Expected no email hash without WithGravatar, got %s", out.String())
+	}
+
+	out.Reset()
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10, WithGravatar: true}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if !strings.Contains(out.String(), GravatarHash("test@example.com")) {
+		t.Errorf("Expected the email hash with WithGravatar, got %s", out.String())
+	}
+}
+
+func TestWriteJSONContributors(t *testing.T) {
+	hotspots := []git.Hotspot{
+		{Path: "main.go", Commits: 3, Score: 3, TopContributor: "Alice", AuthorCommits: 2,
+			Contributors: []git.Contributor{{Author: "Alice", Commits: 2}, {Author: "Bob", Commits: 1}}},
+	}
+
+	var out bytes.Buffer
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var result jsonReport
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	expected := []jsonContributor{{Author: "Alice", Commits: 2}, {Author: "Bob", Commits: 1}}
+	if !reflect.DeepEqual(result.Files[0].Contributors, expected) {
+		t.Errorf("Expected contributors %v, got %v", expected, result.Files[0].Contributors)
+	}
+}
+
+func TestWriteJSONLifetime(t *testing.T) {
+	now := time.Now()
+	created := now.Add(-10 * 24 * time.Hour)
+	deleted := now.Add(-3 * 24 * time.Hour)
+	hotspots := []git.Hotspot{
+		{Path: "gone.go", Commits: 2, Score: 2, Created: created, Deleted: deleted},
+		{Path: "old.go", Commits: 1, Score: 1},
+	}
+
+	var out bytes.Buffer
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10, DateFormat: DateUnix}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var result struct {
+		Files []map[string]any `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	gone := result.Files[0]
+	if gone["created"] != float64(created.Unix()) || gone["deleted"] != float64(deleted.Unix()) || gone["lifetimeDays"] != float64(7) {
+		t.Errorf("Expected gone.go to have lived 7 days, got %v", gone)
+	}
+	if _, ok := result.Files[1]["lifetimeDays"]; ok {
+		t.Errorf("Expected no lifetime for old.go, got %v", result.Files[1])
+	}
+
+	if got, want := DescribeLifetime(hotspots[0], "2006-01-02", now), fmt.Sprintf("7 days (created %s, deleted %s)", created.Format("2006-01-02"), deleted.Format("2006-01-02")); got != want {
+		t.Errorf("DescribeLifetime() = %q, want %q", got, want)
+	}
+	if got := DescribeLifetime(hotspots[1], "", now); got != "" {
+		t.Errorf("Expected no lifetime description for old.go, got %q", got)
+	}
+}
+
+func TestWriteJSONSchemaVersion(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJSON(&out, nil, nil, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if result["schemaVersion"] != float64(SchemaVersion) {
+		t.Errorf("Expected schemaVersion %d, got %v", SchemaVersion, result["schemaVersion"])
+	}
+
+	out.Reset()
+	repos := []RepositoryHotspots{{Name: "api"}, {Name: "web"}}
+	if err := WriteRepositoriesJSON(&out, repos, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteRepositoriesJSON failed: %v", err)
+	}
+	var reports []jsonRepositoryReport
+	if err := json.Unmarshal(out.Bytes(), &reports); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	for _, report := range reports {
+		if report.SchemaVersion != SchemaVersion {
+			t.Errorf("Expected schema version %d for %s, got %d", SchemaVersion, report.Repository, report.SchemaVersion)
+		}
+	}
+}
+
+func TestWriteUnusualPaths(t *testing.T) {
+	hotspots := []git.Hotspot{
+		{Path: "docs/release notes/caf\xe9.md", Commits: 2, Score: 2, TopContributor: "Alice", AuthorCommits: 2},
+	}
+	want := `docs/release notes/caf\351.md`
+
+	var out bytes.Buffer
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var result jsonReport
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if result.Files[0].Path != want {
+		t.Errorf("Expected JSON path %q, got %q", want, result.Files[0].Path)
+	}
+
+	_, rows := HotspotTable(hotspots, "File Path", MetricCommits, DateRFC3339, time.Time{}, time.Now(), 0)
+	if !strings.HasSuffix(rows[0], "  "+want) {
+		t.Errorf("Expected table row to end with %q, got %q", want, rows[0])
+	}
+}
+
+func TestWriteSoleOwned(t *testing.T) {
+	now := time.Now()
+	hotspots := []git.Hotspot{
+		{Path: "silo.go", Commits: 5, Score: 5, TopContributor: "Test User", AuthorCommits: 5, SoleOwned: true, FirstSeen: now, LastModified: now},
+		{Path: "shared.go", Commits: 4, Score: 4, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: now, LastModified: now},
+	}
+
+	// Sole-owned hotspots are flagged in JSON, and left alone otherwise
+	var out bytes.Buffer
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if strings.Count(out.String(), `"soleOwned": true`) != 1 {
+		t.Errorf("Expected one sole-owned hotspot, got %s", out.String())
+	}
+
+	// and their top contributor is marked in tables
+	_, rows := HotspotTable(hotspots, "File Path", MetricCommits, DateRFC3339, time.Time{}, now, 0)
+	if !strings.Contains(rows[0], "(5)!") || strings.Contains(rows[1], "!") {
+		t.Errorf("Expected only the first row to be marked, got %q", rows)
+	}
+}
+
+func TestCompactHotspotTable(t *testing.T) {
+	now := time.Now()
+	hotspots := []git.Hotspot{
+		{Path: "internal/git/accumulator.go", Commits: 5, Score: 5, TopContributor: "Test User", AuthorCommits: 5, FirstSeen: now, LastModified: now},
+		{Path: "main.go", Commits: 4, Score: 4, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: now, LastModified: now},
+	}
+
+	// Rows fit the width, without the top contributor, and short paths are
+	// left whole
+	header, rows := CompactHotspotTable(hotspots, "File Path", MetricCommits, DateRFC3339, now.AddDate(0, 0, -30), now, 80)
+	if strings.Contains(header, "Top Contributor") || strings.Contains(rows[0], "Test User") {
+		t.Errorf("Expected no top contributor column, got %q and %q", header, rows)
+	}
+	for _, row := range rows {
+		if width := utf8.RuneCountInString(row); width > 80 {
+			t.Errorf("Expected rows at most 80 wide, got %d: %q", width, row)
+		}
+	}
+	if !strings.Contains(rows[0], "…") {
+		t.Errorf("Expected the long path shortened, got %q", rows[0])
+	}
+	if !strings.HasSuffix(rows[1], "  main.go") {
+		t.Errorf("Expected main.go whole, got %q", rows[1])
+	}
+
+	// Paths aren't shortened past the minimum, however narrow the terminal
+	_, rows = CompactHotspotTable(hotspots, "File Path", MetricCommits, DateRFC3339, now.AddDate(0, 0, -30), now, 20)
+	if !strings.HasSuffix(rows[0], "  inter…tor.go") {
+		t.Errorf("Expected the path shortened to %d, got %q", minPathWidth, rows[0])
+	}
+}
+
+func TestWriteOwnershipChangesJSON(t *testing.T) {
+	changes := []git.OwnershipChange{
+		{Path: "a.go", PreviousOwner: "Test User", PreviousCommits: 3, NewOwner: "Another User", NewCommits: 2, Commits: 5},
+		{Path: "b.go", PreviousOwner: "Another User", PreviousCommits: 1, NewOwner: "Test User", NewCommits: 1, Commits: 2},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteOwnershipChangesJSON(&buf, changes, 1); err != nil {
+		t.Fatalf("WriteOwnershipChangesJSON failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"previousOwner": "Test User"`) || !strings.Contains(out, `"newOwner": "Another User"`) {
+		t.Errorf("Expected owners in output, got %s", out)
+	}
+	if strings.Contains(out, "b.go") {
+		t.Errorf("Expected output limited to the top change, got %s", out)
+	}
+}
+
+func TestTrendLabel(t *testing.T) {
+	tests := []struct {
+		change   float64
+		expected string
+	}{
+		{-50, "↓ 50%"},
+		{0, "→ 0%"},
+		{125, "↑ 125%"},
+		{math.Inf(1), "↑ new"},
+	}
+
+	for _, tt := range tests {
+		if got := TrendLabel(git.Trend{Change: tt.change}); got != tt.expected {
+			t.Errorf("TrendLabel(%v) = %q, expected %q", tt.change, got, tt.expected)
+		}
+	}
+}
+
+func TestParseDateFormat(t *testing.T) {
+	for _, valid := range []string{"", "rfc3339", "unix", "relative", "2006-01-02", "Jan 2 15:04"} {
+		if _, err := ParseDateFormat(valid); err != nil {
+			t.Errorf("ParseDateFormat(%q) failed: %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"yyyy-mm-dd", "foo"} {
+		if _, err := ParseDateFormat(invalid); err == nil {
+			t.Errorf("Expected ParseDateFormat(%q) to fail", invalid)
+		}
+	}
+}
+
+func TestDateFormatFormat(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	date := now.Add(-3 * 24 * time.Hour)
+
+	tests := []struct {
+		format   DateFormat
+		expected string
+	}{
+		{"", "2024-03-07T12:00:00Z"},
+		{DateRFC3339, "2024-03-07T12:00:00Z"},
+		{DateUnix, "1709812800"},
+		{DateRelative, "3 days ago"},
+		{"2006-01-02", "2024-03-07"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.format.Format(date, now); got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.format, tt.expected, got)
+		}
+	}
+}
+
+func TestWriteJSONDateFormat(t *testing.T) {
+	date := time.Date(2024, time.March, 7, 12, 0, 0, 0, time.UTC)
+	files := []git.Hotspot{{Path: "a.go", Commits: 1, FirstSeen: date, LastModified: date}}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, files, nil, Options{TopCount: 10, DateFormat: DateUnix}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	// Unix timestamps are written as numbers
+	if !strings.Contains(buf.String(), `"lastModified": 1709812800`) {
+		t.Errorf("Expected a numeric lastModified, got %s", buf.String())
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	now := time.Now()
+	files := []git.Hotspot{
+		{Path: "quiet.go", Commits: 1, Score: 1, TopContributor: "Test User", AuthorCommits: 1, FirstSeen: now, LastModified: now},
+		{Path: "busy.go", Commits: 3, Score: 3, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: now.AddDate(0, 0, -3), LastModified: now},
+	}
+	dirs := []git.Hotspot{{Path: "src", Commits: 3, Score: 3, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: now, LastModified: now}}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, files, dirs, Options{TopCount: 1}); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, expected := range []string{"Top Hotspot Files", "File Path", "busy.go", "3 days ago", "Top Hotspot Directories", "Directory Path", "src"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	// Only the top file is shown
+	if strings.Contains(out, "quiet.go") {
+		t.Errorf("Expected output limited to the top file, got:\n%s", out)
+	}
+}
+
+func TestWriteJSONRanks(t *testing.T) {
+	hotspots := []git.Hotspot{
+		{Path: "a.go", Commits: 1, Score: 1},
+		{Path: "b.go", Commits: 3, Score: 3},
+		{Path: "c.go", Commits: 2, Score: 2},
+	}
+
+	// Ranks follow the order of the report, reversed for coldspots
+	for _, tt := range []struct {
+		reverse  bool
+		expected map[string]int
+	}{
+		{false, map[string]int{"b.go": 1, "c.go": 2, "a.go": 3}},
+		{true, map[string]int{"a.go": 1, "c.go": 2, "b.go": 3}},
+	} {
+		var out bytes.Buffer
+		if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10, Reverse: tt.reverse}); err != nil {
+			t.Fatalf("WriteJSON failed: %v", err)
+		}
+		var result jsonReport
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+		for i, h := range result.Files {
+			if h.Rank != tt.expected[h.Path] || h.Rank != i+1 {
+				t.Errorf("Reverse %v: expected %s ranked %d at position %d, got %d", tt.reverse, h.Path, tt.expected[h.Path], i+1, h.Rank)
+			}
+		}
+	}
+}
+
+func TestWriteJSONActivityWindow(t *testing.T) {
+	now := time.Now()
+	hotspots := []git.Hotspot{{Path: "main.go", Commits: 1, Score: 1, LastModified: now, CommitDates: []time.Time{now.AddDate(0, 0, -21)}}}
+	activity := func(opts Options) []int {
+		t.Helper()
+		var buf bytes.Buffer
+		opts.TopCount = 10
+		if err := WriteJSON(&buf, hotspots, nil, opts); err != nil {
+			t.Fatalf("WriteJSON failed: %v", err)
+		}
+		var got struct {
+			Files []jsonHotspot `json:"files"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		return got.Files[0].Activity
+	}
+
+	// Sparklines span a year by default, or the window given
+	if got := activity(Options{}); got[11] != 1 {
+		t.Errorf("Expected the commit in the last bucket of a year, got %v", got)
+	}
+	if got := activity(Options{Since: now.AddDate(0, 0, -30)}); got[3] != 1 {
+		t.Errorf("Expected the commit in the 4th bucket of 30 days, got %v", got)
+	}
+
+	// Activity counted during the analysis is used without commit dates
+	counted := []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 1}
+	hotspots[0].CommitDates, hotspots[0].Activity = nil, counted
+	if got := activity(Options{}); !reflect.DeepEqual(got, counted) {
+		t.Errorf("Expected the counted activity %v, got %v", counted, got)
+	}
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	now := time.Now()
+	files := []git.Hotspot{
+		{Path: "a.go", Commits: 1, Score: 1, LastModified: now},
+		{Path: "dir/b.go", Commits: 3, Score: 3, LastModified: now},
+		{Path: "c.go", Commits: 2, Score: 2, LastModified: now},
+	}
+	dirs := []git.Hotspot{{Path: "dir", Commits: 3, Score: 3, LastModified: now}}
+
+	var out bytes.Buffer
+	if err := WriteJSONLines(&out, files, dirs, Options{TopCount: 2}); err != nil {
+		t.Fatalf("WriteJSONLines failed: %v", err)
+	}
+
+	// One object per line, top files first, then directories
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := []struct {
+		kind, path string
+		rank       int
+	}{{"file", "dir/b.go", 1}, {"file", "c.go", 2}, {"directory", "dir", 1}}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d: %s", len(expected), len(lines), out.String())
+	}
+	for i, line := range lines {
+		var got jsonLine
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("Line %d isn't valid JSON: %v", i, err)
+		}
+		if got.Kind != expected[i].kind || got.Path != expected[i].path || got.Rank != expected[i].rank {
+			t.Errorf("Line %d: expected %s %s ranked %d, got %s %s ranked %d", i, expected[i].kind, expected[i].path, expected[i].rank, got.Kind, got.Path, got.Rank)
+		}
+		if got.Repository != "" {
+			t.Errorf("Line %d: expected no repository, got %q", i, got.Repository)
+		}
+		if got.SchemaVersion != SchemaVersion {
+			t.Errorf("Line %d: expected schema version %d, got %d", i, SchemaVersion, got.SchemaVersion)
+		}
+	}
+}
+
+func TestWriteJSONSummary(t *testing.T) {
+	now := time.Now()
+	hotspots := []git.Hotspot{{Path: "main.go", Commits: 2, Score: 2, LastModified: now}}
+
+	// The summary is left out unless given
+	var out bytes.Buffer
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if strings.Contains(out.String(), "summary") {
+		t.Errorf("Expected no summary, got %s", out.String())
+	}
+
+	summary := &Summary{
+		Summary:      git.Summary{Commits: 2, Authors: 1, FirstCommit: time.Unix(100, 0), LastCommit: time.Unix(200, 0)},
+		Repositories: []string{"/src/repo"},
+		Version:      "v1.0.0",
+	}
+	out.Reset()
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10, DateFormat: DateUnix, Summary: summary}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var report struct {
+		Summary map[string]any `json:"summary"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	expected := map[string]any{
+		"version":      "v1.0.0",
+		"repositories": []any{"/src/repo"},
+		"commits":      float64(2),
+		"authors":      float64(1),
+		"firstCommit":  float64(100),
+		"lastCommit":   float64(200),
+	}
+	if len(report.Summary) != len(expected) {
+		t.Errorf("Expected summary %v without since, got %v", expected, report.Summary)
+	}
+	for key, value := range expected {
+		if fmt.Sprint(report.Summary[key]) != fmt.Sprint(value) {
+			t.Errorf("Expected %s to be %v, got %v", key, value, report.Summary[key])
+		}
+	}
+
+	// Commits that couldn't be fully read are counted
+	summary.Issues = git.CommitIssues{WholeTree: 2}
+	out.Reset()
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10, Summary: summary}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"wholeTree": 2`) {
+		t.Errorf("Expected issues in the summary, got %s", out.String())
+	}
+
+	// Results cut short by a commit limit are marked as truncated
+	summary.LimitCommits, summary.Truncated = 100, true
+	out.Reset()
+	if err := WriteJSON(&out, hotspots, nil, Options{TopCount: 10, Summary: summary}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"limitCommits": 100`) || !strings.Contains(out.String(), `"truncated": true`) {
+		t.Errorf("Expected a truncated summary, got %s", out.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := []git.Hotspot{
+		{Path: "quiet.go", Commits: 1, Score: 1, TopContributor: "Test User", AuthorCommits: 1, FirstSeen: date, LastModified: date},
+		{Path: "busy, \"quoted\".go", Commits: 3, Score: 2.5, TopContributor: "Test User", AuthorCommits: 3, SoleOwned: true, FirstSeen: date, LastModified: date},
+	}
+	dirs := []git.Hotspot{{Path: "src", Commits: 3, Score: 3, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: date, LastModified: date}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, files, dirs, Options{TopCount: 1}); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV: %v", err)
+	}
+
+	// The top file and directory follow the header, with paths quoted as
+	// needed and dates in RFC 3339
+	want := [][]string{
+		csvHeader,
+		{"file", "busy, \"quoted\".go", "3", "2.5", "0", "0", "0", "Test User", "3", "true", "2026-01-02T03:04:05Z", "2026-01-02T03:04:05Z"},
+		{"directory", "src", "3", "3", "0", "0", "0", "Test User", "2", "false", "2026-01-02T03:04:05Z", "2026-01-02T03:04:05Z"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected records %q, got %q", want, records)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	now := time.Now()
+	files := []git.Hotspot{
+		{Path: "pkg/__init__.py", Commits: 3, Score: 3, TopContributor: "Test User", AuthorCommits: 3, SoleOwned: true, FirstSeen: now.AddDate(0, 0, -3), LastModified: now},
+		{Path: "a|b.go", Commits: 1, Score: 1, TopContributor: "Test User", AuthorCommits: 1, FirstSeen: now, LastModified: now},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, files, nil, Options{TopCount: 10, NoDirs: true}); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+
+	// Paths are escaped so they neither format text nor split cells
+	out := buf.String()
+	for _, expected := range []string{
+		"## Top Hotspot Files\n",
+		"| Commits | Top Contributor (Commits) | First Seen | Last Modified | File Path |\n",
+		"| 3 | Test User (3)! | 3 days ago | just now | pkg/\\_\\_init\\_\\_.py |\n",
+		"| 1 | Test User (1) | just now | just now | a\\|b.go |\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "Directories") {
+		t.Errorf("Expected no directory table, got:\n%s", out)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	now := time.Now()
+	files := []git.Hotspot{{Path: "<script>.go", Commits: 3, Score: 3, TopContributor: "Test User", AuthorCommits: 3, SoleOwned: true, FirstSeen: now, LastModified: now}}
+	dirs := []git.Hotspot{{Path: "src", Commits: 3, Score: 3, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: now, LastModified: now}}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, files, dirs, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+
+	// Paths are escaped and sole-owned hotspots marked
+	out := buf.String()
+	for _, expected := range []string{"<h2>Top Hotspot Files</h2>", "<h2>Top Hotspot Directories</h2>", `<tr class="sole-owned">`, "&lt;script&gt;.go", "<td>src</td>"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("Expected the path escaped, got:\n%s", out)
+	}
+}
+
+func TestWriteMarkdownRepoURL(t *testing.T) {
+	now := time.Now()
+	files := []git.Hotspot{{Path: "docs/my notes#1.md", Commits: 2, Score: 2, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: now, LastModified: now}}
+	dirs := []git.Hotspot{{Path: "docs", Commits: 2, Score: 2, TopContributor: "Test User", AuthorCommits: 2, FirstSeen: now, LastModified: now}}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, files, dirs, Options{TopCount: 10, RepoURL: "https://github.com/org/repo/blob/main//"}); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+
+	// File paths link to the file, with trailing slashes trimmed and each
+	// segment escaped, while directories aren't linked
+	out := buf.String()
+	if expected := "| [docs/my notes#1.md](https://github.com/org/repo/blob/main/docs/my%20notes%231.md) |\n"; !strings.Contains(out, expected) {
+		t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+	}
+	if expected := "| docs |\n"; !strings.Contains(out, expected) {
+		t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+	}
+}
+
+func TestWriteHTMLRepoURL(t *testing.T) {
+	now := time.Now()
+	files := []git.Hotspot{{Path: "src/a&b.go", Commits: 3, Score: 3, TopContributor: "Test User", AuthorCommits: 3, FirstSeen: now, LastModified: now}}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, files, nil, Options{TopCount: 10, NoDirs: true, RepoURL: "https://example.com/repo/"}); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+
+	out := buf.String()
+	if expected := `<td><a href="https://example.com/repo/src/a&amp;b.go">src/a&amp;b.go</a></td>`; !strings.Contains(out, expected) {
+		t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+	}
+}
+
+func TestWriteTableChurn(t *testing.T) {
+	now := time.Now()
+	files := []git.Hotspot{
+		{Path: "busy.go", Commits: 5, Score: 5, LinesAdded: 8, LinesDeleted: 2, FirstSeen: now, LastModified: now},
+		{Path: "big.go", Commits: 1, Score: 1, LinesAdded: 400, LinesDeleted: 100, FirstSeen: now, LastModified: now},
+	}
+
+	var out bytes.Buffer
+	if err := WriteTable(&out, files, nil, Options{TopCount: 10, Metric: MetricChurn}); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+
+	// Files are ranked by and show lines changed
+	output := out.String()
+	if !strings.Contains(output, "Lines Changed  Top Contributor") {
+		t.Errorf("Expected a lines changed column, got:\n%s", output)
+	}
+	if strings.Index(output, "big.go") > strings.Index(output, "busy.go") {
+		t.Errorf("Expected big.go before busy.go, got:\n%s", output)
+	}
+	if !strings.Contains(output, "          500") {
+		t.Errorf("Expected 500 lines changed for big.go, got:\n%s", output)
+	}
+}
+
+func TestWriteFileHistory(t *testing.T) {
+	date := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	history := []git.FileChange{
+		{
+			CommitRef: git.CommitRef{Hash: "abcdef1234567890", Date: date, Author: "Jane Smith", Subject: "Rename old.go"},
+			Path:      "new.go",
+			Lines:     git.LineChanges{Added: 3, Deleted: 1},
+		},
+		{
+			CommitRef: git.CommitRef{Hash: "1234567abcdef890", Date: date.Add(-time.Hour), Author: "John Doe", Subject: "Add old.go"},
+			Path:      "old.go",
+			Lines:     git.LineChanges{Added: 10},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFileHistory(&buf, "new.go", history, Options{DateFormat: "2006-01-02"}); err != nil {
+		t.Fatalf("WriteFileHistory failed: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{"History of new.go (2 commits)", "abcdef1", "Jane Smith", "old.go", "Add old.go"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected table to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteFileHistoryJSON(&buf, "new.go", history, Options{}); err != nil {
+		t.Fatalf("WriteFileHistoryJSON failed: %v", err)
+	}
+	var got jsonFileHistory
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if got.File != "new.go" || len(got.Commits) != 2 {
+		t.Fatalf("Expected 2 commits of new.go, got %+v", got)
+	}
+	if c := got.Commits[1]; c.Path != "old.go" || c.LinesAdded != 10 || c.Date != "2024-03-01T11:00:00Z" {
+		t.Errorf("Unexpected oldest commit %+v", c)
+	}
+}
+
+func TestWriteDefectsJSON(t *testing.T) {
+	hotspots := []git.Hotspot{
+		{Path: "b.go", Defects: 2, Commits: 2, TopContributor: "Test User", AuthorCommits: 2},
+		{Path: "a.go", Defects: 1, Commits: 3, TopContributor: "Test User", AuthorCommits: 3},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDefectsJSON(&buf, hotspots, 1); err != nil {
+		t.Fatalf("WriteDefectsJSON failed: %v", err)
+	}
+	var got []jsonDefect
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	expected := jsonDefect{Path: "b.go", Defects: 2, Commits: 2, TopContributor: "Test User", AuthorCommits: 2}
+	if len(got) != 1 || got[0] != expected {
+		t.Errorf("Expected only %+v, got %+v", expected, got)
+	}
+}
+
+func TestWriteSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hotspots.db")
+	now := time.Now()
+	files := []git.Hotspot{
+		{Path: "main.go", Commits: 3, Score: 3, TopContributor: "Alice", AuthorCommits: 2, FirstSeen: now, LastModified: now},
+		{Path: "util.go", Commits: 5, Score: 5, TopContributor: "Bob", AuthorCommits: 5, FirstSeen: now, LastModified: now},
+	}
+	dirs := []git.Hotspot{{Path: "cmd", Commits: 8, Score: 8, FirstSeen: now, LastModified: now}}
+	opts := Options{TopCount: 10, Summary: &Summary{Version: "v1.2.3", Repositories: []string{"/src/repo"}}}
+
+	// Snapshots accumulate across runs
+	for _, head := range []string{"aaa", "bbb"} {
+		run := Run{Time: now, Ref: "refs/heads/main", Head: head}
+		if err := WriteSQLite(path, files, dirs, run, opts); err != nil {
+			t.Fatalf("WriteSQLite failed: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	var runs, fileRows, dirRows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM runs WHERE ref = 'refs/heads/main' AND version = 'v1.2.3'`).Scan(&runs); err != nil {
+		t.Fatalf("Failed to count runs: %v", err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM file_hotspots`).Scan(&fileRows); err != nil {
+		t.Fatalf("Failed to count file hotspots: %v", err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM dir_hotspots`).Scan(&dirRows); err != nil {
+		t.Fatalf("Failed to count directory hotspots: %v", err)
+	}
+	if runs != 2 || fileRows != 4 || dirRows != 2 {
+		t.Errorf("Expected 2 runs, 4 file and 2 directory rows, got %d, %d and %d", runs, fileRows, dirRows)
+	}
+
+	// Each row belongs to its run
+	var top string
+	err = db.QueryRow(`SELECT f.path FROM file_hotspots f JOIN runs r ON f.run_id = r.id WHERE r.head = 'bbb' ORDER BY f.commits DESC LIMIT 1`).Scan(&top)
+	if err != nil || top != "util.go" {
+		t.Errorf("Expected util.go to top the second run, got %q (%v)", top, err)
+	}
+}
+
+func TestWriteContributorsJSON(t *testing.T) {
+	contributors := []git.ContributorStat{
+		{Author: "Alice", Commits: 2, Files: 2},
+		{Author: "Bob", Commits: 1, Files: 1},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteContributorsJSON(&buf, contributors, 1); err != nil {
+		t.Fatalf("WriteContributorsJSON failed: %v", err)
+	}
+	var got []jsonContributorStat
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if expected := []jsonContributorStat{{Author: "Alice", Commits: 2, Files: 2}}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestWriteCounts(t *testing.T) {
+	summary := git.Summary{Commits: 3, Authors: 2, Files: 5}
+
+	var buf bytes.Buffer
+	if err := WriteCounts(&buf, summary); err != nil {
+		t.Fatalf("WriteCounts failed: %v", err)
+	}
+	if expected := "commits=3\nfiles=5\nauthors=2\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteCountsJSON(&buf, summary); err != nil {
+		t.Fatalf("WriteCountsJSON failed: %v", err)
+	}
+	var got jsonCounts
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if expected := (jsonCounts{Commits: 3, Files: 5, Authors: 2}); got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestWriteHeatmap(t *testing.T) {
+	heatmap := git.Heatmap{
+		Bucket: git.BucketMonth,
+		Periods: []time.Time{
+			time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Rows: []git.HeatmapRow{{Path: "main.go", Counts: []int{12, 0}}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHeatmap(&buf, heatmap); err != nil {
+		t.Fatalf("WriteHeatmap failed: %v", err)
+	}
+	if expected := "Commits per month from 2026-01 to 2026-02\n 12  ·  main.go\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteHeatmapJSON(&buf, heatmap); err != nil {
+		t.Fatalf("WriteHeatmapJSON failed: %v", err)
+	}
+	var got jsonHeatmap
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	expected := jsonHeatmap{
+		Bucket:  git.BucketMonth,
+		Periods: []string{"2026-01", "2026-02"},
+		Paths:   []string{"main.go"},
+		Counts:  [][]int{{12, 0}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestWriteHotspotTree(t *testing.T) {
+	root := git.BuildHotspotTree([]git.Hotspot{
+		{Path: "src/main.go", Commits: 3, Score: 3, LinesAdded: 7},
+		{Path: "README.md", Commits: 1, Score: 1},
+	})
+
+	var buf bytes.Buffer
+	if err := WriteHotspotTree(&buf, root); err != nil {
+		t.Fatalf("WriteHotspotTree failed: %v", err)
+	}
+	expected := ". (4 commits, score 4.0)\n" +
+		"├── src (3 commits, score 3.0)\n" +
+		"│   └── main.go (3 commits, score 3.0)\n" +
+		"└── README.md (1 commits, score 1.0)\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteHotspotTreeJSON(&buf, root); err != nil {
+		t.Fatalf("WriteHotspotTreeJSON failed: %v", err)
+	}
+	var got jsonHotspotTreeNode
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	main := jsonHotspotTreeNode{Path: "src/main.go", Type: "file", Commits: 3, Score: 3, LinesAdded: 7}
+	expectedJSON := jsonHotspotTreeNode{Path: ".", Type: "directory", Commits: 4, Score: 4, LinesAdded: 7, Children: []jsonHotspotTreeNode{
+		{Path: "src", Type: "directory", Commits: 3, Score: 3, LinesAdded: 7, Children: []jsonHotspotTreeNode{main}},
+		{Path: "README.md", Type: "file", Commits: 1, Score: 1},
+	}}
+	if !reflect.DeepEqual(got, expectedJSON) {
+		t.Errorf("Expected %+v, got %+v", expectedJSON, got)
+	}
+}
+
+func TestHeatLevel(t *testing.T) {
+	tests := []struct{ count, max, expected int }{
+		{0, 10, 0},
+		{1, 10, 1},
+		{4, 10, 2},
+		{10, 10, 3},
+		{0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := HeatLevel(tt.count, tt.max); got != tt.expected {
+			t.Errorf("HeatLevel(%d, %d) = %d, expected %d", tt.count, tt.max, got, tt.expected)
+		}
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	files := []git.Hotspot{
+		{Path: "a.go", Commits: 1, Score: 1},
+		{Path: "dir \"quoted\"\\new\nline.go", Commits: 3, Score: 2.5, Contributors: []git.Contributor{{Author: "Alice", Commits: 2}, {Author: "Bob", Commits: 1}}},
+	}
+	dirs := []git.Hotspot{{Path: "src", Commits: 2, Score: 2}}
+
+	// Only the top hotspot of each kind is written, with escaped labels
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, files, dirs, Options{TopCount: 1}); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	for _, line := range []string{
+		"# TYPE git_hotspot_commits gauge",
+		`git_hotspot_commits{path="dir \"quoted\"\\new\nline.go",kind="file"} 3`,
+		`git_hotspot_score{path="dir \"quoted\"\\new\nline.go",kind="file"} 2.5`,
+		`git_hotspot_authors{path="dir \"quoted\"\\new\nline.go",kind="file"} 2`,
+		`git_hotspot_commits{path="src",kind="directory"} 2`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("Expected line %q, got:\n%s", line, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "a.go") {
+		t.Errorf("Expected only the top file, got:\n%s", buf.String())
+	}
+
+	// Each repository's hotspots are labeled with it
+	buf.Reset()
+	repos := []RepositoryHotspots{
+		{Name: "api", Files: []git.Hotspot{{Path: "a.go", Commits: 1, Score: 1}}},
+		{Name: "web", Files: []git.Hotspot{{Path: "a.go", Commits: 1, Score: 1}}},
+	}
+	if err := WriteRepositoriesPrometheus(&buf, repos, Options{TopCount: 10}); err != nil {
+		t.Fatalf("WriteRepositoriesPrometheus failed: %v", err)
+	}
+	if got := strings.Count(buf.String(), "# TYPE git_hotspot_commits gauge"); got != 1 {
+		t.Errorf("Expected the commits metric to be declared once, got %d times", got)
+	}
+	if !strings.Contains(buf.String(), `git_hotspot_commits{path="a.go",kind="file",repository="web"} 1`) {
+		t.Errorf("Expected a repository label, got:\n%s", buf.String())
+	}
+}
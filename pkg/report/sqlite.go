@@ -0,0 +1,130 @@
+package report
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"git-hotspots/internal/git"
+
+	_ "modernc.org/sqlite" // Pure-Go driver, so builds don't need cgo
+)
+
+// sqliteSchema creates the tables snapshots are appended to. Dates are
+// stored as RFC 3339 text, which SQLite's date functions understand.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id           INTEGER PRIMARY KEY,
+	timestamp    TEXT NOT NULL,
+	ref          TEXT NOT NULL,
+	head         TEXT NOT NULL,
+	version      TEXT NOT NULL,
+	repositories TEXT NOT NULL,
+	since        TEXT
+);
+CREATE TABLE IF NOT EXISTS file_hotspots (
+	run_id          INTEGER NOT NULL REFERENCES runs(id),
+	path            TEXT NOT NULL,
+	commits         INTEGER NOT NULL,
+	score           REAL NOT NULL,
+	top_contributor TEXT NOT NULL,
+	author_commits  INTEGER NOT NULL,
+	first_seen      TEXT NOT NULL,
+	last_modified   TEXT NOT NULL,
+	lines_added     INTEGER NOT NULL,
+	lines_deleted   INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS dir_hotspots (
+	run_id          INTEGER NOT NULL REFERENCES runs(id),
+	path            TEXT NOT NULL,
+	commits         INTEGER NOT NULL,
+	score           REAL NOT NULL,
+	top_contributor TEXT NOT NULL,
+	author_commits  INTEGER NOT NULL,
+	first_seen      TEXT NOT NULL,
+	last_modified   TEXT NOT NULL,
+	lines_added     INTEGER NOT NULL,
+	lines_deleted   INTEGER NOT NULL
+);
+`
+
+// Run identifies the analysis a SQLite snapshot was taken from.
+type Run struct {
+	Time time.Time // When the analysis ran
+	Ref  string    // Name of the ref the history was walked from, e.g. refs/heads/main
+	Head string    // Hash of the commit the ref pointed to
+}
+
+// WriteSQLite appends a snapshot of the top file and directory hotspots to the
+// SQLite database at path, creating it and its tables if needed. Each call
+// adds a row to runs, so snapshots accumulate for querying trends over time.
+// The version, repositories and window start come from opts.Summary, if set.
+func WriteSQLite(path string, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, run Run, opts Options) error {
+	opts.Sort(fileHotspots)
+	opts.Sort(dirHotspots)
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version, repositories string
+	var since any
+	if opts.Summary != nil {
+		version = opts.Summary.Version
+		repositories = strings.Join(opts.Summary.Repositories, "\n")
+		if !opts.Summary.Since.IsZero() {
+			since = opts.Summary.Since.UTC().Format(time.RFC3339)
+		}
+	}
+	result, err := tx.Exec(`INSERT INTO runs (timestamp, ref, head, version, repositories, since) VALUES (?, ?, ?, ?, ?, ?)`,
+		run.Time.UTC().Format(time.RFC3339), run.Ref, run.Head, version, repositories, since)
+	if err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := insertSQLiteHotspots(tx, "file_hotspots", runID, fileHotspots, opts.TopCount); err != nil {
+		return err
+	}
+	if err := insertSQLiteHotspots(tx, "dir_hotspots", runID, dirHotspots, opts.TopCount); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertSQLiteHotspots inserts the first topCount hotspots into table for the given run.
+func insertSQLiteHotspots(tx *sql.Tx, table string, runID int64, hotspots []git.Hotspot, topCount int) error {
+	stmt, err := tx.Prepare(`INSERT INTO ` + table + ` (run_id, path, commits, score, top_contributor, author_commits, first_seen, last_modified, lines_added, lines_deleted) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, h := range hotspots {
+		if i >= topCount {
+			break
+		}
+		_, err := stmt.Exec(runID, QuotePath(h.Path), h.Commits, h.Score, h.TopContributor, h.AuthorCommits,
+			h.FirstSeen.UTC().Format(time.RFC3339), h.LastModified.UTC().Format(time.RFC3339),
+			h.LinesAdded, h.LinesDeleted)
+		if err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", table, err)
+		}
+	}
+	return nil
+}
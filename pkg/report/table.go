@@ -0,0 +1,209 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"git-hotspots/internal/git"
+)
+
+// Metric is the measure of change hotspot tables show and are ranked by.
+type Metric string
+
+const (
+	// MetricCommits shows commit counts, ranked by score.
+	MetricCommits Metric = "commits"
+
+	// MetricChurn shows and ranks by the lines added and deleted, which
+	// must have been counted.
+	MetricChurn Metric = "churn"
+
+	// MetricReverts shows and ranks by the commits reverting earlier ones.
+	MetricReverts Metric = "reverts"
+)
+
+// String returns a label for the metric, e.g. for titles.
+func (m Metric) String() string {
+	switch m {
+	case MetricChurn:
+		return "lines changed"
+	case MetricReverts:
+		return "reverts"
+	}
+	return "commits"
+}
+
+// SortHotspots sorts hotspots by metric in descending order.
+func SortHotspots(hotspots []git.Hotspot, metric Metric) {
+	git.SortHotspotsBy(hotspots, metric.key)
+}
+
+// SortColdspots sorts hotspots by metric in ascending order, least changed first.
+func SortColdspots(hotspots []git.Hotspot, metric Metric) {
+	git.SortColdspotsBy(hotspots, metric.key)
+}
+
+// key returns the value of the metric hotspots are ranked by, which is the
+// score for commits.
+func (m Metric) key(h git.Hotspot) float64 {
+	switch m {
+	case MetricChurn:
+		return float64(linesChanged(h))
+	case MetricReverts:
+		return float64(h.Reverts)
+	}
+	return h.Score
+}
+
+// linesChanged returns the number of lines added and deleted in a hotspot.
+func linesChanged(h git.Hotspot) int {
+	return h.LinesAdded + h.LinesDeleted
+}
+
+// HotspotTable formats hotspots as rows of aligned columns, the same columns
+// the UI shows, and returns them with their header. The first column shows
+// metric. Top contributors of sole-owned hotspots are marked with a !. Dates
+// are rendered in dateFormat and activity sparklines span the window from
+// since to now. A positive width shortens paths so rows fit in that many
+// columns, as ShortenPath does, down to minPathWidth.
+func HotspotTable(hotspots []git.Hotspot, pathHeader string, metric Metric, dateFormat DateFormat, since, now time.Time, width int) (string, []string) {
+	dateWidth, firstSeen, lastModified := dateColumns(hotspots, dateFormat, now)
+	metricHeader, metricWidth, value := metricColumn(metric)
+	header := fmt.Sprintf("%s  Top Contributor (Commits)  %-*s  %-*s  Activity      %s",
+		metricHeader, dateWidth, "First Seen", dateWidth, "Last Modified", pathHeader)
+	rows := make([]string, len(hotspots))
+	for i, hotspot := range hotspots {
+		marker := ""
+		if hotspot.SoleOwned {
+			marker = "!"
+		}
+		columns := fmt.Sprintf("%*d    %-20s (%d)%-4s%-*s  %-*s  %s  ",
+			metricWidth, value(hotspot),
+			hotspot.TopContributor,
+			hotspot.AuthorCommits, marker,
+			dateWidth, firstSeen[i],
+			dateWidth, lastModified[i],
+			Sparkline(Activity(hotspot, since, now)))
+		rows[i] = columns + fitPath(hotspot.Path, columns, width)
+	}
+	return header, rows
+}
+
+// CompactHotspotTable formats hotspots like HotspotTable for terminals width
+// columns wide, such as narrow windows and tmux panes, leaving out the top
+// contributor column.
+func CompactHotspotTable(hotspots []git.Hotspot, pathHeader string, metric Metric, dateFormat DateFormat, since, now time.Time, width int) (string, []string) {
+	dateWidth, firstSeen, lastModified := dateColumns(hotspots, dateFormat, now)
+	metricHeader, metricWidth, value := metricColumn(metric)
+	header := fmt.Sprintf("%*s  %-*s  %-*s  Activity      %s",
+		metricWidth, metricHeader, dateWidth, "First Seen", dateWidth, "Last Modified", pathHeader)
+	rows := make([]string, len(hotspots))
+	for i, hotspot := range hotspots {
+		columns := fmt.Sprintf("%*d  %-*s  %-*s  %s  ",
+			metricWidth, value(hotspot),
+			dateWidth, firstSeen[i],
+			dateWidth, lastModified[i],
+			Sparkline(Activity(hotspot, since, now)))
+		rows[i] = columns + fitPath(hotspot.Path, columns, width)
+	}
+	return header, rows
+}
+
+// dateColumns returns the first-seen and last-modified dates of hotspots
+// rendered in dateFormat, and the width of the columns fitting them.
+func dateColumns(hotspots []git.Hotspot, dateFormat DateFormat, now time.Time) (int, []string, []string) {
+	width := 14
+	firstSeen := make([]string, len(hotspots))
+	lastModified := make([]string, len(hotspots))
+	for i, hotspot := range hotspots {
+		firstSeen[i] = dateFormat.Format(hotspot.FirstSeen, now)
+		lastModified[i] = dateFormat.Format(hotspot.LastModified, now)
+		width = max(width, len(firstSeen[i]), len(lastModified[i]))
+	}
+	return width, firstSeen, lastModified
+}
+
+// minPathWidth is the fewest characters tables shorten paths to, below
+// which they'd be unrecognizable.
+const minPathWidth = 12
+
+// fitPath returns path quoted for output and, if width is positive,
+// shortened to fit after the columns preceding it in a row width wide, down
+// to minPathWidth, which may make the row wider than width.
+func fitPath(path, columns string, width int) string {
+	path = QuotePath(path)
+	if width <= 0 {
+		return path
+	}
+	return ShortenPath(path, max(width-utf8.RuneCountInString(columns), minPathWidth))
+}
+
+// metricColumn returns the header and width of the column showing metric in
+// tables, and the value it shows for a hotspot: commits, lines changed or
+// reverts, sized to the header.
+func metricColumn(metric Metric) (string, int, func(git.Hotspot) int) {
+	switch metric {
+	case MetricChurn:
+		return "Lines Changed", 13, linesChanged
+	case MetricReverts:
+		return "Reverts", 7, func(h git.Hotspot) int { return h.Reverts }
+	}
+	return "Commits", 7, func(h git.Hotspot) int { return h.Commits }
+}
+
+// WriteTable writes the top file and directory hotspots to w as plain-text
+// tables, for terminals where the UI can't run, leaving out either if
+// opts.NoFiles or opts.NoDirs is set. Dates are relative unless
+// opts.DateFormat says otherwise.
+func WriteTable(w io.Writer, fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts Options) error {
+	filesTitle, dirsTitle := opts.Titles()
+	if !opts.NoFiles {
+		if err := writeTable(w, filesTitle, fileHotspots, "File Path", opts); err != nil {
+			return err
+		}
+		if opts.NoDirs {
+			return nil
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return writeTable(w, dirsTitle, dirHotspots, "Directory Path", opts)
+}
+
+func writeTable(w io.Writer, title string, hotspots []git.Hotspot, pathHeader string, opts Options) error {
+	opts.Sort(hotspots)
+	if len(hotspots) > opts.TopCount {
+		hotspots = hotspots[:opts.TopCount]
+	}
+
+	now := time.Now()
+	header, rows := HotspotTable(hotspots, pathHeader, opts.Metric, opts.DateFormat.Or(DateRelative), opts.WindowStart(now), now, 0)
+	lines := append([]string{title, header, strings.Repeat("-", len(header))}, rows...)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRepositoriesTable writes the top file and directory hotspots of each
+// repository to w as plain-text tables under the repository's name.
+func WriteRepositoriesTable(w io.Writer, repos []RepositoryHotspots, opts Options) error {
+	for _, repo := range repos {
+		if _, err := fmt.Fprintf(w, "Repository: %s\n\n", repo.Name); err != nil {
+			return err
+		}
+		if err := WriteTable(w, repo.Files, repo.Directories, opts); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonHotspotTreeNode is the JSON representation of a hotspot tree node.
+type jsonHotspotTreeNode struct {
+	Path         string                `json:"path"`
+	Type         string                `json:"type"`
+	Commits      int                   `json:"commits"`
+	Score        float64               `json:"score"`
+	LinesAdded   int                   `json:"linesAdded,omitempty"`
+	LinesDeleted int                   `json:"linesDeleted,omitempty"`
+	Children     []jsonHotspotTreeNode `json:"children,omitempty"`
+}
+
+// HotspotTreeLabel returns the display label for a hotspot tree node,
+// e.g. "src (12 commits, score 8.5)".
+func HotspotTreeLabel(node *git.HotspotTreeNode) string {
+	return fmt.Sprintf("%s (%d commits, score %.1f)", QuotePath(node.Name), node.Commits, node.Score)
+}
+
+// WriteHotspotTree writes the hotspot tree to w as an indented tree.
+func WriteHotspotTree(w io.Writer, root *git.HotspotTreeNode) error {
+	if _, err := fmt.Fprintln(w, HotspotTreeLabel(root)); err != nil {
+		return err
+	}
+	return writeHotspotTreeChildren(w, root, "")
+}
+
+func writeHotspotTreeChildren(w io.Writer, node *git.HotspotTreeNode, prefix string) error {
+	for i, child := range node.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		if _, err := fmt.Fprintln(w, prefix+branch+HotspotTreeLabel(child)); err != nil {
+			return err
+		}
+		if err := writeHotspotTreeChildren(w, child, prefix+indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHotspotTreeJSON writes the hotspot tree to w as nested JSON, each
+// node typed as a file or directory.
+func WriteHotspotTreeJSON(w io.Writer, root *git.HotspotTreeNode) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toJSONHotspotTreeNode(root))
+}
+
+func toJSONHotspotTreeNode(node *git.HotspotTreeNode) jsonHotspotTreeNode {
+	result := jsonHotspotTreeNode{
+		Path:         QuotePath(node.Path),
+		Type:         "directory",
+		Commits:      node.Commits,
+		Score:        node.Score,
+		LinesAdded:   node.LinesAdded,
+		LinesDeleted: node.LinesDeleted,
+	}
+	if node.File {
+		result.Type = "file"
+	}
+	for _, child := range node.Children {
+		result.Children = append(result.Children, toJSONHotspotTreeNode(child))
+	}
+	return result
+}
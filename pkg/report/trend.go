@@ -0,0 +1,74 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"git-hotspots/internal/git"
+)
+
+// jsonTrend is the JSON representation of a trend. ChangePercent is null
+// for files without commits before the split point.
+type jsonTrend struct {
+	Path          string   `json:"path"`
+	Earlier       int      `json:"earlierCommits"`
+	Later         int      `json:"laterCommits"`
+	ChangePercent *float64 `json:"changePercent"`
+}
+
+// TrendLabel returns the display label for a trend's change,
+// e.g. "↓ 50%" for a file cooling down or "↑ new" for one with no earlier commits.
+func TrendLabel(trend git.Trend) string {
+	switch {
+	case math.IsInf(trend.Change, 1):
+		return "↑ new"
+	case trend.Change > 0:
+		return fmt.Sprintf("↑ %.0f%%", trend.Change)
+	case trend.Change < 0:
+		return fmt.Sprintf("↓ %.0f%%", -trend.Change)
+	default:
+		return "→ 0%"
+	}
+}
+
+// WriteTrends writes the top trends to w as a plain-text table.
+func WriteTrends(w io.Writer, trends []git.Trend, topCount int) error {
+	if _, err := fmt.Fprintf(w, "%-8s  %-7s  %-5s  %s\n", "Trend", "Earlier", "Later", "Path"); err != nil {
+		return err
+	}
+	for i, trend := range trends {
+		if i >= topCount {
+			break
+		}
+		if _, err := fmt.Fprintf(w, "%-8s  %7d  %5d  %s\n", TrendLabel(trend), trend.Earlier, trend.Later, QuotePath(trend.Path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTrendsJSON writes the top trends to w as a JSON array.
+func WriteTrendsJSON(w io.Writer, trends []git.Trend, topCount int) error {
+	result := []jsonTrend{}
+	for i, trend := range trends {
+		if i >= topCount {
+			break
+		}
+		jt := jsonTrend{
+			Path:    QuotePath(trend.Path),
+			Earlier: trend.Earlier,
+			Later:   trend.Later,
+		}
+		if !math.IsInf(trend.Change, 1) {
+			change := trend.Change
+			jt.ChangePercent = &change
+		}
+		result = append(result, jt)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -2,71 +2,650 @@ package ui
 
 import (
 	"fmt"
+	"io"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 
 	"git-hotspots/internal/git"
+	"git-hotspots/pkg/report"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// Analyzer recomputes file and directory hotspots for commits since the given time.
+// A zero time analyzes the full history. If countLines is set, the lines
+// changed in each hotspot must be counted.
+type Analyzer func(since time.Time, countLines bool) ([]git.Hotspot, []git.Hotspot, error)
+
+// window is a selectable analysis time window.
+type window struct {
+	label string
+	since func(now time.Time) time.Time
+}
+
+// windows are cycled through with the 't' key.
+var windows = []window{
+	{"30d", func(now time.Time) time.Time { return now.AddDate(0, 0, -30) }},
+	{"90d", func(now time.Time) time.Time { return now.AddDate(0, 0, -90) }},
+	{"1y", git.DefaultSince},
+	{"all", func(time.Time) time.Time { return time.Time{} }},
+}
+
+// defaultWindow is the index of the window the initial hotspots were computed for.
+const defaultWindow = 2
+
+// hotspotPanes are the file and directory panes showing one set of hotspots,
+// with a detail pane listing the commits of the selected file.
+type hotspotPanes struct {
+	flex         *tview.Flex
+	fileTextView *tview.TextView
+	dirTextView  *tview.TextView
+	detailView   *tview.TextView
+	dateFormat   report.DateFormat
+	noColor      bool
+	metric       report.Metric
+	linesCounted bool // Whether the hotspots' lines changed were counted
+	scoreIsOther bool // Whether scores are another metric, so commits must be sorted by count
+	reverse      bool // Whether the least changed hotspots are listed first
+	compact      bool // Whether tables leave out the top contributor for small terminals
+
+	// The hotspots last rendered, kept to re-render them for another metric
+	fileHotspots []git.Hotspot
+	dirHotspots  []git.Hotspot
+	topCount     int
+	since        time.Time
+	label        string
+
+	files      []git.Hotspot // File hotspots in display order
+	selected   int           // Index of the selected file
+	showDetail bool
+}
+
+// newHotspotPanes creates empty file and directory panes stacked vertically,
+// rendering dates, colors and the metric as set in opts. The file or directory
+// pane is left out if opts.NoFiles or opts.NoDirs is set. Lines changed are
+// assumed to be counted if the metric is churn.
+func newHotspotPanes(opts report.Options) *hotspotPanes {
+	p := &hotspotPanes{
+		fileTextView: tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWrap(false),
+		dirTextView:  tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWrap(false),
+		detailView:   tview.NewTextView().SetWrap(false),
+		dateFormat:   opts.DateFormat.Or(report.DateRelative),
+		noColor:      opts.NoColor,
+		metric:       opts.Metric,
+		linesCounted: opts.Metric == report.MetricChurn,
+		scoreIsOther: opts.Metric != report.MetricCommits && opts.Metric != "",
+		reverse:      opts.Reverse,
+		compact:      opts.Compact,
+	}
+	if p.metric == "" {
+		p.metric = report.MetricCommits
+	}
+	p.fileTextView.SetBorder(true)
+	p.dirTextView.SetBorder(true)
+	p.detailView.SetBorder(true)
+
+	// Create a flex layout to arrange the text views, with the detail pane
+	// added to the right when shown. Tables are rendered again whenever the
+	// panes are resized, to fit their paths
+	rows := tview.NewFlex().SetDirection(tview.FlexRow)
+	if !opts.NoFiles {
+		rows.AddItem(&resizingTextView{TextView: p.fileTextView, resized: p.redraw}, 0, 1, false)
+	}
+	if !opts.NoDirs {
+		rows.AddItem(&resizingTextView{TextView: p.dirTextView, resized: p.redraw}, 0, 1, false)
+	}
+	p.flex = tview.NewFlex().AddItem(rows, 0, 2, false)
+	return p
+}
+
+// setTitles sets the pane titles, with label describing the hotspots shown
+// alongside the metric they're ranked by.
+func (p *hotspotPanes) setTitles(label string) {
+	p.label = label
+	files, dirs := report.Options{Reverse: p.reverse}.Titles()
+	p.fileTextView.SetTitle(fmt.Sprintf("%s (%s, by %s)", files, label, p.metric))
+	p.dirTextView.SetTitle(fmt.Sprintf("%s (%s, by %s)", dirs, label, p.metric))
+}
+
+// render populates both panes with the top hotspots for the window starting at since.
+// A zero since means the full history.
+func (p *hotspotPanes) render(fileHotspots, dirHotspots []git.Hotspot, topCount int, since time.Time) {
+	p.fileHotspots, p.dirHotspots = fileHotspots, dirHotspots
+	p.topCount, p.since = topCount, since
+	p.redraw()
+}
+
+// redraw renders the hotspots last passed to render again, e.g. after the
+// metric changed.
+func (p *hotspotPanes) redraw() {
+	now := time.Now()
+	since := p.since
+	if since.IsZero() {
+		since = earliestFirstSeen(slices.Concat(p.fileHotspots, p.dirHotspots), now)
+	}
+
+	p.sortHotspots(p.fileHotspots)
+	p.sortHotspots(p.dirHotspots)
+	p.files = renderHotspots(p.fileTextView, p.fileHotspots, p.topCount, "File Path", p.metric, p.dateFormat, p.noColor, p.compact, width(p.fileTextView), since, now)
+	renderHotspots(p.dirTextView, p.dirHotspots, p.topCount, "Directory Path", p.metric, p.dateFormat, p.noColor, p.compact, width(p.dirTextView), since, now)
+	p.selectFile(p.selected)
+}
+
+// width returns the width of the text inside view.
+func width(view *tview.TextView) int {
+	_, _, width, _ := view.GetInnerRect()
+	return width
+}
+
+// resizingTextView is a text view calling resized before it's drawn at a
+// different width than the last time.
+type resizingTextView struct {
+	*tview.TextView
+	width   int
+	resized func()
+}
+
+// Draw draws the text view, calling resized first if its width changed.
+func (v *resizingTextView) Draw(screen tcell.Screen) {
+	if _, _, width, _ := v.GetInnerRect(); width != v.width {
+		v.width = width
+		v.resized()
+	}
+	v.TextView.Draw(screen)
+}
+
+// sortHotspots sorts hotspots by the current metric.
+func (p *hotspotPanes) sortHotspots(hotspots []git.Hotspot) {
+	if p.metric == report.MetricCommits && p.scoreIsOther {
+		commits := func(h git.Hotspot) float64 {
+			return float64(h.Commits)
+		}
+		if p.reverse {
+			git.SortColdspotsBy(hotspots, commits)
+		} else {
+			git.SortHotspotsBy(hotspots, commits)
+		}
+		return
+	}
+	report.Options{Metric: p.metric, Reverse: p.reverse}.Sort(hotspots)
+}
+
+// toggleMetric switches between ranking by commits and by lines changed,
+// re-sorting the hotspots shown.
+func (p *hotspotPanes) toggleMetric() {
+	if p.metric == report.MetricChurn {
+		p.metric = report.MetricCommits
+	} else {
+		p.metric = report.MetricChurn
+	}
+	p.setTitles(p.label)
+	p.redraw()
+}
+
+// selectFile highlights the file at index i, clamped to the files shown,
+// and updates the detail pane.
+func (p *hotspotPanes) selectFile(i int) {
+	p.selected = max(0, min(i, len(p.files)-1))
+	p.fileTextView.Highlight(fmt.Sprint(p.selected)).ScrollToHighlight()
+	p.renderDetail()
+}
+
+// setDetailShown shows or hides the detail pane.
+func (p *hotspotPanes) setDetailShown(show bool) {
+	if show == p.showDetail {
+		return
+	}
+	p.showDetail = show
+	if show {
+		p.flex.AddItem(p.detailView, 0, 1, false)
+		p.renderDetail()
+	} else {
+		p.flex.RemoveItem(p.detailView)
+	}
+}
+
+// renderDetail lists the commits of the selected file, newest first, after
+// its full path, which the table may have shortened, and its lifetime if
+// known.
+func (p *hotspotPanes) renderDetail() {
+	p.detailView.Clear()
+	p.detailView.ScrollToBeginning()
+	if len(p.files) == 0 {
+		p.detailView.SetTitle("Commits")
+		return
+	}
+
+	hotspot := p.files[p.selected]
+	p.detailView.SetTitle(fmt.Sprintf("Commits to %s (q/Esc to close)", tview.Escape(report.QuotePath(hotspot.Path))))
+
+	history := append([]git.CommitRef(nil), hotspot.History...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.After(history[j].Date)
+	})
+
+	now := time.Now()
+	fmt.Fprintf(p.detailView, "Path: %s\n", report.QuotePath(hotspot.Path))
+	if lifetime := report.DescribeLifetime(hotspot, p.dateFormat, now); lifetime != "" {
+		fmt.Fprintf(p.detailView, "Lifetime: %s\n", lifetime)
+	}
+	fmt.Fprintln(p.detailView)
+	for _, ref := range history {
+		hash := ref.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		fmt.Fprintf(p.detailView, "%s  %-14s  %-20s  %s\n",
+			hash, p.dateFormat.Format(ref.Date, now), ref.Author, ref.Subject)
+	}
+}
+
+// handleKey moves the file selection with the arrow keys or j/k, opens the
+// detail pane with Enter and closes it with q or Esc. PgUp and PgDn scroll
+// the detail pane, and c toggles the metric if lines were counted.
+// It returns nil for keys it handled.
+func (p *hotspotPanes) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case event.Rune() == 'c' && p.linesCounted:
+		p.toggleMetric()
+	case event.Key() == tcell.KeyUp || event.Rune() == 'k':
+		p.selectFile(p.selected - 1)
+	case event.Key() == tcell.KeyDown || event.Rune() == 'j':
+		p.selectFile(p.selected + 1)
+	case event.Key() == tcell.KeyEnter:
+		p.setDetailShown(true)
+	case p.showDetail && (event.Key() == tcell.KeyEscape || event.Rune() == 'q'):
+		p.setDetailShown(false)
+	case p.showDetail && event.Key() == tcell.KeyPgUp:
+		row, _ := p.detailView.GetScrollOffset()
+		p.detailView.ScrollTo(max(0, row-10), 0)
+	case p.showDetail && event.Key() == tcell.KeyPgDn:
+		row, _ := p.detailView.GetScrollOffset()
+		p.detailView.ScrollTo(row+10, 0)
+	default:
+		return event
+	}
+	return nil
+}
+
+// newScreen creates the terminal screen for the UI. Tests replace it to
+// simulate terminals that can't be used.
+var newScreen = tcell.NewScreen
+
+// newApplication creates an application drawing on a new screen. If noColor
+// is set, everything is drawn in the terminal's default colors.
+func newApplication(noColor bool) (*tview.Application, error) {
+	screen, err := newScreen()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create terminal screen: %w", err)
+	}
+	if noColor {
+		tview.Styles = monochromeTheme
+	}
+	return tview.NewApplication().SetScreen(screen), nil
+}
+
+// monochromeTheme draws every primitive in the terminal's default colors.
+var monochromeTheme = tview.Theme{
+	PrimitiveBackgroundColor:    tcell.ColorDefault,
+	ContrastBackgroundColor:     tcell.ColorDefault,
+	MoreContrastBackgroundColor: tcell.ColorDefault,
+	BorderColor:                 tcell.ColorDefault,
+	TitleColor:                  tcell.ColorDefault,
+	GraphicsColor:               tcell.ColorDefault,
+	PrimaryTextColor:            tcell.ColorDefault,
+	SecondaryTextColor:          tcell.ColorDefault,
+	TertiaryTextColor:           tcell.ColorDefault,
+	InverseTextColor:            tcell.ColorDefault,
+	ContrastSecondaryTextColor:  tcell.ColorDefault,
+}
+
+// colored wraps text in a tview color tag, unless noColor is set.
+func colored(text, color string, noColor bool) string {
+	if noColor {
+		return text
+	}
+	return fmt.Sprintf("[%s]%s[-]", color, text)
+}
+
 // DisplayHotspots displays the given file and directory hotspots in a terminal UI.
-// topCount specifies the number of top files and directories to display.
-func DisplayHotspots(fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, topCount int) {
-	app := tview.NewApplication()
+// opts.TopCount specifies the number of top files and directories to display,
+// opts.DateFormat how their dates are shown, relative by default, and
+// opts.NoColor disables colors.
+// If analyze is not nil, pressing 't' cycles the analysis window and re-runs it,
+// and pressing 'c' counts lines changed if needed to toggle ranking by them.
+// It returns an error if the terminal UI can't be started.
+func DisplayHotspots(fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts report.Options, analyze Analyzer) error {
+	return WatchHotspots(fileHotspots, dirHotspots, opts, analyze, nil)
+}
 
-	// Sort hotspots for consistent display
-	sort.Slice(fileHotspots, func(i, j int) bool {
-		return fileHotspots[i].Commits > fileHotspots[j].Commits
+// WatchHotspots is like DisplayHotspots, but also re-runs analyze for the
+// current window whenever changes receives, e.g. because the repository's
+// HEAD moved, so the UI stays current.
+func WatchHotspots(fileHotspots []git.Hotspot, dirHotspots []git.Hotspot, opts report.Options, analyze Analyzer, changes <-chan struct{}) error {
+	app, err := newApplication(opts.NoColor)
+	if err != nil {
+		return err
+	}
+	panes := newHotspotPanes(opts)
+
+	// Populate both panes for the current window
+	current := defaultWindow
+	render := func(fileHotspots, dirHotspots []git.Hotspot) {
+		panes.setTitles(windows[current].label)
+		panes.render(fileHotspots, dirHotspots, opts.TopCount, windows[current].since(time.Now()))
+	}
+	render(fileHotspots, dirHotspots)
+
+	// reload re-runs the analysis for the current window in the background,
+	// so the UI stays responsive, optionally toggling the metric afterwards.
+	// A change arriving meanwhile makes it run again once done.
+	loading, stale := false, false
+	var reload func(countLines, toggle bool, title string)
+	reload = func(countLines, toggle bool, title string) {
+		loading = true
+		panes.setTitles(title)
+		since := windows[current].since(time.Now())
+		go func() {
+			fileHotspots, dirHotspots, err := analyze(since, countLines)
+			app.QueueUpdateDraw(func() {
+				loading = false
+				if err != nil {
+					panes.setTitles(colored(fmt.Sprintf("error: %v", err), "red", opts.NoColor))
+					return
+				}
+				panes.linesCounted = countLines
+				render(fileHotspots, dirHotspots)
+				if toggle {
+					panes.toggleMetric()
+				}
+				if stale {
+					stale = false
+					reload(panes.linesCounted, false, fmt.Sprintf("refreshing %s...", windows[current].label))
+				}
+			})
+		}()
+	}
+
+	// Cycle the analysis window or count lines changed
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event = panes.handleKey(event); event == nil {
+			return nil
+		}
+		if (event.Rune() != 't' && event.Rune() != 'c') || analyze == nil || loading {
+			return event
+		}
+
+		// Lines haven't been counted yet if 'c' got here
+		if event.Rune() == 't' {
+			current = (current + 1) % len(windows)
+			reload(panes.linesCounted, false, fmt.Sprintf("loading %s...", windows[current].label))
+		} else {
+			reload(true, true, fmt.Sprintf("counting lines for %s...", windows[current].label))
+		}
+		return nil
 	})
-	sort.Slice(dirHotspots, func(i, j int) bool {
-		return dirHotspots[i].Commits > dirHotspots[j].Commits
+
+	// Refresh whenever the repository changes
+	if changes != nil && analyze != nil {
+		go func() {
+			for range changes {
+				app.QueueUpdateDraw(func() {
+					if loading {
+						stale = true
+						return
+					}
+					reload(panes.linesCounted, false, fmt.Sprintf("refreshing %s...", windows[current].label))
+				})
+			}
+		}()
+	}
+
+	// Set the root primitive and run the application
+	return app.SetRoot(panes.flex, true).Run()
+}
+
+// DisplayRepositoryHotspots displays the hotspots of several repositories in
+// separate tabs, switched with Tab and Shift-Tab.
+// opts and the returned error are as for DisplayHotspots.
+func DisplayRepositoryHotspots(repos []report.RepositoryHotspots, opts report.Options) error {
+	app, err := newApplication(opts.NoColor)
+	if err != nil {
+		return err
+	}
+	pages := tview.NewPages()
+	tabs := tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWrap(false)
+
+	allPanes := make([]*hotspotPanes, len(repos))
+	for i, repo := range repos {
+		panes := newHotspotPanes(opts)
+		allPanes[i] = panes
+		panes.setTitles(repo.Name)
+		panes.render(repo.Files, repo.Directories, opts.TopCount, opts.WindowStart(time.Now()))
+		pages.AddPage(repo.Name, panes.flex, true, i == 0)
+		fmt.Fprintf(tabs, `["%d"] %s [""]  `, i, repo.Name)
+	}
+
+	// Highlight the tab of the current repository
+	current := 0
+	show := func(i int) {
+		current = (i + len(repos)) % len(repos)
+		pages.SwitchToPage(repos[current].Name)
+		tabs.Highlight(fmt.Sprint(current))
+	}
+	if len(repos) > 0 {
+		show(0)
+	}
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case len(repos) == 0:
+			return event
+		case event.Key() == tcell.KeyTab:
+			show(current + 1)
+			return nil
+		case event.Key() == tcell.KeyBacktab:
+			show(current - 1)
+			return nil
+		}
+		return allPanes[current].handleKey(event)
 	})
 
-	// Create a text view for file hotspots
-	fileTextView := tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWrap(false)
-	fileTextView.SetBorder(true).SetTitle("Top Hotspot Files")
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tabs, 1, 0, false).
+		AddItem(pages, 0, 1, false)
 
-	// Populate file hotspots
-	fmt.Fprintln(fileTextView, "[yellow]Commits  Top Contributor (Commits)  File Path[-]")
-	fmt.Fprintln(fileTextView, "[yellow]-----------------------------------------------[-]")
-	for i, hotspot := range fileHotspots {
-		if i >= topCount { // Display top N files
-			break
+	return app.SetRoot(layout, true).Run()
+}
+
+// renderHotspots replaces the contents of view with the top hotspots, which
+// must be sorted, and returns them. Each row is a region named by its index.
+// pathHeader is the title of the path column, and metric what the hotspots
+// show and are ranked by. The header is yellow and sole-owned hotspots are
+// red unless noColor is set. A positive width shortens paths so rows fit in
+// that many columns, and compact leaves out the top contributor column.
+func renderHotspots(view *tview.TextView, hotspots []git.Hotspot, topCount int, pathHeader string, metric report.Metric, dateFormat report.DateFormat, noColor, compact bool, width int, since, now time.Time) []git.Hotspot {
+	view.Clear()
+
+	if len(hotspots) > topCount { // Display top N hotspots
+		hotspots = hotspots[:topCount]
+	}
+
+	header, rows := report.HotspotTable(hotspots, pathHeader, metric, dateFormat, since, now, width)
+	if compact {
+		header, rows = report.CompactHotspotTable(hotspots, pathHeader, metric, dateFormat, since, now, width)
+	}
+	fmt.Fprintln(view, colored(header, "yellow", noColor))
+	fmt.Fprintln(view, colored(strings.Repeat("-", len(header)), "yellow", noColor))
+	for i, row := range rows {
+		row = tview.Escape(row)
+		if hotspots[i].SoleOwned {
+			row = colored(row, "red", noColor)
 		}
-		fmt.Fprintf(fileTextView, "%7d    %-20s (%d)    %s\n", 
-			hotspot.Commits, 
-			hotspot.TopContributor, 
-			hotspot.AuthorCommits,
-			hotspot.Path)
-	}
-
-	// Create a text view for directory hotspots
-	dirTextView := tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWrap(false)
-	dirTextView.SetBorder(true).SetTitle("Top Hotspot Directories")
-
-	// Populate directory hotspots
-	fmt.Fprintln(dirTextView, "[yellow]Commits  Top Contributor (Commits)  Directory Path[-]")
-	fmt.Fprintln(dirTextView, "[yellow]---------------------------------------------------[-]")
-	for i, hotspot := range dirHotspots {
-		if i >= topCount { // Display top N directories
-			break
+		fmt.Fprintf(view, "[\"%d\"]%s[\"\"]\n", i, row)
+	}
+	return hotspots
+}
+
+// earliestFirstSeen returns the earliest first-seen date of the hotspots with commits,
+// used as the start of the sparkline range when analyzing the full history.
+func earliestFirstSeen(hotspots []git.Hotspot, now time.Time) time.Time {
+	earliest := now
+	for _, h := range hotspots {
+		if !h.FirstSeen.IsZero() && h.FirstSeen.Before(earliest) {
+			earliest = h.FirstSeen
 		}
-		fmt.Fprintf(dirTextView, "%7d    %-20s (%d)    %s\n", 
-			hotspot.Commits, 
-			hotspot.TopContributor, 
-			hotspot.AuthorCommits,
-			hotspot.Path)
 	}
+	return earliest
+}
 
-	// Create a flex layout to arrange the text views
-	flex := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(fileTextView, 0, 1, false).
-		AddItem(dirTextView, 0, 1, false)
+// DisplayKnowledgeMap displays the knowledge map as an expandable directory tree.
+// Colors are disabled if opts.NoColor is set.
+func DisplayKnowledgeMap(root *git.KnowledgeNode, opts report.Options) error {
+	app, err := newApplication(opts.NoColor)
+	if err != nil {
+		return err
+	}
 
-	// Set the root primitive and run the application
-	if err := app.SetRoot(flex, true).Run(); err != nil {
-		panic(err)
+	rootNode := newKnowledgeTreeNode(root)
+	treeView := tview.NewTreeView().SetRoot(rootNode).SetCurrentNode(rootNode)
+	treeView.SetBorder(true).SetTitle("Knowledge Map")
+
+	// Expand or collapse a directory on selection
+	treeView.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+
+	return app.SetRoot(treeView, true).Run()
+}
+
+// newKnowledgeTreeNode converts a knowledge map node and its children into tree nodes.
+func newKnowledgeTreeNode(node *git.KnowledgeNode) *tview.TreeNode {
+	treeNode := tview.NewTreeNode(report.KnowledgeLabel(node)).SetSelectable(true)
+	for _, child := range node.Children {
+		treeNode.AddChild(newKnowledgeTreeNode(child))
+	}
+	return treeNode
+}
+
+// DisplayHotspotTree displays the hotspot tree, with the directories under
+// the root collapsed until selected.
+func DisplayHotspotTree(root *git.HotspotTreeNode, opts report.Options) error {
+	app, err := newApplication(opts.NoColor)
+	if err != nil {
+		return err
+	}
+
+	rootNode := newHotspotTreeNode(root, 0)
+	treeView := tview.NewTreeView().SetRoot(rootNode).SetCurrentNode(rootNode)
+	treeView.SetBorder(true).SetTitle("Hotspot Tree")
+
+	// Expand or collapse a directory on selection
+	treeView.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+
+	return app.SetRoot(treeView, true).Run()
+}
+
+// newHotspotTreeNode converts a hotspot tree node at depth and its children
+// into tree nodes, expanding only the root.
+func newHotspotTreeNode(node *git.HotspotTreeNode, depth int) *tview.TreeNode {
+	treeNode := tview.NewTreeNode(report.HotspotTreeLabel(node)).SetSelectable(true).SetExpanded(depth == 0)
+	for _, child := range node.Children {
+		treeNode.AddChild(newHotspotTreeNode(child, depth+1))
 	}
+	return treeNode
+}
+
+// DisplayOwnershipChanges displays the files whose dominant author changed
+// over the analysis window.
+func DisplayOwnershipChanges(changes []git.OwnershipChange, opts report.Options) error {
+	return displayReport("Ownership Changes", opts.NoColor, func(w io.Writer) error {
+		return report.WriteOwnershipChanges(w, changes, opts.TopCount)
+	})
+}
+
+// DisplayTrends displays the files that are cooling down or heating up.
+func DisplayTrends(trends []git.Trend, opts report.Options) error {
+	return displayReport("Trends", opts.NoColor, func(w io.Writer) error {
+		return report.WriteTrends(w, trends, opts.TopCount)
+	})
+}
+
+// DisplayHeatmap displays the commits touching each file in each period as
+// a grid whose cells are colored from green to red by how busy the period
+// was, unless opts.NoColor is set.
+func DisplayHeatmap(heatmap git.Heatmap, opts report.Options) error {
+	app, err := newApplication(opts.NoColor)
+	if err != nil {
+		return err
+	}
+
+	view := tview.NewTextView().SetDynamicColors(true).SetWrap(false)
+	view.SetBorder(true).SetTitle("Heatmap")
+	renderHeatmap(view, heatmap, opts.NoColor)
+	return app.SetRoot(view, true).Run()
 }
 
+// heatColors are the colors of heatmap cells at each level above zero.
+var heatColors = [report.HeatLevels]string{"green", "yellow", "red"}
 
+// renderHeatmap writes heatmap to view as a grid with a row per file, its
+// title in yellow and its cells colored by heat unless noColor is set.
+func renderHeatmap(view *tview.TextView, heatmap git.Heatmap, noColor bool) {
+	fmt.Fprintln(view, colored(report.HeatmapTitle(heatmap), "yellow", noColor))
+	max := report.HeatmapMax(heatmap)
+	width := report.HeatmapCellWidth(max)
+	for _, row := range heatmap.Rows {
+		var line strings.Builder
+		for _, c := range row.Counts {
+			cell := report.HeatmapCell(c, width)
+			if level := report.HeatLevel(c, max); level > 0 {
+				cell = colored(cell, heatColors[level-1], noColor)
+			}
+			line.WriteString(cell)
+		}
+		fmt.Fprintf(view, "%s  %s\n", line.String(), tview.Escape(report.QuotePath(row.Path)))
+	}
+}
+
+// DisplayDefects displays the files touched by the most bug-fixing commits.
+func DisplayDefects(hotspots []git.Hotspot, opts report.Options) error {
+	return displayReport("Defect Hotspots", opts.NoColor, func(w io.Writer) error {
+		return report.WriteDefects(w, hotspots, opts.TopCount)
+	})
+}
+
+// DisplayContributors displays the authors with the most commits.
+func DisplayContributors(contributors []git.ContributorStat, opts report.Options) error {
+	return displayReport("Contributors", opts.NoColor, func(w io.Writer) error {
+		return report.WriteContributors(w, contributors, opts.TopCount)
+	})
+}
+
+// DisplayFileHistory displays the commits that changed a single file.
+func DisplayFileHistory(file string, history []git.FileChange, opts report.Options) error {
+	return displayReport("File History", opts.NoColor, func(w io.Writer) error {
+		return report.WriteFileHistory(w, file, history, opts)
+	})
+}
+
+// displayReport displays a plain-text report in a scrollable view.
+func displayReport(title string, noColor bool, write func(w io.Writer) error) error {
+	app, err := newApplication(noColor)
+	if err != nil {
+		return err
+	}
+
+	view := tview.NewTextView().SetWrap(false)
+	view.SetBorder(true).SetTitle(title)
+	if err := write(view); err != nil {
+		return err
+	}
+
+	return app.SetRoot(view, true).Run()
+}
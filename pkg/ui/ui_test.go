@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"git-hotspots/internal/git"
+	"git-hotspots/pkg/report"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestDisplayHotspotsWithoutTerminal(t *testing.T) {
+	defer func(orig func() (tcell.Screen, error)) { newScreen = orig }(newScreen)
+	newScreen = func() (tcell.Screen, error) {
+		return nil, errors.New("no terminal")
+	}
+
+	hotspots := []git.Hotspot{{Path: "main.go", Commits: 1, Score: 1, LastModified: time.Now()}}
+	if err := DisplayHotspots(hotspots, nil, report.Options{TopCount: 10}, nil); err == nil {
+		t.Errorf("Expected an error when the screen can't be created")
+	}
+	if err := DisplayTrends(nil, report.Options{TopCount: 10}); err == nil {
+		t.Errorf("Expected an error when the screen can't be created")
+	}
+}
+
+func TestRenderHotspotsNoColor(t *testing.T) {
+	now := time.Now()
+	hotspots := []git.Hotspot{{Path: "main.go", Commits: 1, Score: 1, FirstSeen: now, LastModified: now}}
+
+	view := tview.NewTextView().SetDynamicColors(true)
+	renderHotspots(view, hotspots, 10, "File Path", report.MetricCommits, report.DateRelative, false, false, 0, now, now)
+	if !strings.Contains(view.GetText(false), "[yellow]") {
+		t.Errorf("Expected a yellow header, got: %q", view.GetText(false))
+	}
+
+	view = tview.NewTextView().SetDynamicColors(true)
+	renderHotspots(view, hotspots, 10, "File Path", report.MetricCommits, report.DateRelative, true, false, 0, now, now)
+	if strings.Contains(view.GetText(false), "[yellow]") {
+		t.Errorf("Expected no color tags, got: %q", view.GetText(false))
+	}
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	heatmap := git.Heatmap{
+		Bucket:  git.BucketWeek,
+		Periods: []time.Time{time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)},
+		Rows:    []git.HeatmapRow{{Path: "main.go", Counts: []int{1, 9}}},
+	}
+
+	// Cells are colored by how busy they are compared to the busiest one
+	view := tview.NewTextView().SetDynamicColors(true)
+	renderHeatmap(view, heatmap, false)
+	if text := view.GetText(false); !strings.Contains(text, "[green] 1[-]") || !strings.Contains(text, "[red] 9[-]") {
+		t.Errorf("Expected a green and a red cell, got: %q", text)
+	}
+
+	view = tview.NewTextView().SetDynamicColors(true)
+	renderHeatmap(view, heatmap, true)
+	if text := view.GetText(false); strings.Contains(text, "[green]") || !strings.Contains(text, " 1 9  main.go") {
+		t.Errorf("Expected an uncolored grid, got: %q", text)
+	}
+}
+
+func TestNewHotspotTreeNode(t *testing.T) {
+	root := git.BuildHotspotTree([]git.Hotspot{{Path: "src/app/main.go", Commits: 2, Score: 2}})
+
+	// Only the root starts expanded, so the top level shows first
+	node := newHotspotTreeNode(root, 0)
+	if !node.IsExpanded() || len(node.GetChildren()) != 1 {
+		t.Fatalf("Expected an expanded root with one child")
+	}
+	src := node.GetChildren()[0]
+	if src.IsExpanded() || src.GetText() != "src (2 commits, score 2.0)" {
+		t.Errorf("Expected a collapsed src, got %q expanded %v", src.GetText(), src.IsExpanded())
+	}
+	if app := src.GetChildren()[0]; len(app.GetChildren()) != 1 {
+		t.Errorf("Expected main.go nested under src/app")
+	}
+}
+
+func TestHotspotPanesToggleMetric(t *testing.T) {
+	now := time.Now()
+	hotspots := []git.Hotspot{
+		{Path: "busy.go", Commits: 5, Score: 10, LinesAdded: 8, LinesDeleted: 2, FirstSeen: now, LastModified: now},
+		{Path: "big.go", Commits: 1, Score: 500, LinesAdded: 400, LinesDeleted: 100, FirstSeen: now, LastModified: now},
+	}
+
+	// Ranked by churn, as with --rank-by churn
+	panes := newHotspotPanes(report.Options{Metric: report.MetricChurn})
+	panes.setTitles("1y")
+	panes.render(hotspots, nil, 10, now.AddDate(-1, 0, 0))
+	if panes.files[0].Path != "big.go" {
+		t.Errorf("Expected big.go first by lines changed, got %s", panes.files[0].Path)
+	}
+	if title := panes.fileTextView.GetTitle(); !strings.Contains(title, "by lines changed") {
+		t.Errorf("Expected the metric in the title, got %q", title)
+	}
+
+	// Pressing c re-sorts by commits in place
+	if panes.handleKey(tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone)) != nil {
+		t.Fatalf("Expected c to be handled once lines are counted")
+	}
+	if panes.files[0].Path != "busy.go" {
+		t.Errorf("Expected busy.go first by commits, got %s", panes.files[0].Path)
+	}
+	if title := panes.fileTextView.GetTitle(); !strings.Contains(title, "by commits") {
+		t.Errorf("Expected the metric in the title, got %q", title)
+	}
+	if !strings.Contains(panes.fileTextView.GetText(true), "Commits  Top Contributor") {
+		t.Errorf("Expected the commits column, got %q", panes.fileTextView.GetText(true))
+	}
+
+	// Without counted lines, c is left to the caller to count them
+	panes = newHotspotPanes(report.Options{})
+	if panes.handleKey(tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone)) == nil {
+		t.Errorf("Expected c to be passed on when lines weren't counted")
+	}
+}
+
+func TestHotspotPanesCompact(t *testing.T) {
+	now := time.Now()
+	hotspots := []git.Hotspot{{Path: "internal/git/accumulator.go", Commits: 1, Score: 1, TopContributor: "Test User", FirstSeen: now, LastModified: now}}
+
+	// Tables are fitted to the panes once they're drawn at their width
+	panes := newHotspotPanes(report.Options{Compact: true})
+	panes.setTitles("1y")
+	panes.render(hotspots, nil, 10, now.AddDate(-1, 0, 0))
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("Failed to create screen: %v", err)
+	}
+	screen.SetSize(70, 20)
+	panes.flex.SetRect(0, 0, 70, 20)
+	panes.flex.Draw(screen)
+
+	text := panes.fileTextView.GetText(true)
+	if strings.Contains(text, "Test User") || !strings.Contains(text, "…") {
+		t.Errorf("Expected a compact table with a shortened path, got %q", text)
+	}
+}
+
+func TestHotspotPanesShortenPaths(t *testing.T) {
+	now := time.Now()
+	path := "src/api/v2/internal/handlers/user.go"
+	hotspots := []git.Hotspot{{Path: path, Commits: 1, Score: 1, TopContributor: "Test User", FirstSeen: now, LastModified: now}}
+
+	// Paths are shortened to fit the pane, keeping the other columns
+	panes := newHotspotPanes(report.Options{})
+	panes.setTitles("1y")
+	panes.render(hotspots, nil, 10, now.AddDate(-1, 0, 0))
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("Failed to create screen: %v", err)
+	}
+	screen.SetSize(120, 20)
+	panes.flex.SetRect(0, 0, 120, 20)
+	panes.flex.Draw(screen)
+
+	text := panes.fileTextView.GetText(true)
+	if !strings.Contains(text, "Test User") || strings.Contains(text, path) || !strings.Contains(text, "  src/…/") {
+		t.Errorf("Expected the full table with a shortened path, got %q", text)
+	}
+
+	// while the detail pane shows it whole
+	panes.setDetailShown(true)
+	if detail := panes.detailView.GetText(true); !strings.HasPrefix(detail, "Path: "+path+"\n") {
+		t.Errorf("Expected the full path in the detail pane, got %q", detail)
+	}
+}